@@ -0,0 +1,188 @@
+package kinesisstream
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// The aggregated records are compatible with the Kinesis Producer Library: they start with the magic
+// bytes which are followed by an AggregatedRecord protobuf message and its MD5 checksum.
+var aggregationMagic = []byte{0xf3, 0x89, 0x9a, 0xc2}
+
+// Protobuf fields of the aggregated records
+const (
+	fieldPartitionKeyTable = 1
+	fieldRecords           = 3
+
+	fieldPartitionKeyIndex = 1
+	fieldData              = 3
+
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+var errInvalidAggregation = errors.New("invalid aggregated record")
+
+// hashKey returns the hash key of the partition key as Kinesis computes it.
+func hashKey(partitionKey string) *big.Int {
+	sum := md5.Sum([]byte(partitionKey))
+	return new(big.Int).SetBytes(sum[:])
+}
+
+// aggregator collects the records into an aggregated record.
+type aggregator struct {
+	partitionKeys map[string]uint64
+	keyTable      [][]byte
+	records       [][]byte
+	size          int
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{partitionKeys: make(map[string]uint64)}
+}
+
+// addedSize returns the size of the aggregated record after adding the record.
+func (a *aggregator) addedSize(record *Record) int {
+	size := a.size + len(record.Data) + 3*binary.MaxVarintLen64
+	if _, ok := a.partitionKeys[record.PartitionKey]; !ok {
+		size += len(record.PartitionKey) + 2*binary.MaxVarintLen64
+	}
+	return size
+}
+
+func (a *aggregator) add(record *Record) {
+	a.size = a.addedSize(record)
+	index, ok := a.partitionKeys[record.PartitionKey]
+	if !ok {
+		index = uint64(len(a.keyTable))
+		a.partitionKeys[record.PartitionKey] = index
+		a.keyTable = append(a.keyTable, appendBytesField(nil, fieldPartitionKeyTable, []byte(record.PartitionKey)))
+	}
+	var encoded []byte
+	encoded = appendVarintField(encoded, fieldPartitionKeyIndex, index)
+	encoded = appendBytesField(encoded, fieldData, record.Data)
+	a.records = append(a.records, appendBytesField(nil, fieldRecords, encoded))
+}
+
+func (a *aggregator) count() int {
+	return len(a.records)
+}
+
+// bytes returns the aggregated record.
+func (a *aggregator) bytes() []byte {
+	var message []byte
+	for _, key := range a.keyTable {
+		message = append(message, key...)
+	}
+	for _, record := range a.records {
+		message = append(message, record...)
+	}
+	sum := md5.Sum(message)
+	data := append([]byte{}, aggregationMagic...)
+	data = append(data, message...)
+	return append(data, sum[:]...)
+}
+
+// deaggregate returns the records in the aggregated record or the record itself if it is not aggregated.
+func deaggregate(record *Record) ([]*Record, error) {
+	data := record.Data
+	if len(data) < len(aggregationMagic)+md5.Size || !bytes.HasPrefix(data, aggregationMagic) {
+		return []*Record{record}, nil
+	}
+	message := data[len(aggregationMagic) : len(data)-md5.Size]
+	if sum := md5.Sum(message); !bytes.Equal(sum[:], data[len(data)-md5.Size:]) {
+		// not an aggregated record
+		return []*Record{record}, nil
+	}
+
+	var (
+		keyTable []string
+		encoded  [][]byte
+	)
+	err := readFields(message, func(field int, value []byte, _ uint64) error {
+		switch field {
+		case fieldPartitionKeyTable:
+			keyTable = append(keyTable, string(value))
+		case fieldRecords:
+			encoded = append(encoded, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, len(encoded))
+	for i, recordMessage := range encoded {
+		sub := *record
+		sub.SubSequenceNumber = int64(i)
+		sub.Data = nil
+		err := readFields(recordMessage, func(field int, value []byte, number uint64) error {
+			switch field {
+			case fieldPartitionKeyIndex:
+				if number >= uint64(len(keyTable)) {
+					return errInvalidAggregation
+				}
+				sub.PartitionKey = keyTable[number]
+			case fieldData:
+				sub.Data = value
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		records[i] = &sub
+	}
+	return records, nil
+}
+
+func appendVarintField(b []byte, field int, value uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|wireVarint))
+	return binary.AppendUvarint(b, value)
+}
+
+func appendBytesField(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|wireBytes))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// readFields reads the varint and the length-delimited fields of a protobuf message.
+func readFields(message []byte, fn func(field int, value []byte, number uint64) error) error {
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return errInvalidAggregation
+		}
+		message = message[n:]
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case wireVarint:
+			number, n := binary.Uvarint(message)
+			if n <= 0 {
+				return errInvalidAggregation
+			}
+			message = message[n:]
+			if err := fn(field, nil, number); err != nil {
+				return err
+			}
+		case wireBytes:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return errInvalidAggregation
+			}
+			value := message[n : n+int(length)]
+			message = message[n+int(length):]
+			if err := fn(field, value, 0); err != nil {
+				return err
+			}
+		default:
+			return errInvalidAggregation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package kinesisstream
+
+import (
+	"crypto/md5"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregation(t *testing.T) {
+	r := require.New(t)
+
+	agg := newAggregator()
+	records := []*Record{
+		{PartitionKey: "a", Data: []byte("1")},
+		{PartitionKey: "b", Data: []byte("2")},
+		{PartitionKey: "a", Data: []byte{}},
+	}
+	for _, record := range records {
+		size := agg.addedSize(record)
+		agg.add(record)
+		r.Equal(size, agg.size)
+	}
+	r.Equal(3, agg.count())
+	data := agg.bytes()
+	r.LessOrEqual(len(data), agg.size+len(aggregationMagic)+md5.Size)
+
+	deaggregated, err := deaggregate(&Record{
+		PartitionKey:   "a",
+		Data:           data,
+		ShardID:        "shard",
+		SequenceNumber: "1",
+	})
+	r.NoError(err)
+	r.Len(deaggregated, 3)
+	for i, record := range deaggregated {
+		r.Equal(records[i].PartitionKey, record.PartitionKey)
+		r.Equal(string(records[i].Data), string(record.Data))
+		r.Equal("shard", record.ShardID)
+		r.Equal("1", record.SequenceNumber)
+		r.Equal(int64(i), record.SubSequenceNumber)
+	}
+}
+
+func TestDeaggregate_NotAggregated(t *testing.T) {
+	r := require.New(t)
+
+	for _, data := range [][]byte{
+		[]byte("plain"),
+		append(append([]byte{}, aggregationMagic...), make([]byte, md5.Size)...),
+	} {
+		record := &Record{PartitionKey: "a", Data: data}
+		records, err := deaggregate(record)
+		r.NoError(err)
+		r.Equal([]*Record{record}, records)
+	}
+}
+
+func TestDeaggregate_Invalid(t *testing.T) {
+	r := require.New(t)
+
+	// the record refers to a partition key which is not in the table
+	var encoded []byte
+	encoded = appendVarintField(encoded, fieldPartitionKeyIndex, 1)
+	message := appendBytesField(nil, fieldRecords, encoded)
+	sum := md5.Sum(message)
+	data := append(append(append([]byte{}, aggregationMagic...), message...), sum[:]...)
+
+	_, err := deaggregate(&Record{PartitionKey: "a", Data: data})
+	r.ErrorIs(err, errInvalidAggregation)
+}
@@ -0,0 +1,197 @@
+package kinesisstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/internal/shards"
+	"github.com/forta-network/core-go/store/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Consumer defaults
+const (
+	DefaultPollInterval = time.Second
+)
+
+// Handler handles the records which are read from a shard. The records of a partition key are
+// delivered in order. If the handler returns an error, the same records are delivered again after
+// the poll interval so the handlers should be idempotent.
+type Handler func(ctx context.Context, records []*Record) error
+
+// ConsumerConfig configures a consumer.
+type ConsumerConfig struct {
+	StreamName string
+	// CheckpointTableName is the table which stores the progress. It is created if it does not exist.
+	CheckpointTableName string
+	// ConsumerName identifies the consumer in the checkpoints. Only one instance of a consumer
+	// should run at a time. The lock package can be used for electing one.
+	ConsumerName string
+	// StartFromLatest skips the existing records of the shards which have no checkpoints. The child
+	// shards of the consumed shards are still consumed from their oldest records.
+	StartFromLatest bool
+	// BatchSize is the maximum number of Kinesis records per handler call. It is 10000 if zero.
+	// The aggregated records are delivered together so a call can have more records.
+	BatchSize int32
+	// PollInterval is the wait time after reading no records or failing.
+	PollInterval time.Duration
+	// ShardRefreshInterval is how often the new shards are discovered.
+	ShardRefreshInterval time.Duration
+}
+
+// Consumer consumes a Kinesis stream and delivers the deaggregated records with at-least-once
+// semantics. The child shards are consumed after their parents are finished.
+type Consumer struct {
+	client  aws.KinesisClient
+	dynamo  aws.DynamoDBClient
+	config  ConsumerConfig
+	handler Handler
+}
+
+// NewConsumer creates a new consumer.
+func NewConsumer(client aws.KinesisClient, dynamoClient aws.DynamoDBClient, config ConsumerConfig, handler Handler) (*Consumer, error) {
+	if len(config.StreamName) == 0 {
+		return nil, errors.New("stream name is required")
+	}
+	if len(config.CheckpointTableName) == 0 || len(config.ConsumerName) == 0 {
+		return nil, errors.New("checkpoint table name and consumer name are required")
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.ShardRefreshInterval == 0 {
+		config.ShardRefreshInterval = DefaultShardRefreshInterval
+	}
+	return &Consumer{
+		client:  client,
+		dynamo:  dynamoClient,
+		config:  config,
+		handler: handler,
+	}, nil
+}
+
+// CheckpointTableSchema returns the schema of the checkpoint table.
+func CheckpointTableSchema(tableName string) *dynamo.TableSchema {
+	return shards.CheckpointTableSchema(tableName)
+}
+
+// Run consumes the stream until the context is canceled or an aggregated record cannot be
+// deaggregated. It returns nil when the context is canceled.
+func (c *Consumer) Run(ctx context.Context) error {
+	if err := dynamo.EnsureTable(ctx, c.dynamo, CheckpointTableSchema(c.config.CheckpointTableName)); err != nil {
+		return err
+	}
+	return shards.NewConsumer[*Record](c.dynamo, &shardStream{
+		client: c.client,
+		config: c.config,
+	}, shards.Config{
+		StreamName:           c.config.StreamName,
+		CheckpointTableName:  c.config.CheckpointTableName,
+		ConsumerName:         c.config.ConsumerName,
+		StartFromLatest:      c.config.StartFromLatest,
+		PollInterval:         c.config.PollInterval,
+		ShardRefreshInterval: c.config.ShardRefreshInterval,
+	}, shards.Handler[*Record](c.handler)).Run(ctx)
+}
+
+// shardStream reads the shards of a Kinesis stream for the shard consumer.
+type shardStream struct {
+	client aws.KinesisClient
+	config ConsumerConfig
+}
+
+func (s *shardStream) ListShards(ctx context.Context) ([]shards.Shard, error) {
+	kinesisShards, err := listShards(ctx, s.client, s.config.StreamName)
+	if err != nil {
+		return nil, err
+	}
+	shardList := make([]shards.Shard, len(kinesisShards))
+	for i, shard := range kinesisShards {
+		shardList[i] = shards.Shard{
+			ID:     *shard.ShardId,
+			Closed: shard.SequenceNumberRange != nil && shard.SequenceNumberRange.EndingSequenceNumber != nil,
+		}
+		// the merged shards have two parents
+		for _, parentID := range []*string{shard.ParentShardId, shard.AdjacentParentShardId} {
+			if parentID != nil {
+				shardList[i].ParentIDs = append(shardList[i].ParentIDs, *parentID)
+			}
+		}
+	}
+	return shardList, nil
+}
+
+// ShardIterator returns an iterator after the sequence number. If the sequence number is empty
+// or no longer valid, the iterator starts from the oldest or the latest record.
+func (s *shardStream) ShardIterator(ctx context.Context, shardID, sequenceNumber string, latest bool) (*string, error) {
+	input := &kinesis.GetShardIteratorInput{
+		StreamName:        &s.config.StreamName,
+		ShardId:           &shardID,
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	}
+	switch {
+	case len(sequenceNumber) > 0:
+		input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		input.StartingSequenceNumber = &sequenceNumber
+	case latest:
+		input.ShardIteratorType = types.ShardIteratorTypeLatest
+	}
+	res, err := s.client.GetShardIterator(ctx, input)
+	var invalidErr *types.InvalidArgumentException
+	if errors.As(err, &invalidErr) && len(sequenceNumber) > 0 {
+		log.WithError(err).WithField("stream", s.config.StreamName).WithField("shard", shardID).
+			Warn("checkpoint is not valid, some records can be lost")
+		return s.ShardIterator(ctx, shardID, "", false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.ShardIterator, nil
+}
+
+func (s *shardStream) GetRecords(ctx context.Context, shardID string, iterator *string) (*shards.Records[*Record], error) {
+	input := &kinesis.GetRecordsInput{ShardIterator: iterator}
+	if s.config.BatchSize > 0 {
+		input.Limit = &s.config.BatchSize
+	}
+	res, err := s.client.GetRecords(ctx, input)
+	var expiredErr *types.ExpiredIteratorException
+	if errors.As(err, &expiredErr) {
+		return nil, fmt.Errorf("%w: %v", shards.ErrExpiredIterator, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := &shards.Records[*Record]{NextIterator: res.NextShardIterator}
+	for _, kinesisRecord := range res.Records {
+		deaggregated, err := deaggregate(makeRecord(shardID, kinesisRecord))
+		if err != nil {
+			return nil, &shards.RecordError{
+				Err: fmt.Errorf("failed to deaggregate record %s of shard %s: %w", strValue(kinesisRecord.SequenceNumber), shardID, err),
+			}
+		}
+		records.Records = append(records.Records, deaggregated...)
+		records.SequenceNumber = strValue(kinesisRecord.SequenceNumber)
+	}
+	return records, nil
+}
+
+func makeRecord(shardID string, record types.Record) *Record {
+	var arrivedAt time.Time
+	if record.ApproximateArrivalTimestamp != nil {
+		arrivedAt = *record.ApproximateArrivalTimestamp
+	}
+	return &Record{
+		PartitionKey:   strValue(record.PartitionKey),
+		Data:           record.Data,
+		ShardID:        shardID,
+		SequenceNumber: strValue(record.SequenceNumber),
+		ArrivedAt:      arrivedAt,
+	}
+}
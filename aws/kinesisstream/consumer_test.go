@@ -0,0 +1,193 @@
+package kinesisstream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/internal/shards/shardstest"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCheckpointTableName = "test-checkpoints"
+	testConsumerName        = "test-consumer"
+)
+
+func testKinesisRecord(sequenceNumber, partitionKey string, data []byte) types.Record {
+	return types.Record{
+		SequenceNumber:              aws.String(sequenceNumber),
+		PartitionKey:                aws.String(partitionKey),
+		Data:                        data,
+		ApproximateArrivalTimestamp: aws.Time(time.UnixMilli(1700000000000)),
+	}
+}
+
+// expectStream sets up a stream which has two closed parent shards and an open shard which is merged from them.
+func expectStream(client *mock_aws.MockKinesisClient, records map[string][]types.Record) {
+	closedShard := func(id string) types.Shard {
+		return types.Shard{
+			ShardId: aws.String(id),
+			SequenceNumberRange: &types.SequenceNumberRange{
+				StartingSequenceNumber: aws.String("0"),
+				EndingSequenceNumber:   aws.String("999"),
+			},
+		}
+	}
+	client.EXPECT().ListShards(gomock.Any(), gomock.Any()).Return(&kinesis.ListShardsOutput{
+		Shards: []types.Shard{
+			{
+				ShardId:               aws.String("merged"),
+				ParentShardId:         aws.String("parent-1"),
+				AdjacentParentShardId: aws.String("parent-2"),
+				SequenceNumberRange:   &types.SequenceNumberRange{StartingSequenceNumber: aws.String("0")},
+			},
+			closedShard("parent-1"),
+			closedShard("parent-2"),
+		},
+	}, nil).AnyTimes()
+
+	client.EXPECT().GetShardIterator(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kinesis.GetShardIteratorInput, _ ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error) {
+			iterator := *input.ShardId + "/" + string(input.ShardIteratorType)
+			if input.StartingSequenceNumber != nil {
+				iterator += "/" + *input.StartingSequenceNumber
+			}
+			return &kinesis.GetShardIteratorOutput{ShardIterator: aws.String(iterator)}, nil
+		}).AnyTimes()
+
+	var (
+		mu      sync.Mutex
+		expired bool
+	)
+	client.EXPECT().GetRecords(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kinesis.GetRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error) {
+			iterator := *input.ShardIterator
+			switch iterator {
+			case "parent-2/TRIM_HORIZON":
+				// the first iterator expires
+				mu.Lock()
+				defer mu.Unlock()
+				if !expired {
+					expired = true
+					return nil, &types.ExpiredIteratorException{}
+				}
+				fallthrough
+			case "parent-1/TRIM_HORIZON":
+				return &kinesis.GetRecordsOutput{Records: records[iterator]}, nil
+			}
+			// the open shard has no more records after the first read
+			return &kinesis.GetRecordsOutput{
+				Records:           records[iterator],
+				NextShardIterator: aws.String("merged/empty"),
+			}, nil
+		}).AnyTimes()
+}
+
+func newTestConsumer(t *testing.T, client *mock_aws.MockKinesisClient, dynamoClient *memdb.Client, handler *shardstest.Handler[*Record]) *Consumer {
+	consumer, err := NewConsumer(client, dynamoClient, ConsumerConfig{
+		StreamName:           testStreamName,
+		CheckpointTableName:  testCheckpointTableName,
+		ConsumerName:         testConsumerName,
+		PollInterval:         time.Millisecond,
+		ShardRefreshInterval: time.Millisecond * 10,
+	}, handler.Handle)
+	require.NoError(t, err)
+	return consumer
+}
+
+func TestConsumer(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockKinesisClient(ctrl)
+	dynamoClient := memdb.NewClient()
+
+	agg := newAggregator()
+	agg.add(&Record{PartitionKey: "a", Data: []byte("1")})
+	agg.add(&Record{PartitionKey: "b", Data: []byte("2")})
+	expectStream(client, map[string][]types.Record{
+		"parent-1/TRIM_HORIZON":          {testKinesisRecord("1", "a", agg.bytes())},
+		"parent-2/TRIM_HORIZON":          {testKinesisRecord("2", "c", []byte("3"))},
+		"merged/TRIM_HORIZON":            {testKinesisRecord("3", "a", []byte("4"))},
+		"merged/AFTER_SEQUENCE_NUMBER/3": {testKinesisRecord("4", "b", []byte("5"))},
+	})
+
+	// the first delivery fails and it is retried
+	handler := shardstest.NewHandler[*Record](1)
+	cancel, errCh := shardstest.Run(newTestConsumer(t, client, dynamoClient, handler))
+	for i := 0; i < 3; i++ {
+		<-handler.Handled
+	}
+	cancel()
+	r.NoError(<-errCh)
+
+	r.Len(handler.Records, 4)
+	var parentRecords []string
+	for _, record := range handler.Records[:3] {
+		parentRecords = append(parentRecords, record.PartitionKey+"="+string(record.Data))
+	}
+	r.ElementsMatch([]string{"a=1", "b=2", "c=3"}, parentRecords)
+	for _, record := range handler.Records[:3] {
+		if record.ShardID == "parent-1" {
+			r.Equal("1", record.SequenceNumber)
+			r.Equal(int64(1700000000000), record.ArrivedAt.UnixMilli())
+			r.Equal(map[string]int64{"a": 0, "b": 1}[record.PartitionKey], record.SubSequenceNumber)
+		}
+	}
+	// the merged shard is consumed after both parents
+	r.Equal(&Record{
+		PartitionKey:   "a",
+		Data:           []byte("4"),
+		ShardID:        "merged",
+		SequenceNumber: "3",
+		ArrivedAt:      time.UnixMilli(1700000000000),
+	}, handler.Records[3])
+
+	// continues from the checkpoints
+	handler = shardstest.NewHandler[*Record](0)
+	cancel, errCh = shardstest.Run(newTestConsumer(t, client, dynamoClient, handler))
+	<-handler.Handled
+	cancel()
+	r.NoError(<-errCh)
+
+	r.Len(handler.Records, 1)
+	r.Equal("5", string(handler.Records[0].Data))
+}
+
+func TestConsumer_Validation(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockKinesisClient(ctrl)
+	dynamoClient := memdb.NewClient()
+
+	_, err := NewConsumer(client, dynamoClient, ConsumerConfig{}, nil)
+	r.Error(err)
+	_, err = NewConsumer(client, dynamoClient, ConsumerConfig{StreamName: testStreamName}, nil)
+	r.Error(err)
+
+	// the checkpoint table is created
+	consumer, err := NewConsumer(client, dynamoClient, ConsumerConfig{
+		StreamName:          testStreamName,
+		CheckpointTableName: testCheckpointTableName,
+		ConsumerName:        testConsumerName,
+	}, nil)
+	r.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	client.EXPECT().ListShards(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kinesis.ListShardsInput, _ ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+			cancel()
+			return nil, ctx.Err()
+		})
+	r.NoError(consumer.Run(ctx))
+	_, err = dynamoClient.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String(testCheckpointTableName)})
+	r.NoError(err)
+}
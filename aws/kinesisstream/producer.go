@@ -0,0 +1,421 @@
+package kinesisstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/internal/buffer"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Producer defaults
+const (
+	DefaultFlushInterval        = time.Millisecond * 500
+	DefaultBufferSize           = 10000
+	DefaultMaxAggregationSize   = 50 * 1024
+	DefaultMaxAttempts          = 5
+	DefaultRetryInterval        = time.Millisecond * 200
+	DefaultShardRefreshInterval = time.Minute
+	// MaxRecordSize is the maximum size of the data and the partition key of a record.
+	MaxRecordSize     = 1024 * 1024
+	maxRequestRecords = 500
+	maxRequestSize    = 5 * 1024 * 1024
+)
+
+// Producer errors
+var (
+	ErrProducerClosed = errors.New("producer is closed")
+	ErrRecordTooLarge = errors.New("record is too large")
+)
+
+// Record is a Kinesis record. The shard and the sequence fields are set only for the consumed records.
+type Record struct {
+	PartitionKey string
+	Data         []byte
+
+	ShardID        string
+	SequenceNumber string
+	// SubSequenceNumber is the index of the record in its aggregated record.
+	SubSequenceNumber int64
+	ArrivedAt         time.Time
+}
+
+func (record *Record) size() int {
+	return len(record.PartitionKey) + len(record.Data)
+}
+
+// PutError is the error of the records which could not be put.
+type PutError struct {
+	Records []*Record
+	Err     error
+}
+
+func (e *PutError) Error() string {
+	return fmt.Sprintf("failed to put %d records: %v", len(e.Records), e.Err)
+}
+
+func (e *PutError) Unwrap() error {
+	return e.Err
+}
+
+// ProducerConfig configures a producer.
+type ProducerConfig struct {
+	StreamName string
+	// FlushInterval is the maximum duration which the records are buffered for.
+	FlushInterval time.Duration
+	// BufferSize is the number of the records which can be buffered before Put blocks.
+	BufferSize int
+	// DisableAggregation sends every record as a separate Kinesis record.
+	DisableAggregation bool
+	// MaxAggregationSize is the maximum size of an aggregated record.
+	MaxAggregationSize int
+	// MaxAttempts is how many times the failed records are put.
+	MaxAttempts int
+	// RetryInterval is the wait time before the first retry. It is doubled on every retry.
+	RetryInterval time.Duration
+	// ShardRefreshInterval is how often the shard hash key ranges are refreshed for aggregation.
+	ShardRefreshInterval time.Duration
+}
+
+// shardRange is the hash key range of an open shard.
+type shardRange struct {
+	shardID    string
+	start, end *big.Int
+}
+
+// entry is a Kinesis record which contains one or more aggregated records.
+type entry struct {
+	request types.PutRecordsRequestEntry
+	records []*Record
+}
+
+// Producer buffers the records and puts them in batches. The records which are in the same shard
+// are aggregated in the format of the Kinesis Producer Library, so they are processed in order by
+// the consumers which deaggregate them. The failed records are retried and the ones which are not
+// put in the max attempts are returned by the next Flush or Close. The retries can change the order
+// of the records.
+type Producer struct {
+	buffer *buffer.Buffer[*Record]
+	// batcher is used only by the buffer.
+	batcher *batcher
+}
+
+// batcher aggregates the records and puts them by the request limits.
+type batcher struct {
+	client  aws.KinesisClient
+	config  ProducerConfig
+	records []*Record
+	size    int
+
+	shards          []shardRange
+	shardsUpdatedAt time.Time
+}
+
+// NewProducer creates a new producer.
+func NewProducer(client aws.KinesisClient, config ProducerConfig) (*Producer, error) {
+	if len(config.StreamName) == 0 {
+		return nil, errors.New("stream name is required")
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+	if config.BufferSize == 0 {
+		config.BufferSize = DefaultBufferSize
+	}
+	if config.MaxAggregationSize == 0 {
+		config.MaxAggregationSize = DefaultMaxAggregationSize
+	}
+	if config.MaxAggregationSize > MaxRecordSize {
+		return nil, fmt.Errorf("max aggregation size (%d) exceeds the max record size (%d)", config.MaxAggregationSize, MaxRecordSize)
+	}
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+	if config.ShardRefreshInterval == 0 {
+		config.ShardRefreshInterval = DefaultShardRefreshInterval
+	}
+	b := &batcher{client: client, config: config}
+	return &Producer{
+		buffer: buffer.New[*Record](buffer.Config{
+			Size:          config.BufferSize,
+			FlushInterval: config.FlushInterval,
+			ErrClosed:     ErrProducerClosed,
+		}, b),
+		batcher: b,
+	}, nil
+}
+
+// Put buffers the record.
+func (p *Producer) Put(ctx context.Context, partitionKey string, data []byte) error {
+	record := &Record{PartitionKey: partitionKey, Data: data}
+	if len(partitionKey) == 0 {
+		return errors.New("empty partition key provided")
+	}
+	if record.size() > MaxRecordSize {
+		return fmt.Errorf("%w: %d bytes", ErrRecordTooLarge, record.size())
+	}
+	return p.buffer.Put(ctx, record)
+}
+
+// Flush puts the buffered records and returns the errors of the records which could not be put
+// since the previous flush. If the context is done before the errors are returned, they are
+// returned by the next flush.
+func (p *Producer) Flush(ctx context.Context) error {
+	return p.buffer.Flush(ctx)
+}
+
+// Close puts the buffered records and stops the producer. It returns the errors of the records
+// which could not be put since the previous flush.
+func (p *Producer) Close(ctx context.Context) error {
+	return p.buffer.Close(ctx)
+}
+
+// Add adds the record and puts the records if they reach the request size.
+func (b *batcher) Add(record *Record) []error {
+	b.records = append(b.records, record)
+	b.size += record.size()
+	if b.size >= maxRequestSize {
+		return b.Send()
+	}
+	return nil
+}
+
+// Send puts the records and retries the failed ones.
+func (b *batcher) Send() []error {
+	if len(b.records) == 0 {
+		return nil
+	}
+	errs := b.putRecords(b.records)
+	b.records, b.size = nil, 0
+	return errs
+}
+
+// putRecords puts the records and retries the failed ones. It returns the errors of the records
+// which could not be put.
+func (b *batcher) putRecords(records []*Record) []error {
+	var errs []error
+	pending := b.makeEntries(records)
+	retryInterval := b.config.RetryInterval
+	for attempt := 1; len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			time.Sleep(retryInterval)
+			retryInterval *= 2
+		}
+		var retry []*entry
+		for _, batch := range splitRequests(pending) {
+			failed := b.putBatch(batch)
+			for i, e := range batch {
+				err, ok := failed[i]
+				if !ok {
+					continue
+				}
+				if attempt < b.config.MaxAttempts {
+					retry = append(retry, e)
+					continue
+				}
+				log.WithError(err).WithField("stream", b.config.StreamName).Warnf("failed to put %d records", len(e.records))
+				errs = append(errs, &PutError{Records: e.records, Err: err})
+			}
+		}
+		pending = retry
+	}
+	return errs
+}
+
+// putBatch puts the entries and returns the errors of the failed ones by index.
+func (b *batcher) putBatch(batch []*entry) map[int]error {
+	requests := make([]types.PutRecordsRequestEntry, len(batch))
+	for i, e := range batch {
+		requests[i] = e.request
+	}
+	res, err := b.client.PutRecords(context.Background(), &kinesis.PutRecordsInput{
+		StreamName: &b.config.StreamName,
+		Records:    requests,
+	})
+	failed := make(map[int]error)
+	if err != nil {
+		for i := range batch {
+			failed[i] = err
+		}
+		return failed
+	}
+	for i, result := range res.Records {
+		if result.ErrorCode != nil {
+			failed[i] = fmt.Errorf("%s: %s", *result.ErrorCode, strValue(result.ErrorMessage))
+		}
+	}
+	return failed
+}
+
+// splitRequests splits the entries by the request limits.
+func splitRequests(entries []*entry) [][]*entry {
+	var (
+		batches [][]*entry
+		batch   []*entry
+		size    int
+	)
+	for _, e := range entries {
+		entrySize := len(e.request.Data) + len(strValue(e.request.PartitionKey))
+		if len(batch) == maxRequestRecords || size+entrySize > maxRequestSize {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, e)
+		size += entrySize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// makeEntries aggregates the records which are in the same shard. The records are aggregated by
+// partition key if the shards are unknown.
+func (b *batcher) makeEntries(records []*Record) []*entry {
+	if b.config.DisableAggregation {
+		entries := make([]*entry, len(records))
+		for i, record := range records {
+			entries[i] = newEntry([]*Record{record}, record.Data)
+		}
+		return entries
+	}
+
+	b.refreshShards()
+	var (
+		groups   []string
+		grouped  = make(map[string][]*Record)
+		entries  []*entry
+		shardKey = func(record *Record) string {
+			if shardID, ok := b.shardOf(record.PartitionKey); ok {
+				return shardID
+			}
+			return "key:" + record.PartitionKey
+		}
+	)
+	for _, record := range records {
+		key := shardKey(record)
+		if _, ok := grouped[key]; !ok {
+			groups = append(groups, key)
+		}
+		grouped[key] = append(grouped[key], record)
+	}
+	for _, key := range groups {
+		var (
+			agg        = newAggregator()
+			aggRecords []*Record
+		)
+		flushAggregate := func() {
+			switch len(aggRecords) {
+			case 0:
+				return
+			case 1:
+				// a single record is not aggregated
+				entries = append(entries, newEntry(aggRecords, aggRecords[0].Data))
+			default:
+				entries = append(entries, newEntry(aggRecords, agg.bytes()))
+			}
+			agg, aggRecords = newAggregator(), nil
+		}
+		for _, record := range grouped[key] {
+			if agg.count() > 0 && agg.addedSize(record) > b.config.MaxAggregationSize {
+				flushAggregate()
+			}
+			agg.add(record)
+			aggRecords = append(aggRecords, record)
+		}
+		flushAggregate()
+	}
+	return entries
+}
+
+func newEntry(records []*Record, data []byte) *entry {
+	partitionKey := records[0].PartitionKey
+	return &entry{
+		request: types.PutRecordsRequestEntry{
+			PartitionKey: &partitionKey,
+			Data:         data,
+		},
+		records: records,
+	}
+}
+
+// refreshShards updates the shard hash key ranges if they are stale.
+func (b *batcher) refreshShards() {
+	if time.Since(b.shardsUpdatedAt) < b.config.ShardRefreshInterval {
+		return
+	}
+	shards, err := listShards(context.Background(), b.client, b.config.StreamName)
+	if err != nil {
+		log.WithError(err).WithField("stream", b.config.StreamName).Warn("failed to list shards")
+		return
+	}
+	var ranges []shardRange
+	for _, shard := range shards {
+		// the closed shards do not receive records
+		if shard.SequenceNumberRange != nil && shard.SequenceNumberRange.EndingSequenceNumber != nil {
+			continue
+		}
+		if shard.HashKeyRange == nil {
+			continue
+		}
+		start, ok1 := new(big.Int).SetString(strValue(shard.HashKeyRange.StartingHashKey), 10)
+		end, ok2 := new(big.Int).SetString(strValue(shard.HashKeyRange.EndingHashKey), 10)
+		if !ok1 || !ok2 {
+			continue
+		}
+		ranges = append(ranges, shardRange{shardID: *shard.ShardId, start: start, end: end})
+	}
+	b.shards = ranges
+	b.shardsUpdatedAt = time.Now()
+}
+
+func (b *batcher) shardOf(partitionKey string) (string, bool) {
+	key := hashKey(partitionKey)
+	for _, shard := range b.shards {
+		if key.Cmp(shard.start) >= 0 && key.Cmp(shard.end) <= 0 {
+			return shard.shardID, true
+		}
+	}
+	return "", false
+}
+
+// listShards lists all shards of the stream.
+func listShards(ctx context.Context, client aws.KinesisClient, streamName string) ([]types.Shard, error) {
+	var (
+		shards    []types.Shard
+		nextToken *string
+	)
+	for {
+		input := &kinesis.ListShardsInput{NextToken: nextToken}
+		// the stream name cannot be used with the next token
+		if nextToken == nil {
+			input.StreamName = &streamName
+		}
+		res, err := client.ListShards(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, res.Shards...)
+		nextToken = res.NextToken
+		if nextToken == nil {
+			return shards, nil
+		}
+	}
+}
+
+func strValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
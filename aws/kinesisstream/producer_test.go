@@ -0,0 +1,263 @@
+package kinesisstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testStreamName = "test-stream"
+
+// testShards returns two open shards which split the hash key space and a closed parent shard.
+func testShards() []types.Shard {
+	max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	middle := new(big.Int).Rsh(max, 1)
+	shard := func(id, start, end string, closed bool) types.Shard {
+		shard := types.Shard{
+			ShardId:             aws.String(id),
+			HashKeyRange:        &types.HashKeyRange{StartingHashKey: aws.String(start), EndingHashKey: aws.String(end)},
+			SequenceNumberRange: &types.SequenceNumberRange{StartingSequenceNumber: aws.String("0")},
+		}
+		if closed {
+			shard.SequenceNumberRange.EndingSequenceNumber = aws.String("999")
+		} else {
+			shard.ParentShardId = aws.String("parent")
+		}
+		return shard
+	}
+	return []types.Shard{
+		shard("parent", "0", max.String(), true),
+		shard("shard-1", "0", middle.String(), false),
+		shard("shard-2", new(big.Int).Add(middle, big.NewInt(1)).String(), max.String(), false),
+	}
+}
+
+// testPuts records the put entries and fails the entries whose partition keys are in the failures.
+type testPuts struct {
+	mu       sync.Mutex
+	requests [][]types.PutRecordsRequestEntry
+	failures map[string]int
+}
+
+func (p *testPuts) put(input *kinesis.PutRecordsInput) *kinesis.PutRecordsOutput {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res := &kinesis.PutRecordsOutput{Records: make([]types.PutRecordsResultEntry, len(input.Records))}
+	for i, entry := range input.Records {
+		if p.failures[*entry.PartitionKey] > 0 {
+			p.failures[*entry.PartitionKey]--
+			res.Records[i].ErrorCode = aws.String("ProvisionedThroughputExceededException")
+			res.Records[i].ErrorMessage = aws.String("slow down")
+			res.FailedRecordCount = aws.Int32(aws.ToInt32(res.FailedRecordCount) + 1)
+			continue
+		}
+		res.Records[i].ShardId = aws.String("shard")
+		res.Records[i].SequenceNumber = aws.String(fmt.Sprint(i))
+	}
+	p.requests = append(p.requests, input.Records)
+	return res
+}
+
+func newTestProducer(t *testing.T, config ProducerConfig) (*Producer, *testPuts) {
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockKinesisClient(ctrl)
+	puts := &testPuts{failures: make(map[string]int)}
+	client.EXPECT().ListShards(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kinesis.ListShardsInput, _ ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+			// paginated
+			if input.NextToken == nil {
+				require.Equal(t, testStreamName, *input.StreamName)
+				return &kinesis.ListShardsOutput{Shards: testShards()[:2], NextToken: aws.String("next")}, nil
+			}
+			require.Nil(t, input.StreamName)
+			return &kinesis.ListShardsOutput{Shards: testShards()[2:]}, nil
+		}).AnyTimes()
+	client.EXPECT().PutRecords(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			require.Equal(t, testStreamName, *input.StreamName)
+			return puts.put(input), nil
+		}).AnyTimes()
+	config.StreamName = testStreamName
+	config.FlushInterval = time.Hour
+	config.RetryInterval = time.Millisecond
+	producer, err := NewProducer(client, config)
+	require.NoError(t, err)
+	return producer, puts
+}
+
+// deaggregateEntries returns the records in the entries as "key=data".
+func deaggregateEntries(t *testing.T, entries []types.PutRecordsRequestEntry) []string {
+	var records []string
+	for _, entry := range entries {
+		deaggregated, err := deaggregate(&Record{PartitionKey: *entry.PartitionKey, Data: entry.Data})
+		require.NoError(t, err)
+		for _, record := range deaggregated {
+			records = append(records, record.PartitionKey+"="+string(record.Data))
+		}
+	}
+	sort.Strings(records)
+	return records
+}
+
+func TestProducer_Aggregation(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, puts := newTestProducer(t, ProducerConfig{})
+
+	var expected []string
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%d", i%5)
+		data := fmt.Sprint(i)
+		expected = append(expected, key+"="+data)
+		r.NoError(producer.Put(ctx, key, []byte(data)))
+	}
+	r.NoError(producer.Close(ctx))
+	sort.Strings(expected)
+
+	r.Len(puts.requests, 1)
+	entries := puts.requests[0]
+	// the records are aggregated per shard
+	r.Len(entries, 2)
+	r.Equal(expected, deaggregateEntries(t, entries))
+	for _, entry := range entries {
+		deaggregated, err := deaggregate(&Record{PartitionKey: *entry.PartitionKey, Data: entry.Data})
+		r.NoError(err)
+		shardID, ok := producer.batcher.shardOf(*entry.PartitionKey)
+		r.True(ok)
+		for _, record := range deaggregated {
+			recordShardID, _ := producer.batcher.shardOf(record.PartitionKey)
+			r.Equal(shardID, recordShardID)
+		}
+	}
+
+	r.ErrorIs(producer.Put(ctx, "key", []byte("closed")), ErrProducerClosed)
+	r.ErrorIs(producer.Flush(ctx), ErrProducerClosed)
+}
+
+func TestProducer_MaxAggregationSize(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, puts := newTestProducer(t, ProducerConfig{MaxAggregationSize: 1000})
+
+	data := strings.Repeat("a", 400)
+	for i := 0; i < 5; i++ {
+		r.NoError(producer.Put(ctx, "key", []byte(data)))
+	}
+	r.NoError(producer.Flush(ctx))
+	r.Len(puts.requests, 1)
+	// two records fit in an aggregated record and the last one is not aggregated
+	entries := puts.requests[0]
+	r.Len(entries, 3)
+	for _, entry := range entries {
+		r.LessOrEqual(len(entry.Data), 1000)
+	}
+	r.Equal(data, string(entries[2].Data))
+	r.Len(deaggregateEntries(t, entries), 5)
+	r.NoError(producer.Close(ctx))
+}
+
+func TestProducer_DisableAggregation(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, puts := newTestProducer(t, ProducerConfig{DisableAggregation: true})
+
+	r.NoError(producer.Put(ctx, "a", []byte("1")))
+	r.NoError(producer.Put(ctx, "a", []byte("2")))
+	r.NoError(producer.Close(ctx))
+	r.Equal([][]types.PutRecordsRequestEntry{{
+		{PartitionKey: aws.String("a"), Data: []byte("1")},
+		{PartitionKey: aws.String("a"), Data: []byte("2")},
+	}}, puts.requests)
+}
+
+func TestProducer_Retries(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, puts := newTestProducer(t, ProducerConfig{DisableAggregation: true, MaxAttempts: 3})
+
+	puts.failures["retried"] = 2
+	puts.failures["exhausted"] = 3
+	for _, key := range []string{"ok", "retried", "exhausted"} {
+		r.NoError(producer.Put(ctx, key, []byte(key)))
+	}
+	err := producer.Flush(ctx)
+	r.Error(err)
+
+	var putKeys [][]string
+	for _, request := range puts.requests {
+		var keys []string
+		for _, entry := range request {
+			keys = append(keys, *entry.PartitionKey)
+		}
+		putKeys = append(putKeys, keys)
+	}
+	// only the failed records are retried
+	r.Equal([][]string{
+		{"ok", "retried", "exhausted"},
+		{"retried", "exhausted"},
+		{"retried", "exhausted"},
+	}, putKeys)
+
+	var putErr *PutError
+	r.ErrorAs(err, &putErr)
+	r.Len(putErr.Records, 1)
+	r.Equal("exhausted", putErr.Records[0].PartitionKey)
+
+	// the failures are returned once
+	r.NoError(producer.Close(ctx))
+}
+
+func TestProducer_RequestError(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockKinesisClient(ctrl)
+	producer, err := NewProducer(client, ProducerConfig{
+		StreamName:    testStreamName,
+		MaxAttempts:   2,
+		RetryInterval: time.Millisecond,
+	})
+	r.NoError(err)
+
+	// the records are aggregated by partition key when the shards are unknown
+	requestErr := errors.New("request failed")
+	client.EXPECT().ListShards(gomock.Any(), gomock.Any()).Return(nil, requestErr)
+	client.EXPECT().PutRecords(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			r.Len(input.Records, 2)
+			return nil, requestErr
+		}).Times(2)
+	r.NoError(producer.Put(ctx, "a", []byte("1")))
+	r.NoError(producer.Put(ctx, "b", []byte("2")))
+	r.NoError(producer.Put(ctx, "a", []byte("3")))
+	r.ErrorIs(producer.Close(ctx), requestErr)
+}
+
+func TestProducer_Validation(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+
+	_, err := NewProducer(nil, ProducerConfig{})
+	r.Error(err)
+	_, err = NewProducer(nil, ProducerConfig{StreamName: testStreamName, MaxAggregationSize: MaxRecordSize + 1})
+	r.Error(err)
+
+	producer, _ := newTestProducer(t, ProducerConfig{})
+	defer producer.Close(ctx)
+	r.Error(producer.Put(ctx, "", []byte("a")))
+	r.ErrorIs(producer.Put(ctx, "a", make([]byte, MaxRecordSize)), ErrRecordTooLarge)
+}
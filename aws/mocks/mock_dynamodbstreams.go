@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: aws/dynamodbstreams.go
+
+// Package mock_aws is a generated GoMock package.
+package mock_aws
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dynamodbstreams "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDynamoDBStreamsClient is a mock of DynamoDBStreamsClient interface.
+type MockDynamoDBStreamsClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockDynamoDBStreamsClientMockRecorder
+}
+
+// MockDynamoDBStreamsClientMockRecorder is the mock recorder for MockDynamoDBStreamsClient.
+type MockDynamoDBStreamsClientMockRecorder struct {
+	mock *MockDynamoDBStreamsClient
+}
+
+// NewMockDynamoDBStreamsClient creates a new mock instance.
+func NewMockDynamoDBStreamsClient(ctrl *gomock.Controller) *MockDynamoDBStreamsClient {
+	mock := &MockDynamoDBStreamsClient{ctrl: ctrl}
+	mock.recorder = &MockDynamoDBStreamsClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDynamoDBStreamsClient) EXPECT() *MockDynamoDBStreamsClientMockRecorder {
+	return m.recorder
+}
+
+// DescribeStream mocks base method.
+func (m *MockDynamoDBStreamsClient) DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DescribeStream", varargs...)
+	ret0, _ := ret[0].(*dynamodbstreams.DescribeStreamOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeStream indicates an expected call of DescribeStream.
+func (mr *MockDynamoDBStreamsClientMockRecorder) DescribeStream(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeStream", reflect.TypeOf((*MockDynamoDBStreamsClient)(nil).DescribeStream), varargs...)
+}
+
+// GetRecords mocks base method.
+func (m *MockDynamoDBStreamsClient) GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetRecords", varargs...)
+	ret0, _ := ret[0].(*dynamodbstreams.GetRecordsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecords indicates an expected call of GetRecords.
+func (mr *MockDynamoDBStreamsClientMockRecorder) GetRecords(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockDynamoDBStreamsClient)(nil).GetRecords), varargs...)
+}
+
+// GetShardIterator mocks base method.
+func (m *MockDynamoDBStreamsClient) GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetShardIterator", varargs...)
+	ret0, _ := ret[0].(*dynamodbstreams.GetShardIteratorOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShardIterator indicates an expected call of GetShardIterator.
+func (mr *MockDynamoDBStreamsClientMockRecorder) GetShardIterator(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShardIterator", reflect.TypeOf((*MockDynamoDBStreamsClient)(nil).GetShardIterator), varargs...)
+}
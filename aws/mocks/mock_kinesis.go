@@ -0,0 +1,136 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: aws/kinesis.go
+
+// Package mock_aws is a generated GoMock package.
+package mock_aws
+
+import (
+	context "context"
+	reflect "reflect"
+
+	kinesis "github.com/aws/aws-sdk-go-v2/service/kinesis"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockKinesisClient is a mock of KinesisClient interface.
+type MockKinesisClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockKinesisClientMockRecorder
+}
+
+// MockKinesisClientMockRecorder is the mock recorder for MockKinesisClient.
+type MockKinesisClientMockRecorder struct {
+	mock *MockKinesisClient
+}
+
+// NewMockKinesisClient creates a new mock instance.
+func NewMockKinesisClient(ctrl *gomock.Controller) *MockKinesisClient {
+	mock := &MockKinesisClient{ctrl: ctrl}
+	mock.recorder = &MockKinesisClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKinesisClient) EXPECT() *MockKinesisClientMockRecorder {
+	return m.recorder
+}
+
+// GetRecords mocks base method.
+func (m *MockKinesisClient) GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetRecords", varargs...)
+	ret0, _ := ret[0].(*kinesis.GetRecordsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecords indicates an expected call of GetRecords.
+func (mr *MockKinesisClientMockRecorder) GetRecords(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecords", reflect.TypeOf((*MockKinesisClient)(nil).GetRecords), varargs...)
+}
+
+// GetShardIterator mocks base method.
+func (m *MockKinesisClient) GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetShardIterator", varargs...)
+	ret0, _ := ret[0].(*kinesis.GetShardIteratorOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShardIterator indicates an expected call of GetShardIterator.
+func (mr *MockKinesisClientMockRecorder) GetShardIterator(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShardIterator", reflect.TypeOf((*MockKinesisClient)(nil).GetShardIterator), varargs...)
+}
+
+// ListShards mocks base method.
+func (m *MockKinesisClient) ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListShards", varargs...)
+	ret0, _ := ret[0].(*kinesis.ListShardsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShards indicates an expected call of ListShards.
+func (mr *MockKinesisClientMockRecorder) ListShards(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShards", reflect.TypeOf((*MockKinesisClient)(nil).ListShards), varargs...)
+}
+
+// PutRecord mocks base method.
+func (m *MockKinesisClient) PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutRecord", varargs...)
+	ret0, _ := ret[0].(*kinesis.PutRecordOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRecord indicates an expected call of PutRecord.
+func (mr *MockKinesisClientMockRecorder) PutRecord(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecord", reflect.TypeOf((*MockKinesisClient)(nil).PutRecord), varargs...)
+}
+
+// PutRecords mocks base method.
+func (m *MockKinesisClient) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutRecords", varargs...)
+	ret0, _ := ret[0].(*kinesis.PutRecordsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRecords indicates an expected call of PutRecords.
+func (mr *MockKinesisClientMockRecorder) PutRecords(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecords", reflect.TypeOf((*MockKinesisClient)(nil).PutRecords), varargs...)
+}
@@ -0,0 +1,76 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: aws/kms.go
+
+// Package mock_aws is a generated GoMock package.
+package mock_aws
+
+import (
+	context "context"
+	reflect "reflect"
+
+	kms "github.com/aws/aws-sdk-go-v2/service/kms"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockKMSClient is a mock of KMSClient interface.
+type MockKMSClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockKMSClientMockRecorder
+}
+
+// MockKMSClientMockRecorder is the mock recorder for MockKMSClient.
+type MockKMSClientMockRecorder struct {
+	mock *MockKMSClient
+}
+
+// NewMockKMSClient creates a new mock instance.
+func NewMockKMSClient(ctrl *gomock.Controller) *MockKMSClient {
+	mock := &MockKMSClient{ctrl: ctrl}
+	mock.recorder = &MockKMSClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKMSClient) EXPECT() *MockKMSClientMockRecorder {
+	return m.recorder
+}
+
+// Decrypt mocks base method.
+func (m *MockKMSClient) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Decrypt", varargs...)
+	ret0, _ := ret[0].(*kms.DecryptOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrypt indicates an expected call of Decrypt.
+func (mr *MockKMSClientMockRecorder) Decrypt(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrypt", reflect.TypeOf((*MockKMSClient)(nil).Decrypt), varargs...)
+}
+
+// GenerateDataKey mocks base method.
+func (m *MockKMSClient) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GenerateDataKey", varargs...)
+	ret0, _ := ret[0].(*kms.GenerateDataKeyOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateDataKey indicates an expected call of GenerateDataKey.
+func (mr *MockKMSClientMockRecorder) GenerateDataKey(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateDataKey", reflect.TypeOf((*MockKMSClient)(nil).GenerateDataKey), varargs...)
+}
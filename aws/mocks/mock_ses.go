@@ -54,3 +54,23 @@ func (mr *MockSESClientMockRecorder) SendEmail(ctx, params interface{}, optFns .
 	varargs := append([]interface{}{ctx, params}, optFns...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendEmail", reflect.TypeOf((*MockSESClient)(nil).SendEmail), varargs...)
 }
+
+// SendRawEmail mocks base method.
+func (m *MockSESClient) SendRawEmail(ctx context.Context, params *ses.SendRawEmailInput, optFns ...func(*ses.Options)) (*ses.SendRawEmailOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SendRawEmail", varargs...)
+	ret0, _ := ret[0].(*ses.SendRawEmailOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendRawEmail indicates an expected call of SendRawEmail.
+func (mr *MockSESClientMockRecorder) SendRawEmail(ctx, params interface{}, optFns ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendRawEmail", reflect.TypeOf((*MockSESClient)(nil).SendRawEmail), varargs...)
+}
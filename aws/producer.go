@@ -0,0 +1,335 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/forta-network/core-go/internal/buffer"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+// Producer defaults
+const (
+	DefaultProducerFlushInterval = time.Second
+	DefaultProducerBufferSize    = 1000
+	DefaultProducerMaxAttempts   = 3
+	DefaultProducerRetryInterval = time.Millisecond * 200
+	// MaxMessageSize is the maximum size of a message and a batch in SQS and SNS.
+	MaxMessageSize  = 256 * 1024
+	maxBatchEntries = 10
+)
+
+// The offloaded payloads are replaced with pointers in the format of the AWS extended clients.
+const (
+	s3PointerClass             = "software.amazon.payloadoffloading.PayloadS3Pointer"
+	extendedPayloadSizeAttrKey = "ExtendedPayloadSize"
+)
+
+// Producer errors
+var (
+	ErrProducerClosed  = errors.New("producer is closed")
+	ErrMessageTooLarge = errors.New("message is too large")
+)
+
+// ProducerMessage is a message which is sent by a producer.
+type ProducerMessage struct {
+	Body string
+	// GroupID and DeduplicationID are used by the FIFO queues and topics. The messages of a group
+	// are sent one per request, so a failed message is retried before the later messages of its
+	// group are sent.
+	GroupID         string
+	DeduplicationID string
+	// Attributes are sent as string message attributes.
+	Attributes map[string]string
+
+	// payloadSize is the size of the offloaded body.
+	payloadSize int
+}
+
+// size returns the size of the message as it is counted by SQS and SNS.
+func (msg *ProducerMessage) size() int {
+	size := len(msg.Body)
+	for name, value := range msg.Attributes {
+		size += len(name) + len("String") + len(value)
+	}
+	if msg.payloadSize > 0 {
+		size += len(extendedPayloadSizeAttrKey) + len("Number") + len(strconv.Itoa(msg.payloadSize))
+	}
+	return size
+}
+
+// ProducerError is the error of a message which could not be sent.
+type ProducerError struct {
+	Message *ProducerMessage
+	Err     error
+}
+
+func (e *ProducerError) Error() string {
+	return fmt.Sprintf("failed to send message: %v", e.Err)
+}
+
+func (e *ProducerError) Unwrap() error {
+	return e.Err
+}
+
+// batchEntryError is the error of a failed batch entry.
+type batchEntryError struct {
+	code        string
+	message     string
+	senderFault bool
+}
+
+func (e *batchEntryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+// ProducerOffloadConfig configures offloading the large message bodies to S3.
+type ProducerOffloadConfig struct {
+	Uploader S3Uploader
+	Bucket   string
+	// KeyPrefix is prepended to the object keys.
+	KeyPrefix string
+	// Threshold is the message size above which the bodies are offloaded. It is the max message size if zero.
+	Threshold int
+}
+
+// ProducerConfig configures a producer.
+type ProducerConfig struct {
+	// FlushInterval is the maximum duration which the messages are buffered for.
+	FlushInterval time.Duration
+	// BufferSize is the number of the messages which can be buffered before Send blocks.
+	BufferSize int
+	// MaxAttempts is how many times the failed messages are sent.
+	MaxAttempts int
+	// RetryInterval is the wait time before the first retry. It is doubled on every retry.
+	RetryInterval time.Duration
+	// Offload enables offloading the large messages if it is not nil.
+	Offload *ProducerOffloadConfig
+}
+
+// batchSender sends the messages in a batch and returns the errors of the failed messages by index.
+type batchSender func(ctx context.Context, messages []*ProducerMessage) (map[int]error, error)
+
+// Producer buffers the messages and sends them in batches. The batches are sent when they are full
+// or after the flush interval. The failed messages are retried and the ones which are not sent in
+// the max attempts are returned by the next Flush or Close.
+type Producer struct {
+	config ProducerConfig
+	buffer *buffer.Buffer[*ProducerMessage]
+}
+
+// batcher collects the messages into the batches by the SQS and SNS limits.
+type batcher struct {
+	config ProducerConfig
+	send   batchSender
+	batch  []*ProducerMessage
+	size   int
+}
+
+func newProducer(config ProducerConfig, send batchSender) *Producer {
+	if config.FlushInterval == 0 {
+		config.FlushInterval = DefaultProducerFlushInterval
+	}
+	if config.BufferSize == 0 {
+		config.BufferSize = DefaultProducerBufferSize
+	}
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = DefaultProducerMaxAttempts
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = DefaultProducerRetryInterval
+	}
+	if config.Offload != nil {
+		offload := *config.Offload
+		if offload.Threshold == 0 {
+			offload.Threshold = MaxMessageSize
+		}
+		config.Offload = &offload
+	}
+	return &Producer{
+		config: config,
+		buffer: buffer.New[*ProducerMessage](buffer.Config{
+			Size:          config.BufferSize,
+			FlushInterval: config.FlushInterval,
+			ErrClosed:     ErrProducerClosed,
+		}, &batcher{config: config, send: send}),
+	}
+}
+
+// Send buffers the message. The large message bodies are offloaded to S3 before buffering
+// if offloading is enabled. Otherwise, it returns ErrMessageTooLarge for them.
+func (p *Producer) Send(ctx context.Context, msg *ProducerMessage) error {
+	msg, err := p.prepare(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return p.buffer.Put(ctx, msg)
+}
+
+// prepare copies the message and offloads its body if it is too large.
+func (p *Producer) prepare(ctx context.Context, msg *ProducerMessage) (*ProducerMessage, error) {
+	prepared := *msg
+	threshold := MaxMessageSize
+	if p.config.Offload != nil {
+		threshold = p.config.Offload.Threshold
+	}
+	if prepared.size() <= threshold {
+		return &prepared, nil
+	}
+	if p.config.Offload == nil {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, prepared.size())
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := p.config.Offload.KeyPrefix + hex.EncodeToString(suffix)
+	_, err := p.config.Offload.Uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: &p.config.Offload.Bucket,
+		Key:    &key,
+		Body:   bytes.NewReader([]byte(msg.Body)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to offload message body: %w", err)
+	}
+	pointer, err := json.Marshal([]interface{}{s3PointerClass, map[string]string{
+		"s3BucketName": p.config.Offload.Bucket,
+		"s3Key":        key,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	prepared.Body = string(pointer)
+	prepared.payloadSize = len(msg.Body)
+	if prepared.size() > MaxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes after offloading", ErrMessageTooLarge, prepared.size())
+	}
+	return &prepared, nil
+}
+
+// Flush sends the buffered messages and returns the errors of the messages which could not be sent
+// since the previous flush. If the context is done before the errors are returned, they are
+// returned by the next flush.
+func (p *Producer) Flush(ctx context.Context) error {
+	return p.buffer.Flush(ctx)
+}
+
+// Close sends the buffered messages and stops the producer. It returns the errors of the messages
+// which could not be sent since the previous flush.
+func (p *Producer) Close(ctx context.Context) error {
+	return p.buffer.Close(ctx)
+}
+
+// Add adds the message to the batch and sends the batch if it is full.
+func (b *batcher) Add(msg *ProducerMessage) []error {
+	var errs []error
+	if b.size+msg.size() > MaxMessageSize {
+		errs = b.Send()
+	}
+	b.batch = append(b.batch, msg)
+	b.size += msg.size()
+	if len(b.batch) == maxBatchEntries {
+		errs = append(errs, b.Send()...)
+	}
+	return errs
+}
+
+// Send sends the batch and retries the failed messages.
+func (b *batcher) Send() []error {
+	if len(b.batch) == 0 {
+		return nil
+	}
+	errs := b.sendBatch(b.batch)
+	b.batch, b.size = nil, 0
+	return errs
+}
+
+// sendBatch sends the batch and retries the failed messages. It returns the errors of
+// the messages which could not be sent. SQS and SNS keep the order of the entries, so the
+// messages of a group are sent together. When a message fails, it is retried with the later
+// messages of its group, even if they are sent, so the groups stay in order. The FIFO queues
+// and topics deduplicate the messages which are sent again.
+func (b *batcher) sendBatch(batch []*ProducerMessage) []error {
+	var errs []error
+	pending := batch
+	retryInterval := b.config.RetryInterval
+	for attempt := 1; len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			time.Sleep(retryInterval)
+			retryInterval *= 2
+		}
+		var (
+			retry         []*ProducerMessage
+			retriedGroups = make(map[string]bool)
+		)
+		failed, err := b.send(context.Background(), pending)
+		for i, msg := range pending {
+			msgErr := err
+			if msgErr == nil {
+				msgErr = failed[i]
+			}
+			if msgErr == nil {
+				// the later messages of the retried groups are retried after them
+				if retriedGroups[msg.GroupID] {
+					retry = append(retry, msg)
+				}
+				continue
+			}
+			var entryErr *batchEntryError
+			if attempt < b.config.MaxAttempts && !(errors.As(msgErr, &entryErr) && entryErr.senderFault) {
+				retry = append(retry, msg)
+				if len(msg.GroupID) > 0 {
+					retriedGroups[msg.GroupID] = true
+				}
+				continue
+			}
+			log.WithError(msgErr).Warn("failed to send message")
+			errs = append(errs, &ProducerError{Message: msg, Err: msgErr})
+		}
+		pending = retry
+	}
+	return errs
+}
+
+// messageAttributes returns the attributes of the message as the values of SQS or SNS.
+func messageAttributes[V any](msg *ProducerMessage, newValue func(dataType, value string) V) map[string]V {
+	if len(msg.Attributes) == 0 && msg.payloadSize == 0 {
+		return nil
+	}
+	attributes := make(map[string]V)
+	for name, value := range msg.Attributes {
+		attributes[name] = newValue("String", value)
+	}
+	if msg.payloadSize > 0 {
+		attributes[extendedPayloadSizeAttrKey] = newValue("Number", strconv.Itoa(msg.payloadSize))
+	}
+	return attributes
+}
+
+// failedEntries returns the errors of the failed entries of SQS or SNS by index.
+func failedEntries[E any](entries []E, entryError func(entry E) (id string, err *batchEntryError)) map[int]error {
+	failed := make(map[int]error)
+	for _, entry := range entries {
+		id, err := entryError(entry)
+		i, _ := strconv.Atoi(id)
+		failed[i] = err
+	}
+	return failed
+}
+
+func optionalString(s string) *string {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
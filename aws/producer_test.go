@@ -0,0 +1,331 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testTopicARN = "arn:aws:sns:us-east-1:123456789012:test"
+
+// testBatches records the sent batches and fails the entries which are in the failures.
+type testBatches struct {
+	mu       sync.Mutex
+	batches  [][]string
+	failures map[string][]types.BatchResultErrorEntry
+}
+
+func (b *testBatches) send(input *sqs.SendMessageBatchInput) *sqs.SendMessageBatchOutput {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var (
+		bodies []string
+		res    sqs.SendMessageBatchOutput
+	)
+	for _, entry := range input.Entries {
+		bodies = append(bodies, *entry.MessageBody)
+		if failures := b.failures[*entry.MessageBody]; len(failures) > 0 {
+			failure := failures[0]
+			failure.Id = entry.Id
+			res.Failed = append(res.Failed, failure)
+			b.failures[*entry.MessageBody] = failures[1:]
+		}
+	}
+	b.batches = append(b.batches, bodies)
+	return &res
+}
+
+func (b *testBatches) sent() [][]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([][]string{}, b.batches...)
+}
+
+func newTestSQSProducer(t *testing.T, config ProducerConfig) (*Producer, *mock_aws.MockSQSClient, *testBatches) {
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSQSClient(ctrl)
+	batches := &testBatches{failures: make(map[string][]types.BatchResultErrorEntry)}
+	client.EXPECT().SendMessageBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *sqs.SendMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+			require.Equal(t, testQueueURL, *input.QueueUrl)
+			return batches.send(input), nil
+		}).AnyTimes()
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Hour
+	}
+	config.RetryInterval = time.Millisecond
+	return NewSQSProducer(client, testQueueURL, config), client, batches
+}
+
+func TestProducer_Batching(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, _, batches := newTestSQSProducer(t, ProducerConfig{})
+
+	var bodies []string
+	for i := 0; i < 25; i++ {
+		body := strings.Repeat("a", i+1)
+		bodies = append(bodies, body)
+		r.NoError(producer.Send(ctx, &ProducerMessage{Body: body}))
+	}
+	r.NoError(producer.Flush(ctx))
+	sent := batches.sent()
+	r.Len(sent, 3)
+	r.Equal(bodies[:10], sent[0])
+	r.Equal(bodies[10:20], sent[1])
+	r.Equal(bodies[20:], sent[2])
+
+	// the batches do not exceed the max size
+	large := strings.Repeat("b", MaxMessageSize/2)
+	for i := 0; i < 3; i++ {
+		r.NoError(producer.Send(ctx, &ProducerMessage{Body: large}))
+	}
+	r.NoError(producer.Close(ctx))
+	sent = batches.sent()
+	r.Len(sent, 5)
+	r.Equal([]string{large, large}, sent[3])
+	r.Equal([]string{large}, sent[4])
+
+	r.ErrorIs(producer.Send(ctx, &ProducerMessage{Body: "closed"}), ErrProducerClosed)
+	r.ErrorIs(producer.Flush(ctx), ErrProducerClosed)
+}
+
+func TestProducer_FlushInterval(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, _, batches := newTestSQSProducer(t, ProducerConfig{FlushInterval: time.Millisecond * 10})
+	defer producer.Close(ctx)
+
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "a"}))
+	r.Eventually(func() bool {
+		return len(batches.sent()) == 1
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestProducer_Retries(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, _, batches := newTestSQSProducer(t, ProducerConfig{MaxAttempts: 3})
+
+	throttled := types.BatchResultErrorEntry{Code: aws.String("Throttled"), Message: aws.String("slow down")}
+	invalid := types.BatchResultErrorEntry{Code: aws.String("Invalid"), Message: aws.String("bad message"), SenderFault: true}
+	batches.failures["retried"] = []types.BatchResultErrorEntry{throttled, throttled}
+	batches.failures["exhausted"] = []types.BatchResultErrorEntry{throttled, throttled, throttled}
+	batches.failures["invalid"] = []types.BatchResultErrorEntry{invalid}
+
+	for _, body := range []string{"ok", "retried", "exhausted", "invalid"} {
+		r.NoError(producer.Send(ctx, &ProducerMessage{Body: body}))
+	}
+	err := producer.Flush(ctx)
+	r.Error(err)
+	// only the failed entries are retried and the sender faults are not retried
+	r.Equal([][]string{
+		{"ok", "retried", "exhausted", "invalid"},
+		{"retried", "exhausted"},
+		{"retried", "exhausted"},
+	}, batches.sent())
+
+	var failed []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var producerErr *ProducerError
+		r.ErrorAs(err, &producerErr)
+		failed = append(failed, producerErr.Message.Body)
+	}
+	r.ElementsMatch([]string{"exhausted", "invalid"}, failed)
+
+	// the failures are returned once
+	r.NoError(producer.Close(ctx))
+}
+
+func TestProducer_RequestError(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSQSClient(ctrl)
+	producer := NewSQSProducer(client, testQueueURL, ProducerConfig{MaxAttempts: 2, RetryInterval: time.Millisecond})
+
+	requestErr := errors.New("request failed")
+	client.EXPECT().SendMessageBatch(gomock.Any(), gomock.Any()).Return(nil, requestErr).Times(2)
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "a"}))
+	r.ErrorIs(producer.Close(ctx), requestErr)
+}
+
+func TestProducer_FIFO(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSQSClient(ctrl)
+	producer := NewSQSProducer(client, testQueueURL, ProducerConfig{})
+	client.EXPECT().SendMessageBatch(gomock.Any(), &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(testQueueURL),
+		Entries: []types.SendMessageBatchRequestEntry{
+			{
+				Id:                     aws.String("0"),
+				MessageBody:            aws.String("a"),
+				MessageGroupId:         aws.String("group"),
+				MessageDeduplicationId: aws.String("dedup"),
+				MessageAttributes: map[string]types.MessageAttributeValue{
+					"kind": {DataType: aws.String("String"), StringValue: aws.String("test")},
+				},
+			},
+			{Id: aws.String("1"), MessageBody: aws.String("b")},
+		},
+	}).Return(&sqs.SendMessageBatchOutput{}, nil)
+	r.NoError(producer.Send(ctx, &ProducerMessage{
+		Body:            "a",
+		GroupID:         "group",
+		DeduplicationID: "dedup",
+		Attributes:      map[string]string{"kind": "test"},
+	}))
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "b"}))
+	r.NoError(producer.Close(ctx))
+}
+
+func TestProducer_FIFORetries(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, _, batches := newTestSQSProducer(t, ProducerConfig{})
+
+	throttled := types.BatchResultErrorEntry{Code: aws.String("Throttled"), Message: aws.String("slow down")}
+	batches.failures["a1"] = []types.BatchResultErrorEntry{throttled}
+	for _, msg := range []*ProducerMessage{
+		{Body: "a1", GroupID: "a"},
+		{Body: "a2", GroupID: "a"},
+		{Body: "b1", GroupID: "b"},
+		{Body: "a3", GroupID: "a"},
+		{Body: "c"},
+	} {
+		r.NoError(producer.Send(ctx, msg))
+	}
+	r.NoError(producer.Close(ctx))
+	// the messages of a group are batched together and the failed message is retried with the
+	// later messages of its group
+	r.Equal([][]string{
+		{"a1", "a2", "b1", "a3", "c"},
+		{"a1", "a2", "a3"},
+	}, batches.sent())
+}
+
+func TestProducer_FlushCanceled(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSQSClient(ctrl)
+	producer := NewSQSProducer(client, testQueueURL, ProducerConfig{MaxAttempts: 1, FlushInterval: time.Hour})
+	defer producer.Close(ctx)
+
+	requestErr := errors.New("request failed")
+	sending := make(chan struct{})
+	release := make(chan struct{})
+	client.EXPECT().SendMessageBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *sqs.SendMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+			close(sending)
+			<-release
+			return nil, requestErr
+		})
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "a"}))
+
+	// the flush is canceled while the batch is sent
+	flushCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-sending
+		cancel()
+		time.AfterFunc(time.Millisecond*10, func() {
+			close(release)
+		})
+	}()
+	r.ErrorIs(producer.Flush(flushCtx), context.Canceled)
+
+	// the failures are returned by the next flush
+	r.ErrorIs(producer.Flush(ctx), requestErr)
+	r.NoError(producer.Flush(ctx))
+}
+
+func TestProducer_Offload(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	uploader := mock_aws.NewMockS3Uploader(ctrl)
+	producer, _, batches := newTestSQSProducer(t, ProducerConfig{
+		Offload: &ProducerOffloadConfig{
+			Uploader:  uploader,
+			Bucket:    "bucket",
+			KeyPrefix: "messages/",
+			Threshold: 10,
+		},
+	})
+
+	var uploadedKey string
+	uploader.EXPECT().Upload(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.PutObjectInput, _ ...func(*manager.Uploader)) (*manager.UploadOutput, error) {
+			r.Equal("bucket", *input.Bucket)
+			r.True(strings.HasPrefix(*input.Key, "messages/"))
+			data, err := io.ReadAll(input.Body)
+			r.NoError(err)
+			r.Equal("a large message body", string(data))
+			uploadedKey = *input.Key
+			return &manager.UploadOutput{}, nil
+		})
+
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "small"}))
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "a large message body"}))
+	r.NoError(producer.Close(ctx))
+	r.Equal([][]string{{
+		"small",
+		`["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"bucket","s3Key":"` + uploadedKey + `"}]`,
+	}}, batches.sent())
+}
+
+func TestProducer_MessageTooLarge(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	producer, _, _ := newTestSQSProducer(t, ProducerConfig{})
+	defer producer.Close(ctx)
+
+	err := producer.Send(ctx, &ProducerMessage{Body: strings.Repeat("a", MaxMessageSize+1)})
+	r.ErrorIs(err, ErrMessageTooLarge)
+}
+
+func TestSNSProducer(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSNSClient(ctrl)
+	producer := NewSNSProducer(client, testTopicARN, ProducerConfig{RetryInterval: time.Millisecond})
+
+	client.EXPECT().PublishBatch(gomock.Any(), &sns.PublishBatchInput{
+		TopicArn: aws.String(testTopicARN),
+		PublishBatchRequestEntries: []snstypes.PublishBatchRequestEntry{
+			{Id: aws.String("0"), Message: aws.String("a"), MessageGroupId: aws.String("group")},
+			{Id: aws.String("1"), Message: aws.String("b")},
+		},
+	}).Return(&sns.PublishBatchOutput{
+		Failed: []snstypes.BatchResultErrorEntry{{Id: aws.String("1"), Code: aws.String("Throttled")}},
+	}, nil)
+	client.EXPECT().PublishBatch(gomock.Any(), &sns.PublishBatchInput{
+		TopicArn: aws.String(testTopicARN),
+		PublishBatchRequestEntries: []snstypes.PublishBatchRequestEntry{
+			{Id: aws.String("0"), Message: aws.String("b")},
+		},
+	}).Return(&sns.PublishBatchOutput{}, nil)
+
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "a", GroupID: "group"}))
+	r.NoError(producer.Send(ctx, &ProducerMessage{Body: "b"}))
+	r.NoError(producer.Close(ctx))
+}
@@ -0,0 +1,42 @@
+package aws
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// NewSNSProducer creates a producer which publishes the messages to the topic with PublishBatch.
+func NewSNSProducer(client SNSClient, topicARN string, config ProducerConfig) *Producer {
+	return newProducer(config, func(ctx context.Context, messages []*ProducerMessage) (map[int]error, error) {
+		entries := make([]types.PublishBatchRequestEntry, len(messages))
+		for i, msg := range messages {
+			entries[i] = types.PublishBatchRequestEntry{
+				Id:                     aws.String(strconv.Itoa(i)),
+				Message:                aws.String(msg.Body),
+				MessageGroupId:         optionalString(msg.GroupID),
+				MessageDeduplicationId: optionalString(msg.DeduplicationID),
+				MessageAttributes: messageAttributes(msg, func(dataType, value string) types.MessageAttributeValue {
+					return types.MessageAttributeValue{DataType: aws.String(dataType), StringValue: aws.String(value)}
+				}),
+			}
+		}
+		res, err := client.PublishBatch(ctx, &sns.PublishBatchInput{
+			TopicArn:                   &topicARN,
+			PublishBatchRequestEntries: entries,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return failedEntries(res.Failed, func(entry types.BatchResultErrorEntry) (string, *batchEntryError) {
+			return aws.ToString(entry.Id), &batchEntryError{
+				code:        aws.ToString(entry.Code),
+				message:     aws.ToString(entry.Message),
+				senderFault: entry.SenderFault,
+			}
+		}), nil
+	})
+}
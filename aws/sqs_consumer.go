@@ -0,0 +1,395 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// SQS consumer defaults
+const (
+	DefaultSQSConcurrency       = 10
+	DefaultSQSWaitTime          = time.Second * 20
+	DefaultSQSVisibilityTimeout = time.Second * 30
+	DefaultSQSDeleteInterval    = time.Second
+	DefaultSQSRetryInterval     = time.Second * 5
+	DefaultSQSShutdownTimeout   = time.Second * 30
+	// sqsMaxBatchSize is the maximum number of messages per receive and batch request.
+	sqsMaxBatchSize = 10
+)
+
+// SQSMessage is a received message with a decoded body.
+type SQSMessage[T any] struct {
+	ID                string
+	ReceiptHandle     string
+	Body              T
+	Attributes        map[string]string
+	MessageAttributes map[string]types.MessageAttributeValue
+	// ReceiveCount is the number of times the message was received, including this time.
+	ReceiveCount int
+	SentAt       time.Time
+}
+
+// SQSHandler handles a message. The message is deleted if the handler returns nil and it is
+// received again after the visibility timeout otherwise.
+type SQSHandler[T any] func(ctx context.Context, message *SQSMessage[T]) error
+
+// SQSDecoder decodes a message body.
+type SQSDecoder[T any] func(body string) (T, error)
+
+// SQSConsumerConfig configures an SQS consumer.
+type SQSConsumerConfig struct {
+	QueueURL string
+	// DeadLetterQueueURL receives the messages which cannot be decoded or handled in the max attempts.
+	// Such messages are left to the redrive policy of the queue if it is empty.
+	DeadLetterQueueURL string
+	// MaxAttempts is how many times a message is handled before it is dead-lettered. The messages
+	// are retried until they expire if it is zero.
+	MaxAttempts int
+	// Concurrency is the maximum number of messages which are handled at the same time.
+	Concurrency int
+	// WaitTime is the long polling duration.
+	WaitTime time.Duration
+	// VisibilityTimeout is the visibility timeout of the received messages. It is extended
+	// at every half of it while the messages are being handled.
+	VisibilityTimeout time.Duration
+	// DeleteInterval is the maximum duration which the deletions are batched for.
+	DeleteInterval time.Duration
+	// RetryInterval is the wait time after failing to receive messages.
+	RetryInterval time.Duration
+	// ShutdownTimeout is how long the running handlers are waited for after the context is canceled.
+	// Their contexts are canceled after the timeout.
+	ShutdownTimeout time.Duration
+}
+
+// SQSConsumer receives the messages from a queue and handles them concurrently.
+type SQSConsumer[T any] struct {
+	client  SQSClient
+	config  SQSConsumerConfig
+	handler SQSHandler[T]
+	decoder SQSDecoder[T]
+}
+
+// NewSQSConsumer creates a new consumer which decodes the message bodies from JSON.
+func NewSQSConsumer[T any](client SQSClient, config SQSConsumerConfig, handler SQSHandler[T]) (*SQSConsumer[T], error) {
+	if len(config.QueueURL) == 0 {
+		return nil, errors.New("queue URL is required")
+	}
+	if config.Concurrency == 0 {
+		config.Concurrency = DefaultSQSConcurrency
+	}
+	if config.WaitTime == 0 {
+		config.WaitTime = DefaultSQSWaitTime
+	}
+	if config.VisibilityTimeout == 0 {
+		config.VisibilityTimeout = DefaultSQSVisibilityTimeout
+	}
+	if config.VisibilityTimeout < time.Second {
+		return nil, fmt.Errorf("visibility timeout (%s) must be at least one second", config.VisibilityTimeout)
+	}
+	if config.DeleteInterval == 0 {
+		config.DeleteInterval = DefaultSQSDeleteInterval
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = DefaultSQSRetryInterval
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = DefaultSQSShutdownTimeout
+	}
+	return &SQSConsumer[T]{
+		client:  client,
+		config:  config,
+		handler: handler,
+		decoder: decodeJSONMessage[T],
+	}, nil
+}
+
+func decodeJSONMessage[T any](body string) (T, error) {
+	var v T
+	err := json.Unmarshal([]byte(body), &v)
+	return v, err
+}
+
+// WithDecoder sets the message body decoder.
+func (c *SQSConsumer[T]) WithDecoder(decoder SQSDecoder[T]) *SQSConsumer[T] {
+	c.decoder = decoder
+	return c
+}
+
+// Run receives and handles the messages until the context is canceled. After cancellation, it stops
+// receiving, waits for the running handlers and deletes the handled messages before returning nil.
+// It returns an error if the queue does not exist.
+func (c *SQSConsumer[T]) Run(ctx context.Context) error {
+	// the handlers and the deletions outlive the context until the shutdown
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+	deletes := make(chan string)
+	deleterDone := make(chan struct{})
+	go func() {
+		defer close(deleterDone)
+		c.deleteMessages(context.WithoutCancel(ctx), deletes)
+	}()
+
+	var (
+		wg     sync.WaitGroup
+		runErr error
+	)
+	slots := make(chan struct{}, c.config.Concurrency)
+	for ctx.Err() == nil {
+		free := c.acquireSlots(ctx, slots)
+		if free == 0 {
+			break
+		}
+		messages, err := c.receive(ctx, free)
+		for i := len(messages); i < free; i++ {
+			<-slots
+		}
+		var notExistErr *types.QueueDoesNotExist
+		if errors.As(err, &notExistErr) {
+			runErr = fmt.Errorf("failed to receive messages from %s: %w", c.config.QueueURL, err)
+			break
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.WithError(err).WithField("queue", c.config.QueueURL).Warn("failed to receive messages")
+				sleep(ctx, c.config.RetryInterval)
+			}
+			continue
+		}
+		for _, message := range messages {
+			message := message
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				c.process(handlerCtx, message, deletes)
+			}()
+		}
+	}
+
+	handlersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(handlersDone)
+	}()
+	select {
+	case <-handlersDone:
+	case <-time.After(c.config.ShutdownTimeout):
+		log.WithField("queue", c.config.QueueURL).Warn("handlers did not finish before the shutdown timeout")
+		cancelHandlers()
+		<-handlersDone
+	}
+	close(deletes)
+	<-deleterDone
+	return runErr
+}
+
+// acquireSlots waits for at least one free handler slot and acquires up to the max batch size.
+// It returns zero if the context is canceled.
+func (c *SQSConsumer[T]) acquireSlots(ctx context.Context, slots chan struct{}) int {
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return 0
+	}
+	free := 1
+	for free < sqsMaxBatchSize {
+		select {
+		case slots <- struct{}{}:
+			free++
+		default:
+			return free
+		}
+	}
+	return free
+}
+
+func (c *SQSConsumer[T]) receive(ctx context.Context, maxMessages int) ([]types.Message, error) {
+	res, err := c.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            &c.config.QueueURL,
+		MaxNumberOfMessages: int32(maxMessages),
+		WaitTimeSeconds:     int32(c.config.WaitTime.Seconds()),
+		VisibilityTimeout:   int32(c.config.VisibilityTimeout.Seconds()),
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameSentTimestamp,
+			types.MessageSystemAttributeNameMessageGroupId,
+		},
+		MessageAttributeNames: []string{"All"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Messages, nil
+}
+
+func (c *SQSConsumer[T]) process(ctx context.Context, msg types.Message, deletes chan<- string) {
+	logger := log.WithField("queue", c.config.QueueURL).WithField("message", aws.ToString(msg.MessageId))
+	message, err := c.decode(msg)
+	if err != nil {
+		logger.WithError(err).Warn("failed to decode message")
+		c.deadLetter(ctx, logger, msg, deletes)
+		return
+	}
+	// the previous attempts may have failed without returning, e.g. by crashing
+	if c.config.MaxAttempts > 0 && message.ReceiveCount > c.config.MaxAttempts {
+		c.deadLetter(ctx, logger, msg, deletes)
+		return
+	}
+
+	stopExtending := c.extendVisibility(ctx, logger, msg.ReceiptHandle)
+	err = c.handler(ctx, message)
+	stopExtending()
+	if err == nil {
+		deletes <- message.ReceiptHandle
+		return
+	}
+	logger.WithError(err).WithField("receiveCount", message.ReceiveCount).Warn("failed to handle message")
+	if c.config.MaxAttempts > 0 && message.ReceiveCount >= c.config.MaxAttempts {
+		c.deadLetter(ctx, logger, msg, deletes)
+	}
+}
+
+func (c *SQSConsumer[T]) decode(msg types.Message) (*SQSMessage[T], error) {
+	body, err := c.decoder(aws.ToString(msg.Body))
+	if err != nil {
+		return nil, err
+	}
+	message := &SQSMessage[T]{
+		ID:                aws.ToString(msg.MessageId),
+		ReceiptHandle:     aws.ToString(msg.ReceiptHandle),
+		Body:              body,
+		Attributes:        msg.Attributes,
+		MessageAttributes: msg.MessageAttributes,
+	}
+	if count, ok := msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]; ok {
+		message.ReceiveCount, _ = strconv.Atoi(count)
+	}
+	if sentAt, ok := msg.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)]; ok {
+		millis, _ := strconv.ParseInt(sentAt, 10, 64)
+		message.SentAt = time.UnixMilli(millis)
+	}
+	return message, nil
+}
+
+// extendVisibility extends the visibility timeout of the message until the returned function is called.
+func (c *SQSConsumer[T]) extendVisibility(ctx context.Context, logger *log.Entry, receiptHandle *string) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(c.config.VisibilityTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := c.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          &c.config.QueueURL,
+				ReceiptHandle:     receiptHandle,
+				VisibilityTimeout: int32(c.config.VisibilityTimeout.Seconds()),
+			})
+			if err != nil {
+				logger.WithError(err).Warn("failed to extend visibility timeout")
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// deadLetter sends the message to the dead-letter queue and deletes it.
+func (c *SQSConsumer[T]) deadLetter(ctx context.Context, logger *log.Entry, msg types.Message, deletes chan<- string) {
+	if len(c.config.DeadLetterQueueURL) == 0 {
+		logger.Warn("poison message is left to the redrive policy")
+		return
+	}
+	input := &sqs.SendMessageInput{
+		QueueUrl:          &c.config.DeadLetterQueueURL,
+		MessageBody:       msg.Body,
+		MessageAttributes: msg.MessageAttributes,
+	}
+	// the FIFO queues require a message group
+	if groupID, ok := msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]; ok {
+		input.MessageGroupId = &groupID
+		input.MessageDeduplicationId = msg.MessageId
+	}
+	if _, err := c.client.SendMessage(ctx, input); err != nil {
+		logger.WithError(err).Warn("failed to send message to the dead-letter queue")
+		return
+	}
+	logger.Warn("sent poison message to the dead-letter queue")
+	deletes <- aws.ToString(msg.ReceiptHandle)
+}
+
+// deleteMessages deletes the messages in batches until the channel is closed.
+func (c *SQSConsumer[T]) deleteMessages(ctx context.Context, receiptHandles <-chan string) {
+	var batch []string
+	ticker := time.NewTicker(c.config.DeleteInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case receiptHandle, ok := <-receiptHandles:
+			if !ok {
+				c.deleteBatch(ctx, batch)
+				return
+			}
+			batch = append(batch, receiptHandle)
+			if len(batch) < sqsMaxBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		c.deleteBatch(ctx, batch)
+		batch = nil
+	}
+}
+
+func (c *SQSConsumer[T]) deleteBatch(ctx context.Context, receiptHandles []string) {
+	if len(receiptHandles) == 0 {
+		return
+	}
+	entries := make([]types.DeleteMessageBatchRequestEntry, len(receiptHandles))
+	for i := range receiptHandles {
+		entries[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: &receiptHandles[i],
+		}
+	}
+	logger := log.WithField("queue", c.config.QueueURL)
+	res, err := c.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: &c.config.QueueURL,
+		Entries:  entries,
+	})
+	if err != nil {
+		logger.WithError(err).Warnf("failed to delete %d messages", len(receiptHandles))
+		return
+	}
+	for _, failed := range res.Failed {
+		logger.WithField("code", aws.ToString(failed.Code)).Warnf("failed to delete message: %s", aws.ToString(failed.Message))
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,226 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testQueueURL           = "https://sqs.test/queue"
+	testDeadLetterQueueURL = "https://sqs.test/dlq"
+)
+
+type testPayload struct {
+	Value string `json:"value"`
+}
+
+func testMessage(id, body string, receiveCount string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("receipt-" + id),
+		Body:          aws.String(body),
+		Attributes: map[string]string{
+			string(types.MessageSystemAttributeNameApproximateReceiveCount): receiveCount,
+			string(types.MessageSystemAttributeNameSentTimestamp):           "1700000000000",
+		},
+	}
+}
+
+// testSQS serves the messages from the mock client and records the deletions.
+type testSQS struct {
+	mu       sync.Mutex
+	messages []types.Message
+	deleted  []string
+}
+
+func newTestSQS(ctrl *gomock.Controller, messages ...types.Message) (*testSQS, *mock_aws.MockSQSClient) {
+	q := &testSQS{messages: messages}
+	client := mock_aws.NewMockSQSClient(ctrl)
+	client.EXPECT().ReceiveMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			q.mu.Lock()
+			n := len(q.messages)
+			if n > int(input.MaxNumberOfMessages) {
+				n = int(input.MaxNumberOfMessages)
+			}
+			messages := q.messages[:n]
+			q.messages = q.messages[n:]
+			q.mu.Unlock()
+			if len(messages) > 0 {
+				return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).AnyTimes()
+	client.EXPECT().DeleteMessageBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			for _, entry := range input.Entries {
+				q.deleted = append(q.deleted, *entry.ReceiptHandle)
+			}
+			return &sqs.DeleteMessageBatchOutput{}, nil
+		}).AnyTimes()
+	return q, client
+}
+
+func (q *testSQS) deletedHandles() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	deleted := append([]string{}, q.deleted...)
+	sort.Strings(deleted)
+	return deleted
+}
+
+func runTestConsumer(ctx context.Context, consumer *SQSConsumer[testPayload]) chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- consumer.Run(ctx)
+	}()
+	return errCh
+}
+
+func TestSQSConsumer(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	q, client := newTestSQS(ctrl,
+		testMessage("1", `{"value":"a"}`, "1"),
+		testMessage("2", `{"value":"b"}`, "1"),
+		testMessage("3", `{"value":"c"}`, "1"),
+	)
+
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer, err := NewSQSConsumer[testPayload](client, SQSConsumerConfig{
+		QueueURL:       testQueueURL,
+		Concurrency:    2,
+		DeleteInterval: time.Millisecond * 10,
+	}, func(ctx context.Context, message *SQSMessage[testPayload]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, message.Body.Value)
+		r.Equal(1, message.ReceiveCount)
+		r.Equal(int64(1700000000000), message.SentAt.UnixMilli())
+		if len(received) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	r.NoError(err)
+
+	r.NoError(<-runTestConsumer(ctx, consumer))
+	sort.Strings(received)
+	r.Equal([]string{"a", "b", "c"}, received)
+	r.Equal([]string{"receipt-1", "receipt-2", "receipt-3"}, q.deletedHandles())
+}
+
+func TestSQSConsumer_DeadLetter(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	q, client := newTestSQS(ctrl,
+		testMessage("1", `{"value":"a"}`, "2"),
+		testMessage("2", `{"value":"b"}`, "3"),
+		testMessage("3", `invalid`, "1"),
+		testMessage("4", `{"value":"d"}`, "4"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		mu           sync.Mutex
+		deadLettered []string
+	)
+	client.EXPECT().SendMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+			r.Equal(testDeadLetterQueueURL, *input.QueueUrl)
+			mu.Lock()
+			defer mu.Unlock()
+			deadLettered = append(deadLettered, *input.MessageBody)
+			if len(deadLettered) == 3 {
+				cancel()
+			}
+			return &sqs.SendMessageOutput{}, nil
+		}).Times(3)
+
+	var handled []string
+	consumer, err := NewSQSConsumer[testPayload](client, SQSConsumerConfig{
+		QueueURL:           testQueueURL,
+		DeadLetterQueueURL: testDeadLetterQueueURL,
+		MaxAttempts:        3,
+		Concurrency:        1,
+	}, func(ctx context.Context, message *SQSMessage[testPayload]) error {
+		handled = append(handled, message.Body.Value)
+		return errors.New("failed")
+	})
+	r.NoError(err)
+
+	r.NoError(<-runTestConsumer(ctx, consumer))
+	// the message which exceeded the max attempts is not handled
+	r.Equal([]string{"a", "b"}, handled)
+	sort.Strings(deadLettered)
+	r.Equal([]string{`invalid`, `{"value":"b"}`, `{"value":"d"}`}, deadLettered)
+	r.Equal([]string{"receipt-2", "receipt-3", "receipt-4"}, q.deletedHandles())
+}
+
+func TestSQSConsumer_VisibilityAndShutdown(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	q, client := newTestSQS(ctrl, testMessage("1", `{"value":"a"}`, "1"))
+
+	var (
+		mu       sync.Mutex
+		extended int
+	)
+	client.EXPECT().ChangeMessageVisibility(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+			r.Equal("receipt-1", *input.ReceiptHandle)
+			r.Equal(int32(1), input.VisibilityTimeout)
+			mu.Lock()
+			defer mu.Unlock()
+			extended++
+			return &sqs.ChangeMessageVisibilityOutput{}, nil
+		}).MinTimes(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer, err := NewSQSConsumer[testPayload](client, SQSConsumerConfig{
+		QueueURL:          testQueueURL,
+		VisibilityTimeout: time.Second,
+	}, func(handlerCtx context.Context, message *SQSMessage[testPayload]) error {
+		// the running handler is not interrupted by the cancellation
+		cancel()
+		time.Sleep(time.Millisecond * 700)
+		return handlerCtx.Err()
+	})
+	r.NoError(err)
+
+	r.NoError(<-runTestConsumer(ctx, consumer))
+	r.Equal([]string{"receipt-1"}, q.deletedHandles())
+	mu.Lock()
+	defer mu.Unlock()
+	r.GreaterOrEqual(extended, 1)
+}
+
+func TestSQSConsumer_QueueDoesNotExist(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSQSClient(ctrl)
+	client.EXPECT().ReceiveMessage(gomock.Any(), gomock.Any()).Return(nil, &types.QueueDoesNotExist{})
+
+	consumer, err := NewSQSConsumer[testPayload](client, SQSConsumerConfig{QueueURL: testQueueURL}, nil)
+	r.NoError(err)
+	r.ErrorAs(consumer.Run(context.Background()), new(*types.QueueDoesNotExist))
+}
@@ -0,0 +1,42 @@
+package aws
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// NewSQSProducer creates a producer which sends the messages to the queue with SendMessageBatch.
+func NewSQSProducer(client SQSClient, queueURL string, config ProducerConfig) *Producer {
+	return newProducer(config, func(ctx context.Context, messages []*ProducerMessage) (map[int]error, error) {
+		entries := make([]types.SendMessageBatchRequestEntry, len(messages))
+		for i, msg := range messages {
+			entries[i] = types.SendMessageBatchRequestEntry{
+				Id:                     aws.String(strconv.Itoa(i)),
+				MessageBody:            aws.String(msg.Body),
+				MessageGroupId:         optionalString(msg.GroupID),
+				MessageDeduplicationId: optionalString(msg.DeduplicationID),
+				MessageAttributes: messageAttributes(msg, func(dataType, value string) types.MessageAttributeValue {
+					return types.MessageAttributeValue{DataType: aws.String(dataType), StringValue: aws.String(value)}
+				}),
+			}
+		}
+		res, err := client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: &queueURL,
+			Entries:  entries,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return failedEntries(res.Failed, func(entry types.BatchResultErrorEntry) (string, *batchEntryError) {
+			return aws.ToString(entry.Id), &batchEntryError{
+				code:        aws.ToString(entry.Code),
+				message:     aws.ToString(entry.Message),
+				senderFault: entry.SenderFault,
+			}
+		}), nil
+	})
+}
@@ -28,6 +28,19 @@ func (ec *etherClient) withBackoff(
 	method string,
 	operation func(ctx context.Context, ethClient *ethclient.Client) error,
 	options ...retryOptions,
+) error {
+	return ec.withProviderBackoff(ctx, method, func(ctx context.Context, wrapper *ethClientWrapper) error {
+		return operation(ctx, wrapper.Client)
+	}, options...)
+}
+
+// withProviderBackoff is the same as withBackoff but lets the operation access the provided
+// client wrapper instead of only the client.
+func (ec *etherClient) withProviderBackoff(
+	ctx context.Context,
+	method string,
+	operation func(ctx context.Context, wrapper *ethClientWrapper) error,
+	options ...retryOptions,
 ) error {
 	bo := backoff.NewExponentialBackOff()
 	bo.InitialInterval = backoffInitialInterval
@@ -45,15 +58,16 @@ func (ec *etherClient) withBackoff(
 			bo.MaxElapsedTime = opts.MaxElapsedTime
 		}
 	}
+	// the providers which do not support the method are tried once
+	unavailable := make(map[*ethClientWrapper]bool)
 	err := backoff.Retry(func() error {
 		if ctx.Err() != nil {
 			return backoff.Permanent(ctx.Err())
 		}
 
 		wrapper := ec.provider.Provide()
-		ethClient := wrapper.Client
 		tCtx, cancel := context.WithTimeout(ctx, backoffContextTimeout)
-		opErr := operation(tCtx, ethClient)
+		opErr := operation(tCtx, wrapper)
 		cancel()
 
 		// If metrics handler is set, call with the RPC URL and the client method that was used.
@@ -69,6 +83,13 @@ func (ec *etherClient) withBackoff(
 			// Move onto the next provider.
 			ec.provider.Next()
 		}
+		if opErr != nil && IsMethodUnavailable(opErr) {
+			// stop after every provider is tried
+			if unavailable[wrapper] {
+				return backoff.Permanent(opErr)
+			}
+			unavailable[wrapper] = true
+		}
 		return handleRetryErr(ctx, method, opErr)
 	}, bo)
 	if err != nil {
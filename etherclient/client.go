@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -73,6 +74,33 @@ type Extras interface {
 	DebugTraceBlockByNumber(
 		ctx context.Context, blockNumber *big.Int, traceCallConfig TraceCallConfig, result interface{},
 	) error
+	DebugTraceBlockByHash(
+		ctx context.Context, blockHash common.Hash, traceCallConfig TraceCallConfig, result interface{},
+	) error
+	DebugTraceTransactionPrestate(
+		ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+	) (PrestateResult, error)
+	DebugTraceTransactionPrestateDiff(
+		ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+	) (*PrestateDiffResult, error)
+	DebugTraceTransaction4Byte(ctx context.Context, txHash string) (FourByteResult, error)
+	DebugTraceTransactionFlatCalls(
+		ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+	) ([]*FlatTrace, error)
+	DebugTraceTransactionStructLogs(
+		ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+	) (*StructLogResult, error)
+	TraceBlock(ctx context.Context, blockNumber *big.Int) ([]*FlatTrace, error)
+	TraceTransaction(ctx context.Context, txHash string) ([]*FlatTrace, error)
+	TraceReplayBlockTransactions(
+		ctx context.Context, blockNumber *big.Int, traceTypes ...string,
+	) ([]*ReplayedTransaction, error)
+	CallContractWithOverrides(
+		ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int,
+		stateOverride StateOverride, blockOverrides *BlockOverrides,
+	) ([]byte, error)
+	GetCallTrace(ctx context.Context, txHash string) (*TracedCall, error)
+	GetBlockCallTraces(ctx context.Context, blockNumber *big.Int) (TracedBlock, error)
 	GetBlockTransactions(ctx context.Context, number *big.Int) ([]*BlockTx, error)
 
 	GetBlockByHash(ctx context.Context, hash common.Hash) (ret1 *Block, err error)
@@ -94,6 +122,11 @@ var _ EthClient = &ethclient.Client{}
 type ethClientWrapper struct {
 	url string
 	*ethclient.Client
+
+	// traceAPI is the detected tracing API family of this provider.
+	traceAPI atomic.Int32
+	// traceAPIDetectedAt is the unix nano time when the tracing API was detected.
+	traceAPIDetectedAt atomic.Int64
 }
 
 func (ecw *ethClientWrapper) GetURL() string {
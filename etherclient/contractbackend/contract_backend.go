@@ -23,7 +23,8 @@ type ContractBackend interface {
 // contractBackend is a wrapper of go-ethereum client. This is useful for implementing
 // extra features. It's not thread-safe.
 type contractBackend struct {
-	nonce uint64
+	nonce     uint64
+	simulator *simulator
 	ContractBackend
 }
 
@@ -32,6 +33,16 @@ func NewContractBackend(client *rpc.Client) bind.ContractBackend {
 	return &contractBackend{ContractBackend: ethclient.NewClient(client)}
 }
 
+// NewContractBackendWithSimulation creates a new contract backend which simulates every transaction
+// against the pending state before sending and refuses to send the ones that revert. The simulation
+// can be skipped per transaction by using a context from `WithoutSimulation()`.
+func NewContractBackendWithSimulation(client *rpc.Client) bind.ContractBackend {
+	return &contractBackend{
+		ContractBackend: ethclient.NewClient(client),
+		simulator:       &simulator{caller: client},
+	}
+}
+
 // PendingNonceAt helps us count the nonce more robustly.
 func (cb *contractBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
 	logger := log.WithField("address", account.Hex())
@@ -63,6 +74,12 @@ func (cb *contractBackend) PendingNonceAt(ctx context.Context, account common.Ad
 // SendTransaction sends the transaction with the most up-to-date nonce.
 func (cb *contractBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	logger := getTxLogger(tx)
+	if cb.simulator != nil && !isSimulationSkipped(ctx) {
+		if err := cb.simulator.Simulate(ctx, tx); err != nil {
+			logger.WithError(err).Error("refusing to send")
+			return err
+		}
+	}
 	logger.Info("sending")
 	if err := cb.ContractBackend.SendTransaction(ctx, tx); err != nil {
 		logger.WithError(err).Error("failed to send")
@@ -0,0 +1,152 @@
+package contractbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/core-go/etherclient"
+	"github.com/forta-network/core-go/utils/traceutils"
+)
+
+const simulationBlock = "pending"
+
+// RPCCaller makes raw JSON-RPC calls. This is implemented by `rpc.Client`.
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// SimulationError is returned when a transaction is refused because it reverts
+// in the pre-send simulation.
+type SimulationError struct {
+	TxHash common.Hash
+	// Reason is the decoded revert reason if available and the raw error otherwise.
+	Reason string
+	// Frame is the failing call frame from the call trace. It is nil if the
+	// simulation was done with eth_call.
+	Frame *etherclient.TracedCall
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("transaction %s reverts in simulation: %s", e.TxHash.Hex(), e.Reason)
+}
+
+type skipSimulationKey struct{}
+
+// WithoutSimulation returns a context which disables the pre-send simulation
+// for the transactions sent with it.
+func WithoutSimulation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipSimulationKey{}, true)
+}
+
+func isSimulationSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipSimulationKey{}).(bool)
+	return skip
+}
+
+type simulator struct {
+	caller RPCCaller
+}
+
+// Simulate runs the transaction against the pending state. It prefers debug_traceCall
+// and falls back to eth_call only if the provider does not support tracing.
+func (s *simulator) Simulate(ctx context.Context, tx *types.Transaction) error {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return fmt.Errorf("failed to get the sender for simulation: %v", err)
+	}
+	msg := toTraceCallTransaction(from, tx)
+
+	var result etherclient.TracedCall
+	err = s.caller.CallContext(ctx, &result, "debug_traceCall", msg, simulationBlock, etherclient.TraceCallConfig{
+		Tracer: etherclient.TracerCall,
+	})
+	if err != nil && etherclient.IsMethodUnavailable(err) {
+		return s.call(ctx, tx.Hash(), msg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to simulate transaction: %v", err)
+	}
+	if len(result.Error) == 0 {
+		return nil
+	}
+	frame := traceutils.DeepestRevert(&result).Call
+	return &SimulationError{
+		TxHash: tx.Hash(),
+		Reason: decodeRevertReason(frame.Output, frame.Error),
+		Frame:  frame,
+	}
+}
+
+func (s *simulator) call(ctx context.Context, txHash common.Hash, msg *etherclient.TraceCallTransaction) error {
+	var result hexutil.Bytes
+	err := s.caller.CallContext(ctx, &result, "eth_call", msg, simulationBlock)
+	if err == nil {
+		return nil
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		revertData, _ := dataErr.ErrorData().(string)
+		return &SimulationError{
+			TxHash: txHash,
+			Reason: decodeRevertReason(revertData, dataErr.Error()),
+		}
+	}
+	if strings.Contains(err.Error(), "execution reverted") {
+		return &SimulationError{TxHash: txHash, Reason: err.Error()}
+	}
+	return fmt.Errorf("failed to simulate transaction: %v", err)
+}
+
+func decodeRevertReason(output, fallback string) string {
+	b, err := hexutil.Decode(output)
+	if err != nil {
+		return fallback
+	}
+	reason, err := abi.UnpackRevert(b)
+	if err != nil {
+		return fallback
+	}
+	return reason
+}
+
+func toTraceCallTransaction(from common.Address, tx *types.Transaction) *etherclient.TraceCallTransaction {
+	gas := hexutil.Uint64(tx.Gas())
+	nonce := hexutil.Uint64(tx.Nonce())
+	input := hexutil.Bytes(tx.Data())
+	msg := &etherclient.TraceCallTransaction{
+		From:  &from,
+		To:    tx.To(),
+		Gas:   &gas,
+		Value: (*hexutil.Big)(tx.Value()),
+		Nonce: &nonce,
+		Input: &input,
+	}
+	if tx.Type() == types.LegacyTxType {
+		msg.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		return msg
+	}
+	accessList := tx.AccessList()
+	msg.AccessList = &accessList
+	msg.ChainID = (*hexutil.Big)(tx.ChainId())
+	if tx.Type() == types.AccessListTxType {
+		msg.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		return msg
+	}
+	msg.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+	msg.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	switch tx.Type() {
+	case types.BlobTxType:
+		msg.BlobFeeCap = (*hexutil.Big)(tx.BlobGasFeeCap())
+		msg.BlobHashes = tx.BlobHashes()
+	case types.SetCodeTxType:
+		msg.AuthorizationList = tx.SetCodeAuthorizations()
+	}
+	return msg
+}
@@ -0,0 +1,188 @@
+package contractbackend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/core-go/etherclient"
+	mock_contract_backend "github.com/forta-network/core-go/etherclient/contractbackend/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type rpcCallerFunc func(result interface{}, method string) error
+
+func (f rpcCallerFunc) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return f(result, method)
+}
+
+type testDataError struct {
+	data string
+}
+
+func (e *testDataError) Error() string {
+	return "execution reverted"
+}
+
+func (e *testDataError) ErrorData() interface{} {
+	return e.data
+}
+
+func packRevertReason(r *require.Assertions, reason string) string {
+	typ, err := abi.NewType("string", "", nil)
+	r.NoError(err)
+	packed, err := (abi.Arguments{{Type: typ}}).Pack(reason)
+	r.NoError(err)
+	return hexutil.Encode(append([]byte{0x08, 0xc3, 0x79, 0xa0}, packed...))
+}
+
+func signTestTx(r *require.Assertions) *types.Transaction {
+	key, err := crypto.GenerateKey()
+	r.NoError(err)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		To:        &testAddr,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(30),
+		GasTipCap: big.NewInt(1),
+		Value:     big.NewInt(1),
+	})
+	tx, err = types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(1)), key)
+	r.NoError(err)
+	return tx
+}
+
+func TestSimulation_TraceReverts(t *testing.T) {
+	r := require.New(t)
+
+	mockBackend := mock_contract_backend.NewMockContractBackend(gomock.NewController(t))
+
+	revertOutput := packRevertReason(r, "insufficient balance")
+	trace := &etherclient.TracedCall{
+		Error:  "execution reverted",
+		Output: revertOutput,
+		Calls: []*etherclient.TracedCall{
+			{CallType: "CALL"},
+			{CallType: "STATICCALL", Error: "execution reverted", Output: revertOutput},
+		},
+	}
+	caller := rpcCallerFunc(func(result interface{}, method string) error {
+		r.Equal("debug_traceCall", method)
+		b, _ := json.Marshal(trace)
+		return json.Unmarshal(b, result)
+	})
+	backend := &contractBackend{ContractBackend: mockBackend, simulator: &simulator{caller: caller}}
+
+	// Given that the transaction reverts in the trace
+	testTx := signTestTx(r)
+
+	// When the transaction is sent
+	err := backend.SendTransaction(context.Background(), testTx)
+
+	// Then it should not be broadcast and the error should point at the failing frame
+	var simErr *SimulationError
+	r.True(errors.As(err, &simErr))
+	r.Equal("insufficient balance", simErr.Reason)
+	r.Equal(testTx.Hash(), simErr.TxHash)
+	r.NotNil(simErr.Frame)
+	r.Equal("STATICCALL", simErr.Frame.CallType)
+}
+
+func TestSimulation_CallFallback(t *testing.T) {
+	r := require.New(t)
+
+	mockBackend := mock_contract_backend.NewMockContractBackend(gomock.NewController(t))
+
+	revertOutput := packRevertReason(r, "paused")
+	caller := rpcCallerFunc(func(result interface{}, method string) error {
+		switch method {
+		case "debug_traceCall":
+			return errors.New("the method debug_traceCall does not exist/is not available")
+		case "eth_call":
+			return &testDataError{data: revertOutput}
+		}
+		return nil
+	})
+	backend := &contractBackend{ContractBackend: mockBackend, simulator: &simulator{caller: caller}}
+
+	// Given that tracing is unavailable and the call reverts
+	testTx := signTestTx(r)
+
+	// When the transaction is sent
+	err := backend.SendTransaction(context.Background(), testTx)
+
+	// Then it should not be broadcast and the reason should be decoded from the call error
+	var simErr *SimulationError
+	r.True(errors.As(err, &simErr))
+	r.Equal("paused", simErr.Reason)
+	r.Nil(simErr.Frame)
+}
+
+func TestSimulation_TraceError(t *testing.T) {
+	r := require.New(t)
+
+	mockBackend := mock_contract_backend.NewMockContractBackend(gomock.NewController(t))
+
+	caller := rpcCallerFunc(func(result interface{}, method string) error {
+		r.Equal("debug_traceCall", method, "should not fall back to eth_call")
+		return context.DeadlineExceeded
+	})
+	backend := &contractBackend{ContractBackend: mockBackend, simulator: &simulator{caller: caller}}
+
+	// Given that tracing times out
+	testTx := signTestTx(r)
+
+	// When the transaction is sent
+	err := backend.SendTransaction(context.Background(), testTx)
+
+	// Then it should not be broadcast
+	r.ErrorContains(err, context.DeadlineExceeded.Error())
+	var simErr *SimulationError
+	r.False(errors.As(err, &simErr))
+}
+
+func TestSimulation_Success(t *testing.T) {
+	r := require.New(t)
+
+	mockBackend := mock_contract_backend.NewMockContractBackend(gomock.NewController(t))
+	mockBackend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil)
+
+	caller := rpcCallerFunc(func(result interface{}, method string) error {
+		return json.Unmarshal([]byte(`{"type":"CALL"}`), result)
+	})
+	backend := &contractBackend{ContractBackend: mockBackend, simulator: &simulator{caller: caller}}
+
+	// When a non-reverting transaction is sent
+	testTx := signTestTx(r)
+
+	// Then it should be broadcast
+	r.NoError(backend.SendTransaction(context.Background(), testTx))
+	r.Equal(testTx.Nonce()+1, backend.nonce)
+}
+
+func TestSimulation_Skipped(t *testing.T) {
+	r := require.New(t)
+
+	mockBackend := mock_contract_backend.NewMockContractBackend(gomock.NewController(t))
+	mockBackend.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil)
+
+	caller := rpcCallerFunc(func(result interface{}, method string) error {
+		r.FailNow("should not simulate")
+		return nil
+	})
+	backend := &contractBackend{ContractBackend: mockBackend, simulator: &simulator{caller: caller}}
+
+	// When the transaction is sent with simulation disabled
+	testTx := signTestTx(r)
+
+	// Then it should be broadcast without simulating
+	r.NoError(backend.SendTransaction(WithoutSimulation(context.Background()), testTx))
+}
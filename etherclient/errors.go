@@ -2,20 +2,20 @@ package etherclient
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/cenkalti/backoff"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/sirupsen/logrus"
 )
 
 // any non-retriable failure errors can be listed here
 var permanentErrors = []string{
-	"method not found",
 	"hash is not currently canonical",
 	//"unknown block",
 	"unable to complete request at this time",
 	"503 service unavailable",
-	"trace_block is not available",
 	"invalid host",
 	"receipt was empty",
 }
@@ -51,3 +51,30 @@ func handleRetryErr(ctx context.Context, method string, err error) error {
 	logger.WithError(err).Warn("failed...retrying")
 	return err
 }
+
+// methodNotFoundCode is the JSON-RPC error code of the unknown methods.
+const methodNotFoundCode = -32601
+
+// methodUnavailableErrors are the messages of the clients which do not use the JSON-RPC error
+// code for the unknown methods. They mention the method so that the other errors are not mistaken
+// for them, e.g. geth's pruned state error "historical state <root> is not available".
+var methodUnavailableErrors = []string{
+	"method not found",
+	"does not exist/is not available",
+	"trace_block is not available",
+}
+
+// IsMethodUnavailable tells if the error means that the provider does not support the JSON-RPC method.
+func IsMethodUnavailable(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+		return true
+	}
+	errStr := strings.ToLower(err.Error())
+	for _, s := range methodUnavailableErrors {
+		if strings.Contains(errStr, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package etherclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRPCError struct {
+	code    int
+	message string
+}
+
+func (e *testRPCError) Error() string  { return e.message }
+func (e *testRPCError) ErrorCode() int { return e.code }
+
+func TestIsMethodUnavailable(t *testing.T) {
+	r := require.New(t)
+
+	r.True(IsMethodUnavailable(&testRPCError{code: -32601, message: "the method debug_traceTransaction does not exist/is not available"}))
+	r.True(IsMethodUnavailable(&testRPCError{code: -32000, message: "Method not found"}))
+	r.True(IsMethodUnavailable(errors.New("the method debug_traceCall does not exist/is not available")))
+	r.True(IsMethodUnavailable(errors.New("trace_block is not available")))
+	// the pruned state is not a missing method
+	r.False(IsMethodUnavailable(&testRPCError{code: -32000, message: "historical state 0x1234 is not available"}))
+	r.False(IsMethodUnavailable(errors.New("historical state 0x1234 is not available")))
+	r.False(IsMethodUnavailable(context.DeadlineExceeded))
+}
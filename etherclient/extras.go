@@ -54,15 +54,36 @@ type TraceCallTransaction struct {
 
 // TraceCallConfig contains the tracer configuration to be used while simulating the transaction.
 type TraceCallConfig struct {
-	Tracer         string                 `json:"tracer,omitempty"`
-	TracerConfig   *TracerConfig          `json:"tracerConfig,omitempty"`
-	StateOverrides map[string]interface{} `json:"stateOverrides,omitempty"`
+	Tracer         string          `json:"tracer,omitempty"`
+	TracerConfig   *TracerConfig   `json:"tracerConfig,omitempty"`
+	StateOverrides StateOverride   `json:"stateOverrides,omitempty"`
+	BlockOverrides *BlockOverrides `json:"blockOverrides,omitempty"`
+	// Timeout is a duration string (e.g. "10s") which overrides the default tracing timeout.
+	Timeout string  `json:"timeout,omitempty"`
+	Reexec  *uint64 `json:"reexec,omitempty"`
+
+	// Struct logger (default tracer) options. These are historically not a part of the tracer config.
+	EnableMemory     bool `json:"enableMemory,omitempty"`
+	DisableStack     bool `json:"disableStack,omitempty"`
+	DisableStorage   bool `json:"disableStorage,omitempty"`
+	EnableReturnData bool `json:"enableReturnData,omitempty"`
+	Limit            int  `json:"limit,omitempty"`
 }
 
 // TracerConfig contains some extra tracer parameters.
 type TracerConfig struct {
+	// callTracer options
 	WithLog     bool `json:"withLog,omitempty"`
 	OnlyTopCall bool `json:"onlyTopCall,omitempty"`
+
+	// prestateTracer options
+	DiffMode       bool `json:"diffMode,omitempty"`
+	DisableCode    bool `json:"disableCode,omitempty"`
+	DisableStorage bool `json:"disableStorage,omitempty"`
+
+	// flatCallTracer options
+	ConvertParityErrors bool `json:"convertParityErrors,omitempty"`
+	IncludePrecompiles  bool `json:"includePrecompiles,omitempty"`
 }
 
 // TracedCall contains traced call data. This also represents the top level object
@@ -100,6 +121,12 @@ func (ec *etherClient) DebugTraceCall(
 	default:
 		return errors.New("invalid block number type")
 	}
+	if err := traceCallConfig.StateOverrides.Validate(); err != nil {
+		return fmt.Errorf("invalid state overrides: %v", err)
+	}
+	if err := traceCallConfig.BlockOverrides.Validate(); err != nil {
+		return fmt.Errorf("invalid block overrides: %v", err)
+	}
 
 	return ec.withBackoff(ctx, "DebugTraceCall()", func(ctx context.Context, ethClient *ethclient.Client) error {
 		return ethClient.Client().CallContext(ctx, &result, "debug_traceCall", req, block, traceCallConfig)
@@ -141,6 +168,18 @@ func (ec *etherClient) DebugTraceBlockByNumber(
 	})
 }
 
+func (ec *etherClient) DebugTraceBlockByHash(
+	ctx context.Context, blockHash common.Hash, traceCallConfig TraceCallConfig, result interface{},
+) error {
+	return ec.withBackoff(ctx, "DebugTraceBlockByHash()", func(ctx context.Context, ethClient *ethclient.Client) error {
+		return ethClient.Client().CallContext(ctx, &result, "debug_traceBlockByHash", blockHash, traceCallConfig)
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
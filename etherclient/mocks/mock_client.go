@@ -795,6 +795,21 @@ func (mr *MockEtherClientMockRecorder) CallContractAtHash(ctx, msg, blockHash in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallContractAtHash", reflect.TypeOf((*MockEtherClient)(nil).CallContractAtHash), ctx, msg, blockHash)
 }
 
+// CallContractWithOverrides mocks base method.
+func (m *MockEtherClient) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, stateOverride etherclient.StateOverride, blockOverrides *etherclient.BlockOverrides) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallContractWithOverrides", ctx, msg, blockNumber, stateOverride, blockOverrides)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallContractWithOverrides indicates an expected call of CallContractWithOverrides.
+func (mr *MockEtherClientMockRecorder) CallContractWithOverrides(ctx, msg, blockNumber, stateOverride, blockOverrides interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallContractWithOverrides", reflect.TypeOf((*MockEtherClient)(nil).CallContractWithOverrides), ctx, msg, blockNumber, stateOverride, blockOverrides)
+}
+
 // ChainID mocks base method.
 func (m *MockEtherClient) ChainID(ctx context.Context) (*big.Int, error) {
 	m.ctrl.T.Helper()
@@ -866,6 +881,20 @@ func (mr *MockEtherClientMockRecorder) CodeAtHash(ctx, account, blockHash interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CodeAtHash", reflect.TypeOf((*MockEtherClient)(nil).CodeAtHash), ctx, account, blockHash)
 }
 
+// DebugTraceBlockByHash mocks base method.
+func (m *MockEtherClient) DebugTraceBlockByHash(ctx context.Context, blockHash common.Hash, traceCallConfig etherclient.TraceCallConfig, result interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceBlockByHash", ctx, blockHash, traceCallConfig, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DebugTraceBlockByHash indicates an expected call of DebugTraceBlockByHash.
+func (mr *MockEtherClientMockRecorder) DebugTraceBlockByHash(ctx, blockHash, traceCallConfig, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceBlockByHash", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceBlockByHash), ctx, blockHash, traceCallConfig, result)
+}
+
 // DebugTraceBlockByNumber mocks base method.
 func (m *MockEtherClient) DebugTraceBlockByNumber(ctx context.Context, blockNumber *big.Int, traceCallConfig etherclient.TraceCallConfig, result interface{}) error {
 	m.ctrl.T.Helper()
@@ -908,6 +937,81 @@ func (mr *MockEtherClientMockRecorder) DebugTraceTransaction(ctx, txHash, traceC
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransaction", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceTransaction), ctx, txHash, traceCallConfig, result)
 }
 
+// DebugTraceTransaction4Byte mocks base method.
+func (m *MockEtherClient) DebugTraceTransaction4Byte(ctx context.Context, txHash string) (etherclient.FourByteResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransaction4Byte", ctx, txHash)
+	ret0, _ := ret[0].(etherclient.FourByteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransaction4Byte indicates an expected call of DebugTraceTransaction4Byte.
+func (mr *MockEtherClientMockRecorder) DebugTraceTransaction4Byte(ctx, txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransaction4Byte", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceTransaction4Byte), ctx, txHash)
+}
+
+// DebugTraceTransactionFlatCalls mocks base method.
+func (m *MockEtherClient) DebugTraceTransactionFlatCalls(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) ([]*etherclient.FlatTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionFlatCalls", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].([]*etherclient.FlatTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionFlatCalls indicates an expected call of DebugTraceTransactionFlatCalls.
+func (mr *MockEtherClientMockRecorder) DebugTraceTransactionFlatCalls(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionFlatCalls", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceTransactionFlatCalls), ctx, txHash, traceCallConfig)
+}
+
+// DebugTraceTransactionPrestate mocks base method.
+func (m *MockEtherClient) DebugTraceTransactionPrestate(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) (etherclient.PrestateResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionPrestate", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].(etherclient.PrestateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionPrestate indicates an expected call of DebugTraceTransactionPrestate.
+func (mr *MockEtherClientMockRecorder) DebugTraceTransactionPrestate(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionPrestate", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceTransactionPrestate), ctx, txHash, traceCallConfig)
+}
+
+// DebugTraceTransactionPrestateDiff mocks base method.
+func (m *MockEtherClient) DebugTraceTransactionPrestateDiff(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) (*etherclient.PrestateDiffResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionPrestateDiff", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].(*etherclient.PrestateDiffResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionPrestateDiff indicates an expected call of DebugTraceTransactionPrestateDiff.
+func (mr *MockEtherClientMockRecorder) DebugTraceTransactionPrestateDiff(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionPrestateDiff", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceTransactionPrestateDiff), ctx, txHash, traceCallConfig)
+}
+
+// DebugTraceTransactionStructLogs mocks base method.
+func (m *MockEtherClient) DebugTraceTransactionStructLogs(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) (*etherclient.StructLogResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionStructLogs", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].(*etherclient.StructLogResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionStructLogs indicates an expected call of DebugTraceTransactionStructLogs.
+func (mr *MockEtherClientMockRecorder) DebugTraceTransactionStructLogs(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionStructLogs", reflect.TypeOf((*MockEtherClient)(nil).DebugTraceTransactionStructLogs), ctx, txHash, traceCallConfig)
+}
+
 // EstimateGas mocks base method.
 func (m *MockEtherClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -983,6 +1087,21 @@ func (mr *MockEtherClientMockRecorder) GetBlockByNumber(ctx, number interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockByNumber", reflect.TypeOf((*MockEtherClient)(nil).GetBlockByNumber), ctx, number)
 }
 
+// GetBlockCallTraces mocks base method.
+func (m *MockEtherClient) GetBlockCallTraces(ctx context.Context, blockNumber *big.Int) (etherclient.TracedBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockCallTraces", ctx, blockNumber)
+	ret0, _ := ret[0].(etherclient.TracedBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockCallTraces indicates an expected call of GetBlockCallTraces.
+func (mr *MockEtherClientMockRecorder) GetBlockCallTraces(ctx, blockNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockCallTraces", reflect.TypeOf((*MockEtherClient)(nil).GetBlockCallTraces), ctx, blockNumber)
+}
+
 // GetBlockTransactions mocks base method.
 func (m *MockEtherClient) GetBlockTransactions(ctx context.Context, number *big.Int) ([]*etherclient.BlockTx, error) {
 	m.ctrl.T.Helper()
@@ -998,6 +1117,21 @@ func (mr *MockEtherClientMockRecorder) GetBlockTransactions(ctx, number interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockTransactions", reflect.TypeOf((*MockEtherClient)(nil).GetBlockTransactions), ctx, number)
 }
 
+// GetCallTrace mocks base method.
+func (m *MockEtherClient) GetCallTrace(ctx context.Context, txHash string) (*etherclient.TracedCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallTrace", ctx, txHash)
+	ret0, _ := ret[0].(*etherclient.TracedCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallTrace indicates an expected call of GetCallTrace.
+func (mr *MockEtherClientMockRecorder) GetCallTrace(ctx, txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallTrace", reflect.TypeOf((*MockEtherClient)(nil).GetCallTrace), ctx, txHash)
+}
+
 // HeaderByHash mocks base method.
 func (m *MockEtherClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
 	m.ctrl.T.Helper()
@@ -1321,6 +1455,56 @@ func (mr *MockEtherClientMockRecorder) SyncProgress(ctx interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncProgress", reflect.TypeOf((*MockEtherClient)(nil).SyncProgress), ctx)
 }
 
+// TraceBlock mocks base method.
+func (m *MockEtherClient) TraceBlock(ctx context.Context, blockNumber *big.Int) ([]*etherclient.FlatTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TraceBlock", ctx, blockNumber)
+	ret0, _ := ret[0].([]*etherclient.FlatTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceBlock indicates an expected call of TraceBlock.
+func (mr *MockEtherClientMockRecorder) TraceBlock(ctx, blockNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceBlock", reflect.TypeOf((*MockEtherClient)(nil).TraceBlock), ctx, blockNumber)
+}
+
+// TraceReplayBlockTransactions mocks base method.
+func (m *MockEtherClient) TraceReplayBlockTransactions(ctx context.Context, blockNumber *big.Int, traceTypes ...string) ([]*etherclient.ReplayedTransaction, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, blockNumber}
+	for _, a := range traceTypes {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "TraceReplayBlockTransactions", varargs...)
+	ret0, _ := ret[0].([]*etherclient.ReplayedTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceReplayBlockTransactions indicates an expected call of TraceReplayBlockTransactions.
+func (mr *MockEtherClientMockRecorder) TraceReplayBlockTransactions(ctx, blockNumber interface{}, traceTypes ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, blockNumber}, traceTypes...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceReplayBlockTransactions", reflect.TypeOf((*MockEtherClient)(nil).TraceReplayBlockTransactions), varargs...)
+}
+
+// TraceTransaction mocks base method.
+func (m *MockEtherClient) TraceTransaction(ctx context.Context, txHash string) ([]*etherclient.FlatTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TraceTransaction", ctx, txHash)
+	ret0, _ := ret[0].([]*etherclient.FlatTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceTransaction indicates an expected call of TraceTransaction.
+func (mr *MockEtherClientMockRecorder) TraceTransaction(ctx, txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceTransaction", reflect.TypeOf((*MockEtherClient)(nil).TraceTransaction), ctx, txHash)
+}
+
 // TransactionByHash mocks base method.
 func (m *MockEtherClient) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
 	m.ctrl.T.Helper()
@@ -1420,6 +1604,35 @@ func (m *MockExtras) EXPECT() *MockExtrasMockRecorder {
 	return m.recorder
 }
 
+// CallContractWithOverrides mocks base method.
+func (m *MockExtras) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, stateOverride etherclient.StateOverride, blockOverrides *etherclient.BlockOverrides) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallContractWithOverrides", ctx, msg, blockNumber, stateOverride, blockOverrides)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallContractWithOverrides indicates an expected call of CallContractWithOverrides.
+func (mr *MockExtrasMockRecorder) CallContractWithOverrides(ctx, msg, blockNumber, stateOverride, blockOverrides interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallContractWithOverrides", reflect.TypeOf((*MockExtras)(nil).CallContractWithOverrides), ctx, msg, blockNumber, stateOverride, blockOverrides)
+}
+
+// DebugTraceBlockByHash mocks base method.
+func (m *MockExtras) DebugTraceBlockByHash(ctx context.Context, blockHash common.Hash, traceCallConfig etherclient.TraceCallConfig, result interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceBlockByHash", ctx, blockHash, traceCallConfig, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DebugTraceBlockByHash indicates an expected call of DebugTraceBlockByHash.
+func (mr *MockExtrasMockRecorder) DebugTraceBlockByHash(ctx, blockHash, traceCallConfig, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceBlockByHash", reflect.TypeOf((*MockExtras)(nil).DebugTraceBlockByHash), ctx, blockHash, traceCallConfig, result)
+}
+
 // DebugTraceBlockByNumber mocks base method.
 func (m *MockExtras) DebugTraceBlockByNumber(ctx context.Context, blockNumber *big.Int, traceCallConfig etherclient.TraceCallConfig, result interface{}) error {
 	m.ctrl.T.Helper()
@@ -1462,6 +1675,81 @@ func (mr *MockExtrasMockRecorder) DebugTraceTransaction(ctx, txHash, traceCallCo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransaction", reflect.TypeOf((*MockExtras)(nil).DebugTraceTransaction), ctx, txHash, traceCallConfig, result)
 }
 
+// DebugTraceTransaction4Byte mocks base method.
+func (m *MockExtras) DebugTraceTransaction4Byte(ctx context.Context, txHash string) (etherclient.FourByteResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransaction4Byte", ctx, txHash)
+	ret0, _ := ret[0].(etherclient.FourByteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransaction4Byte indicates an expected call of DebugTraceTransaction4Byte.
+func (mr *MockExtrasMockRecorder) DebugTraceTransaction4Byte(ctx, txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransaction4Byte", reflect.TypeOf((*MockExtras)(nil).DebugTraceTransaction4Byte), ctx, txHash)
+}
+
+// DebugTraceTransactionFlatCalls mocks base method.
+func (m *MockExtras) DebugTraceTransactionFlatCalls(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) ([]*etherclient.FlatTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionFlatCalls", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].([]*etherclient.FlatTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionFlatCalls indicates an expected call of DebugTraceTransactionFlatCalls.
+func (mr *MockExtrasMockRecorder) DebugTraceTransactionFlatCalls(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionFlatCalls", reflect.TypeOf((*MockExtras)(nil).DebugTraceTransactionFlatCalls), ctx, txHash, traceCallConfig)
+}
+
+// DebugTraceTransactionPrestate mocks base method.
+func (m *MockExtras) DebugTraceTransactionPrestate(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) (etherclient.PrestateResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionPrestate", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].(etherclient.PrestateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionPrestate indicates an expected call of DebugTraceTransactionPrestate.
+func (mr *MockExtrasMockRecorder) DebugTraceTransactionPrestate(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionPrestate", reflect.TypeOf((*MockExtras)(nil).DebugTraceTransactionPrestate), ctx, txHash, traceCallConfig)
+}
+
+// DebugTraceTransactionPrestateDiff mocks base method.
+func (m *MockExtras) DebugTraceTransactionPrestateDiff(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) (*etherclient.PrestateDiffResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionPrestateDiff", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].(*etherclient.PrestateDiffResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionPrestateDiff indicates an expected call of DebugTraceTransactionPrestateDiff.
+func (mr *MockExtrasMockRecorder) DebugTraceTransactionPrestateDiff(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionPrestateDiff", reflect.TypeOf((*MockExtras)(nil).DebugTraceTransactionPrestateDiff), ctx, txHash, traceCallConfig)
+}
+
+// DebugTraceTransactionStructLogs mocks base method.
+func (m *MockExtras) DebugTraceTransactionStructLogs(ctx context.Context, txHash string, traceCallConfig etherclient.TraceCallConfig) (*etherclient.StructLogResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugTraceTransactionStructLogs", ctx, txHash, traceCallConfig)
+	ret0, _ := ret[0].(*etherclient.StructLogResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebugTraceTransactionStructLogs indicates an expected call of DebugTraceTransactionStructLogs.
+func (mr *MockExtrasMockRecorder) DebugTraceTransactionStructLogs(ctx, txHash, traceCallConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugTraceTransactionStructLogs", reflect.TypeOf((*MockExtras)(nil).DebugTraceTransactionStructLogs), ctx, txHash, traceCallConfig)
+}
+
 // GetBlockByHash mocks base method.
 func (m *MockExtras) GetBlockByHash(ctx context.Context, hash common.Hash) (*etherclient.Block, error) {
 	m.ctrl.T.Helper()
@@ -1492,6 +1780,21 @@ func (mr *MockExtrasMockRecorder) GetBlockByNumber(ctx, number interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockByNumber", reflect.TypeOf((*MockExtras)(nil).GetBlockByNumber), ctx, number)
 }
 
+// GetBlockCallTraces mocks base method.
+func (m *MockExtras) GetBlockCallTraces(ctx context.Context, blockNumber *big.Int) (etherclient.TracedBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockCallTraces", ctx, blockNumber)
+	ret0, _ := ret[0].(etherclient.TracedBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockCallTraces indicates an expected call of GetBlockCallTraces.
+func (mr *MockExtrasMockRecorder) GetBlockCallTraces(ctx, blockNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockCallTraces", reflect.TypeOf((*MockExtras)(nil).GetBlockCallTraces), ctx, blockNumber)
+}
+
 // GetBlockTransactions mocks base method.
 func (m *MockExtras) GetBlockTransactions(ctx context.Context, number *big.Int) ([]*etherclient.BlockTx, error) {
 	m.ctrl.T.Helper()
@@ -1506,3 +1809,68 @@ func (mr *MockExtrasMockRecorder) GetBlockTransactions(ctx, number interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockTransactions", reflect.TypeOf((*MockExtras)(nil).GetBlockTransactions), ctx, number)
 }
+
+// GetCallTrace mocks base method.
+func (m *MockExtras) GetCallTrace(ctx context.Context, txHash string) (*etherclient.TracedCall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallTrace", ctx, txHash)
+	ret0, _ := ret[0].(*etherclient.TracedCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallTrace indicates an expected call of GetCallTrace.
+func (mr *MockExtrasMockRecorder) GetCallTrace(ctx, txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallTrace", reflect.TypeOf((*MockExtras)(nil).GetCallTrace), ctx, txHash)
+}
+
+// TraceBlock mocks base method.
+func (m *MockExtras) TraceBlock(ctx context.Context, blockNumber *big.Int) ([]*etherclient.FlatTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TraceBlock", ctx, blockNumber)
+	ret0, _ := ret[0].([]*etherclient.FlatTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceBlock indicates an expected call of TraceBlock.
+func (mr *MockExtrasMockRecorder) TraceBlock(ctx, blockNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceBlock", reflect.TypeOf((*MockExtras)(nil).TraceBlock), ctx, blockNumber)
+}
+
+// TraceReplayBlockTransactions mocks base method.
+func (m *MockExtras) TraceReplayBlockTransactions(ctx context.Context, blockNumber *big.Int, traceTypes ...string) ([]*etherclient.ReplayedTransaction, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, blockNumber}
+	for _, a := range traceTypes {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "TraceReplayBlockTransactions", varargs...)
+	ret0, _ := ret[0].([]*etherclient.ReplayedTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceReplayBlockTransactions indicates an expected call of TraceReplayBlockTransactions.
+func (mr *MockExtrasMockRecorder) TraceReplayBlockTransactions(ctx, blockNumber interface{}, traceTypes ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, blockNumber}, traceTypes...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceReplayBlockTransactions", reflect.TypeOf((*MockExtras)(nil).TraceReplayBlockTransactions), varargs...)
+}
+
+// TraceTransaction mocks base method.
+func (m *MockExtras) TraceTransaction(ctx context.Context, txHash string) ([]*etherclient.FlatTrace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TraceTransaction", ctx, txHash)
+	ret0, _ := ret[0].([]*etherclient.FlatTrace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TraceTransaction indicates an expected call of TraceTransaction.
+func (mr *MockExtrasMockRecorder) TraceTransaction(ctx, txHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TraceTransaction", reflect.TypeOf((*MockExtras)(nil).TraceTransaction), ctx, txHash)
+}
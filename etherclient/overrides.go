@@ -0,0 +1,156 @@
+package etherclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// OverrideAccount contains the account fields to override before executing a call.
+type OverrideAccount struct {
+	Nonce   *hexutil.Uint64 `json:"nonce,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+	Balance *hexutil.Big    `json:"balance,omitempty"`
+	// State replaces the whole storage of the account.
+	State map[common.Hash]common.Hash `json:"state,omitempty"`
+	// StateDiff overrides only the given storage slots.
+	StateDiff        map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+	MovePrecompileTo *common.Address             `json:"movePrecompileToAddress,omitempty"`
+}
+
+// StateOverride is the collection of overridden accounts.
+type StateOverride map[common.Address]OverrideAccount
+
+// Validate checks the overrides before they are sent to the node.
+func (so StateOverride) Validate() error {
+	for addr, account := range so {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if err := validateUint256("balance", account.Balance); err != nil {
+			return fmt.Errorf("account %s has invalid override: %v", addr.Hex(), err)
+		}
+		if account.MovePrecompileTo != nil {
+			if _, ok := so[*account.MovePrecompileTo]; ok {
+				return fmt.Errorf("account %s is already overridden", account.MovePrecompileTo.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+// BlockOverrides contains the block header fields to override before executing a call.
+type BlockOverrides struct {
+	Number      *hexutil.Big    `json:"number,omitempty"`
+	Difficulty  *hexutil.Big    `json:"difficulty,omitempty"`
+	Time        *hexutil.Uint64 `json:"time,omitempty"`
+	GasLimit    *hexutil.Uint64 `json:"gasLimit,omitempty"`
+	Coinbase    *common.Address `json:"feeRecipient,omitempty"`
+	PrevRandao  *common.Hash    `json:"prevRandao,omitempty"`
+	BaseFee     *hexutil.Big    `json:"baseFeePerGas,omitempty"`
+	BlobBaseFee *hexutil.Big    `json:"blobBaseFee,omitempty"`
+}
+
+// Validate checks the overrides before they are sent to the node.
+func (bo *BlockOverrides) Validate() error {
+	if bo == nil {
+		return nil
+	}
+	if err := validateUint256("number", bo.Number); err != nil {
+		return err
+	}
+	if err := validateUint256("difficulty", bo.Difficulty); err != nil {
+		return err
+	}
+	if err := validateUint256("baseFee", bo.BaseFee); err != nil {
+		return err
+	}
+	return validateUint256("blobBaseFee", bo.BlobBaseFee)
+}
+
+func validateUint256(name string, n *hexutil.Big) error {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case n.ToInt().Sign() < 0:
+		return fmt.Errorf("%s cannot be negative", name)
+	case n.ToInt().BitLen() > 256:
+		return fmt.Errorf("%s exceeds 256 bits", name)
+	}
+	return nil
+}
+
+// CallContractWithOverrides executes an eth_call after applying the given state and block overrides.
+func (ec *etherClient) CallContractWithOverrides(
+	ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int,
+	stateOverride StateOverride, blockOverrides *BlockOverrides,
+) (ret1 []byte, err error) {
+	if err := stateOverride.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid state override: %v", err)
+	}
+	if err := blockOverrides.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid block overrides: %v", err)
+	}
+
+	args := []interface{}{toCallArg(msg), toBlockNumArg(blockNumber), stateOverride}
+	if blockOverrides != nil {
+		args = append(args, blockOverrides)
+	}
+	err = ec.withBackoff(ctx, "CallContractWithOverrides()", func(ctx context.Context, ethClient *ethclient.Client) error {
+		var r1 hexutil.Bytes
+		e := ethClient.Client().CallContext(ctx, &r1, "eth_call", args...)
+		ret1 = r1
+		return e
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+	return
+}
+
+// toCallArg is a copy of the same function from the go-ethereum client.
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	if msg.BlobGasFeeCap != nil {
+		arg["maxFeePerBlobGas"] = (*hexutil.Big)(msg.BlobGasFeeCap)
+	}
+	if msg.BlobHashes != nil {
+		arg["blobVersionedHashes"] = msg.BlobHashes
+	}
+	if msg.AuthorizationList != nil {
+		arg["authorizationList"] = msg.AuthorizationList
+	}
+	return arg
+}
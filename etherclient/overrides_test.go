@@ -0,0 +1,107 @@
+package etherclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testEthAPI struct {
+	stateOverride  StateOverride
+	blockOverrides *BlockOverrides
+}
+
+func (api *testEthAPI) Call(
+	args map[string]interface{}, block string, stateOverride StateOverride, blockOverrides *BlockOverrides,
+) (hexutil.Bytes, error) {
+	api.stateOverride = stateOverride
+	api.blockOverrides = blockOverrides
+	return hexutil.Bytes{0x01}, nil
+}
+
+func TestStateOverrideValidate(t *testing.T) {
+	addr1 := common.HexToAddress("0x1")
+	addr2 := common.HexToAddress("0x2")
+	slots := map[common.Hash]common.Hash{{}: {}}
+
+	testCases := []struct {
+		name     string
+		override StateOverride
+		fail     bool
+	}{
+		{
+			name: "valid",
+			override: StateOverride{
+				addr1: {Balance: (*hexutil.Big)(big.NewInt(1)), StateDiff: slots},
+			},
+		},
+		{
+			name: "both state and state diff",
+			override: StateOverride{
+				addr1: {State: slots, StateDiff: slots},
+			},
+			fail: true,
+		},
+		{
+			name: "negative balance",
+			override: StateOverride{
+				addr1: {Balance: (*hexutil.Big)(big.NewInt(-1))},
+			},
+			fail: true,
+		},
+		{
+			name: "precompile moved to overridden account",
+			override: StateOverride{
+				addr1: {MovePrecompileTo: &addr2},
+				addr2: {},
+			},
+			fail: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.override.Validate()
+			if testCase.fail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCallContractWithOverrides(t *testing.T) {
+	r := require.New(t)
+
+	api := &testEthAPI{}
+	ec := newTestClient(r, "eth", api)
+
+	addr := common.HexToAddress("0x1")
+	code := hexutil.Bytes{0x60, 0x00}
+	number := (*hexutil.Big)(big.NewInt(100))
+	coinbase := common.HexToAddress("0x2")
+
+	result, err := ec.CallContractWithOverrides(
+		context.Background(), ethereum.CallMsg{To: &addr}, nil,
+		StateOverride{addr: {Code: &code}}, &BlockOverrides{Number: number, Coinbase: &coinbase},
+	)
+	r.NoError(err)
+	r.Equal([]byte{0x01}, result)
+	r.Equal(code, *api.stateOverride[addr].Code)
+	r.Equal(int64(100), api.blockOverrides.Number.ToInt().Int64())
+	r.Equal(coinbase, *api.blockOverrides.Coinbase)
+
+	// invalid overrides are not sent
+	api.blockOverrides = nil
+	_, err = ec.CallContractWithOverrides(
+		context.Background(), ethereum.CallMsg{To: &addr}, nil,
+		nil, &BlockOverrides{BaseFee: (*hexutil.Big)(big.NewInt(-1))},
+	)
+	r.Error(err)
+	r.Nil(api.blockOverrides)
+}
@@ -0,0 +1,338 @@
+package etherclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Tracing API families detected per provider.
+const (
+	traceAPIUnknown int32 = iota
+	traceAPIDebug
+	traceAPIParity
+)
+
+// traceAPIRecheckInterval is how long a provider uses the parity tracing API before the debug
+// API is tried again.
+const traceAPIRecheckInterval = time.Minute * 10
+
+// usesParityTraces tells if the debug API was recently found unavailable on the provider.
+func (ecw *ethClientWrapper) usesParityTraces() bool {
+	return ecw.traceAPI.Load() == traceAPIParity &&
+		time.Since(time.Unix(0, ecw.traceAPIDetectedAt.Load())) < traceAPIRecheckInterval
+}
+
+func (ecw *ethClientWrapper) setTraceAPI(traceAPI int32) {
+	ecw.traceAPIDetectedAt.Store(time.Now().UnixNano())
+	ecw.traceAPI.Store(traceAPI)
+}
+
+// Parity trace types
+const (
+	FlatTraceTypeCall    = "call"
+	FlatTraceTypeCreate  = "create"
+	FlatTraceTypeSuicide = "suicide"
+	FlatTraceTypeReward  = "reward"
+)
+
+// ReplayedTransaction is a single transaction result from trace_replayBlockTransactions.
+type ReplayedTransaction struct {
+	Output          hexutil.Bytes   `json:"output"`
+	StateDiff       json.RawMessage `json:"stateDiff"`
+	Trace           []*FlatTrace    `json:"trace"`
+	TransactionHash common.Hash     `json:"transactionHash"`
+	VmTrace         json.RawMessage `json:"vmTrace"`
+}
+
+// parityErrors maps the Parity/OpenEthereum errors to the call tracer errors.
+var parityErrors = map[string]string{
+	"Reverted":                       "execution reverted",
+	"Out of gas":                     "out of gas",
+	"Bad instruction":                "invalid opcode",
+	"Bad jump destination":           "invalid jump destination",
+	"Stack underflow":                "stack underflow",
+	"Mutable Call In Static Context": "write protection",
+}
+
+func (ec *etherClient) TraceBlock(ctx context.Context, blockNumber *big.Int) (ret1 []*FlatTrace, err error) {
+	err = ec.withBackoff(ctx, "TraceBlock()", func(ctx context.Context, ethClient *ethclient.Client) error {
+		return ethClient.Client().CallContext(ctx, &ret1, "trace_block", toBlockNumArg(blockNumber))
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+	return
+}
+
+func (ec *etherClient) TraceTransaction(ctx context.Context, txHash string) (ret1 []*FlatTrace, err error) {
+	err = ec.withBackoff(ctx, "TraceTransaction()", func(ctx context.Context, ethClient *ethclient.Client) error {
+		return ethClient.Client().CallContext(ctx, &ret1, "trace_transaction", txHash)
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+	return
+}
+
+// TraceReplayBlockTransactions replays all transactions in a block. The trace types
+// default to "trace" if not specified.
+func (ec *etherClient) TraceReplayBlockTransactions(
+	ctx context.Context, blockNumber *big.Int, traceTypes ...string,
+) (ret1 []*ReplayedTransaction, err error) {
+	if len(traceTypes) == 0 {
+		traceTypes = []string{"trace"}
+	}
+	err = ec.withBackoff(ctx, "TraceReplayBlockTransactions()", func(ctx context.Context, ethClient *ethclient.Client) error {
+		return ethClient.Client().CallContext(
+			ctx, &ret1, "trace_replayBlockTransactions", toBlockNumArg(blockNumber), traceTypes,
+		)
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+	return
+}
+
+// GetCallTrace returns the call tree of a transaction by using the tracing API that the
+// current provider supports. Logs are only available if the provider supports the debug API.
+func (ec *etherClient) GetCallTrace(ctx context.Context, txHash string) (ret1 *TracedCall, err error) {
+	err = ec.withProviderBackoff(ctx, "GetCallTrace()", func(ctx context.Context, wrapper *ethClientWrapper) error {
+		if !wrapper.usesParityTraces() {
+			var r1 TracedCall
+			e := wrapper.Client.Client().CallContext(ctx, &r1, "debug_traceTransaction", txHash, TraceCallConfig{
+				Tracer:       TracerCall,
+				TracerConfig: &TracerConfig{WithLog: true},
+			})
+			if e == nil {
+				wrapper.setTraceAPI(traceAPIDebug)
+				ret1 = &r1
+				return nil
+			}
+			if !IsMethodUnavailable(e) {
+				return e
+			}
+			wrapper.setTraceAPI(traceAPIParity)
+		}
+
+		var traces []*FlatTrace
+		if e := wrapper.Client.Client().CallContext(ctx, &traces, "trace_transaction", txHash); e != nil {
+			if IsMethodUnavailable(e) {
+				// probe the debug API again with the next request
+				wrapper.setTraceAPI(traceAPIUnknown)
+			}
+			return e
+		}
+		r1, e := NormalizeFlatTraces(traces)
+		if e != nil {
+			return backoff.Permanent(e)
+		}
+		ret1 = r1
+		return nil
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+	return
+}
+
+// GetBlockCallTraces returns the call trees of all transactions in a block by using the
+// tracing API that the current provider supports.
+func (ec *etherClient) GetBlockCallTraces(ctx context.Context, blockNumber *big.Int) (ret1 TracedBlock, err error) {
+	err = ec.withProviderBackoff(ctx, "GetBlockCallTraces()", func(ctx context.Context, wrapper *ethClientWrapper) error {
+		if !wrapper.usesParityTraces() {
+			var r1 TracedBlock
+			e := wrapper.Client.Client().CallContext(
+				ctx, &r1, "debug_traceBlockByNumber", toBlockNumArg(blockNumber), TraceCallConfig{
+					Tracer:       TracerCall,
+					TracerConfig: &TracerConfig{WithLog: true},
+				},
+			)
+			if e == nil {
+				wrapper.setTraceAPI(traceAPIDebug)
+				ret1 = r1
+				return nil
+			}
+			if !IsMethodUnavailable(e) {
+				return e
+			}
+			wrapper.setTraceAPI(traceAPIParity)
+		}
+
+		var traces []*FlatTrace
+		if e := wrapper.Client.Client().CallContext(ctx, &traces, "trace_block", toBlockNumArg(blockNumber)); e != nil {
+			if IsMethodUnavailable(e) {
+				// probe the debug API again with the next request
+				wrapper.setTraceAPI(traceAPIUnknown)
+			}
+			return e
+		}
+		r1, e := NormalizeFlatBlockTraces(traces)
+		if e != nil {
+			return backoff.Permanent(e)
+		}
+		ret1 = r1
+		return nil
+	}, retryOptions{
+		MinBackoff:     ec.retryInterval,
+		MaxElapsedTime: 1 * time.Minute,
+		MaxBackoff:     ec.retryInterval,
+	})
+	return
+}
+
+// NormalizeFlatBlockTraces converts the flat traces of a block to call trees, in the same
+// format as the call tracer returns. Block rewards are ignored.
+func NormalizeFlatBlockTraces(traces []*FlatTrace) (TracedBlock, error) {
+	var (
+		txHashes []common.Hash
+		txTraces = make(map[common.Hash][]*FlatTrace)
+	)
+	for _, trace := range traces {
+		if trace.TransactionHash == nil {
+			continue
+		}
+		txHash := *trace.TransactionHash
+		if _, ok := txTraces[txHash]; !ok {
+			txHashes = append(txHashes, txHash)
+		}
+		txTraces[txHash] = append(txTraces[txHash], trace)
+	}
+
+	block := make(TracedBlock, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		call, err := NormalizeFlatTraces(txTraces[txHash])
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize traces of tx %s: %v", txHash.Hex(), err)
+		}
+		block = append(block, &BlockTraceTx{TxHash: txHash.Hex(), Result: call})
+	}
+	return block, nil
+}
+
+// NormalizeFlatTraces converts the flat traces of a single transaction to a call tree, in the
+// same format as the call tracer returns.
+func NormalizeFlatTraces(traces []*FlatTrace) (*TracedCall, error) {
+	sorted := slices.Clone(traces)
+	slices.SortStableFunc(sorted, func(a, b *FlatTrace) int {
+		return slices.Compare(a.TraceAddress, b.TraceAddress)
+	})
+
+	var root *TracedCall
+	calls := make(map[string]*TracedCall)
+	for _, trace := range sorted {
+		if trace.Type == FlatTraceTypeReward {
+			continue
+		}
+		call := flatTraceToCall(trace)
+		calls[traceAddressKey(trace.TraceAddress)] = call
+		if len(trace.TraceAddress) == 0 {
+			if root != nil {
+				return nil, errors.New("found multiple root traces")
+			}
+			root = call
+			continue
+		}
+		parent, ok := calls[traceAddressKey(trace.TraceAddress[:len(trace.TraceAddress)-1])]
+		if !ok {
+			return nil, fmt.Errorf("parent of trace %v not found", trace.TraceAddress)
+		}
+		parent.Calls = append(parent.Calls, call)
+	}
+	if root == nil {
+		return nil, errors.New("root trace not found")
+	}
+	return root, nil
+}
+
+func traceAddressKey(traceAddress []int) string {
+	return fmt.Sprint(traceAddress)
+}
+
+func flatTraceToCall(trace *FlatTrace) *TracedCall {
+	action := trace.Action
+	call := &TracedCall{
+		Input: "0x",
+		Error: normalizeParityError(trace.Error),
+	}
+	if trace.Result != nil {
+		call.GasUsed = uint64ToBig(trace.Result.GasUsed)
+	}
+
+	switch trace.Type {
+	case FlatTraceTypeCreate:
+		call.CallType = "CREATE"
+		if len(action.CreationMethod) > 0 {
+			call.CallType = strings.ToUpper(action.CreationMethod)
+		}
+		call.From = addressOrZero(action.From)
+		call.Value = action.Value
+		call.Input = bytesOrEmpty(action.Init, "0x")
+		if trace.Result != nil {
+			call.To = addressOrZero(trace.Result.Address)
+			call.Output = bytesOrEmpty(trace.Result.Code, "")
+		}
+
+	case FlatTraceTypeSuicide:
+		call.CallType = "SELFDESTRUCT"
+		call.From = addressOrZero(action.SelfDestructed)
+		call.To = addressOrZero(action.RefundAddress)
+		call.Value = action.Balance
+
+	default:
+		call.CallType = strings.ToUpper(action.CallType)
+		call.From = addressOrZero(action.From)
+		call.To = addressOrZero(action.To)
+		call.Input = bytesOrEmpty(action.Input, "0x")
+		// the call tracer does not report value for these
+		if call.CallType != "DELEGATECALL" && call.CallType != "STATICCALL" {
+			call.Value = action.Value
+		}
+		if trace.Result != nil {
+			call.Output = bytesOrEmpty(trace.Result.Output, "")
+		}
+	}
+	return call
+}
+
+func normalizeParityError(errStr string) string {
+	if normalized, ok := parityErrors[errStr]; ok {
+		return normalized
+	}
+	return errStr
+}
+
+func addressOrZero(addr *common.Address) common.Address {
+	if addr == nil {
+		return common.Address{}
+	}
+	return *addr
+}
+
+func bytesOrEmpty(b *hexutil.Bytes, empty string) string {
+	if b == nil {
+		return empty
+	}
+	return b.String()
+}
+
+func uint64ToBig(n *hexutil.Uint64) *hexutil.Big {
+	if n == nil {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).SetUint64(uint64(*n)))
+}
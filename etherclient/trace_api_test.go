@@ -0,0 +1,217 @@
+package etherclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/forta-network/core-go/etherclient/provider"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+const testParityTxTraces = `[
+	{
+		"action": {"callType": "call", "from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002", "gas": "0x10000", "input": "0x12345678", "value": "0x5"},
+		"blockNumber": 1, "error": "Reverted", "subtraces": 2, "traceAddress": [],
+		"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000011", "transactionPosition": 0, "type": "call"
+	},
+	{
+		"action": {"callType": "delegatecall", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000003", "gas": "0x100", "input": "0x", "value": "0x0"},
+		"blockNumber": 1, "result": {"gasUsed": "0x10", "output": "0xabcd"}, "subtraces": 1, "traceAddress": [0],
+		"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000011", "transactionPosition": 0, "type": "call"
+	},
+	{
+		"action": {"from": "0x0000000000000000000000000000000000000002", "gas": "0x100", "init": "0x6000", "value": "0x0"},
+		"blockNumber": 1, "result": {"address": "0x0000000000000000000000000000000000000005", "code": "0x00", "gasUsed": "0x20"}, "subtraces": 0, "traceAddress": [1],
+		"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000011", "transactionPosition": 0, "type": "create"
+	},
+	{
+		"action": {"address": "0x0000000000000000000000000000000000000003", "refundAddress": "0x0000000000000000000000000000000000000004", "balance": "0x7"},
+		"blockNumber": 1, "subtraces": 0, "traceAddress": [0, 0],
+		"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000011", "transactionPosition": 0, "type": "suicide"
+	}
+]`
+
+const testParityRewardTrace = `{
+	"action": {"author": "0x0000000000000000000000000000000000000009", "rewardType": "block", "value": "0x1"},
+	"blockNumber": 1, "subtraces": 0, "traceAddress": [], "type": "reward"
+}`
+
+type testParityAPI struct{}
+
+func (api *testParityAPI) Transaction(txHash string) (json.RawMessage, error) {
+	return json.RawMessage(testParityTxTraces), nil
+}
+
+func (api *testParityAPI) Block(blockNumber string) (json.RawMessage, error) {
+	var traces []json.RawMessage
+	if err := json.Unmarshal([]byte(testParityTxTraces), &traces); err != nil {
+		return nil, err
+	}
+	traces = append(traces, json.RawMessage(testParityRewardTrace))
+	return json.Marshal(traces)
+}
+
+func requireNormalizedTxTrace(r *require.Assertions, call *TracedCall) {
+	r.Equal("CALL", call.CallType)
+	r.Equal("execution reverted", call.Error)
+	r.Equal("0x12345678", call.Input)
+	r.Equal(int64(5), call.Value.ToInt().Int64())
+	r.Len(call.Calls, 2)
+
+	delegateCall := call.Calls[0]
+	r.Equal("DELEGATECALL", delegateCall.CallType)
+	r.Equal(common.HexToAddress("0x3"), delegateCall.To)
+	r.Nil(delegateCall.Value)
+	r.Equal("0xabcd", delegateCall.Output)
+	r.Equal(int64(16), delegateCall.GasUsed.ToInt().Int64())
+	r.Len(delegateCall.Calls, 1)
+
+	selfDestruct := delegateCall.Calls[0]
+	r.Equal("SELFDESTRUCT", selfDestruct.CallType)
+	r.Equal(common.HexToAddress("0x3"), selfDestruct.From)
+	r.Equal(common.HexToAddress("0x4"), selfDestruct.To)
+	r.Equal(int64(7), selfDestruct.Value.ToInt().Int64())
+
+	create := call.Calls[1]
+	r.Equal("CREATE", create.CallType)
+	r.Equal(common.HexToAddress("0x5"), create.To)
+	r.Equal("0x6000", create.Input)
+	r.Equal("0x00", create.Output)
+}
+
+func TestNormalizeFlatTraces(t *testing.T) {
+	r := require.New(t)
+
+	var traces []*FlatTrace
+	r.NoError(json.Unmarshal([]byte(testParityTxTraces), &traces))
+
+	// shuffle the input to see that the tree is built by trace addresses
+	traces[1], traces[3] = traces[3], traces[1]
+
+	call, err := NormalizeFlatTraces(traces)
+	r.NoError(err)
+	requireNormalizedTxTrace(r, call)
+
+	_, err = NormalizeFlatTraces(traces[1:])
+	r.Error(err)
+}
+
+func TestGetCallTrace_ParityFallback(t *testing.T) {
+	r := require.New(t)
+
+	// the test server does not have the debug namespace
+	ec := newTestClient(r, "trace", &testParityAPI{})
+
+	call, err := ec.GetCallTrace(context.Background(), "0x11")
+	r.NoError(err)
+	requireNormalizedTxTrace(r, call)
+	r.Equal(traceAPIParity, ec.provider.Provide().traceAPI.Load())
+
+	block, err := ec.GetBlockCallTraces(context.Background(), big.NewInt(1))
+	r.NoError(err)
+	r.Len(block, 1)
+	r.Equal(common.HexToHash("0x11").Hex(), block[0].TxHash)
+	requireNormalizedTxTrace(r, block[0].Result)
+}
+
+func TestGetCallTrace_Debug(t *testing.T) {
+	r := require.New(t)
+
+	api := &testDebugAPI{
+		results: map[string]string{
+			TracerCall: `{"type": "CALL", "from": "0x0000000000000000000000000000000000000001", "logs": [{"address": "0x0000000000000000000000000000000000000002"}]}`,
+		},
+	}
+	ec := newTestClient(r, "debug", api)
+
+	call, err := ec.GetCallTrace(context.Background(), "0x11")
+	r.NoError(err)
+	r.Equal("CALL", call.CallType)
+	r.Len(call.Logs, 1)
+	r.True(api.configs[0].TracerConfig.WithLog)
+	r.Equal(traceAPIDebug, ec.provider.Provide().traceAPI.Load())
+}
+
+// newTestProviders creates a client with a provider for each server.
+func newTestProviders(servers ...*rpc.Server) *etherClient {
+	var wrappers []*ethClientWrapper
+	for _, server := range servers {
+		wrappers = append(wrappers, &ethClientWrapper{Client: ethclient.NewClient(rpc.DialInProc(server))})
+	}
+	return &etherClient{
+		provider:      provider.NewRingProvider(wrappers...),
+		retryInterval: time.Millisecond,
+	}
+}
+
+func TestTraceBlock_ProviderRotation(t *testing.T) {
+	r := require.New(t)
+
+	// the first provider does not have the trace namespace
+	parityServer := rpc.NewServer()
+	r.NoError(parityServer.RegisterName("trace", &testParityAPI{}))
+	ec := newTestProviders(rpc.NewServer(), parityServer)
+
+	traces, err := ec.TraceBlock(context.Background(), big.NewInt(1))
+	r.NoError(err)
+	r.Len(traces, 5)
+
+	// no provider has the trace namespace
+	ec = newTestProviders(rpc.NewServer(), rpc.NewServer())
+	_, err = ec.TraceTransaction(context.Background(), "0x11")
+	r.Error(err)
+	r.True(IsMethodUnavailable(err))
+}
+
+func TestGetCallTrace_ProviderRotation(t *testing.T) {
+	r := require.New(t)
+
+	parityServer := rpc.NewServer()
+	r.NoError(parityServer.RegisterName("trace", &testParityAPI{}))
+	ec := newTestProviders(rpc.NewServer(), parityServer)
+	first := ec.provider.Provide()
+
+	call, err := ec.GetCallTrace(context.Background(), "0x11")
+	r.NoError(err)
+	requireNormalizedTxTrace(r, call)
+	// the provider without any tracing API is probed again later
+	r.Equal(traceAPIUnknown, first.traceAPI.Load())
+	r.Equal(traceAPIParity, ec.provider.Provide().traceAPI.Load())
+}
+
+func TestGetCallTrace_DebugRecheck(t *testing.T) {
+	r := require.New(t)
+
+	api := &testDebugAPI{
+		results: map[string]string{
+			TracerCall: `{"type": "CALL", "from": "0x0000000000000000000000000000000000000001"}`,
+		},
+	}
+	server := rpc.NewServer()
+	r.NoError(server.RegisterName("debug", api))
+	r.NoError(server.RegisterName("trace", &testParityAPI{}))
+	ec := newTestProviders(server)
+	wrapper := ec.provider.Provide()
+
+	// the debug API is not used while the provider is known to use the parity API
+	wrapper.setTraceAPI(traceAPIParity)
+	call, err := ec.GetCallTrace(context.Background(), "0x11")
+	r.NoError(err)
+	requireNormalizedTxTrace(r, call)
+	r.Empty(api.configs)
+
+	// and it is probed again later
+	wrapper.traceAPIDetectedAt.Store(time.Now().Add(-traceAPIRecheckInterval).UnixNano())
+	call, err = ec.GetCallTrace(context.Background(), "0x11")
+	r.NoError(err)
+	r.Equal("CALL", call.CallType)
+	r.Len(api.configs, 1)
+	r.Equal(traceAPIDebug, wrapper.traceAPI.Load())
+}
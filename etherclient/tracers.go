@@ -0,0 +1,172 @@
+package etherclient
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Known tracer names. The struct logger is used when no tracer is specified.
+const (
+	TracerCall     = "callTracer"
+	TracerPrestate = "prestateTracer"
+	Tracer4Byte    = "4byteTracer"
+	TracerFlatCall = "flatCallTracer"
+)
+
+// PrestateAccount contains the account state collected by the prestate tracer.
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// PrestateResult is the prestate tracer result in the default mode. It contains the
+// state of each touched account before the execution.
+type PrestateResult map[common.Address]*PrestateAccount
+
+// PrestateDiffResult is the prestate tracer result in the diff mode. The post state
+// only contains the modified accounts and fields.
+type PrestateDiffResult struct {
+	Pre  PrestateResult `json:"pre"`
+	Post PrestateResult `json:"post"`
+}
+
+// FourByteResult is the 4byte tracer result. The keys are in "<selector>-<calldata size>"
+// format (e.g. "0x27dc297e-128") and the values are the number of occurrences.
+type FourByteResult map[string]int
+
+// FlatTrace is a Parity-style flat trace as returned by the flat call tracer.
+type FlatTrace struct {
+	Action              FlatTraceAction  `json:"action"`
+	BlockHash           *common.Hash     `json:"blockHash"`
+	BlockNumber         uint64           `json:"blockNumber"`
+	Error               string           `json:"error,omitempty"`
+	Result              *FlatTraceResult `json:"result,omitempty"`
+	Subtraces           int              `json:"subtraces"`
+	TraceAddress        []int            `json:"traceAddress"`
+	TransactionHash     *common.Hash     `json:"transactionHash"`
+	TransactionPosition uint64           `json:"transactionPosition"`
+	Type                string           `json:"type"`
+}
+
+// FlatTraceAction contains the action fields of a flat trace. Which fields are set
+// depends on the trace type (call, create, suicide or reward).
+type FlatTraceAction struct {
+	Author         *common.Address `json:"author,omitempty"`
+	RewardType     string          `json:"rewardType,omitempty"`
+	SelfDestructed *common.Address `json:"address,omitempty"`
+	Balance        *hexutil.Big    `json:"balance,omitempty"`
+	CallType       string          `json:"callType,omitempty"`
+	CreationMethod string          `json:"creationMethod,omitempty"`
+	From           *common.Address `json:"from,omitempty"`
+	Gas            *hexutil.Uint64 `json:"gas,omitempty"`
+	Init           *hexutil.Bytes  `json:"init,omitempty"`
+	Input          *hexutil.Bytes  `json:"input,omitempty"`
+	RefundAddress  *common.Address `json:"refundAddress,omitempty"`
+	To             *common.Address `json:"to,omitempty"`
+	Value          *hexutil.Big    `json:"value,omitempty"`
+}
+
+// FlatTraceResult contains the result fields of a flat trace.
+type FlatTraceResult struct {
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+}
+
+// StructLogResult is the result of the default struct logger.
+type StructLogResult struct {
+	Gas         uint64       `json:"gas"`
+	Failed      bool         `json:"failed"`
+	ReturnValue string       `json:"returnValue"`
+	StructLogs  []*StructLog `json:"structLogs"`
+}
+
+// StructLog is a single opcode step captured by the struct logger.
+type StructLog struct {
+	Pc            uint64             `json:"pc"`
+	Op            string             `json:"op"`
+	Gas           uint64             `json:"gas"`
+	GasCost       uint64             `json:"gasCost"`
+	Depth         int                `json:"depth"`
+	Error         string             `json:"error,omitempty"`
+	Stack         *[]string          `json:"stack,omitempty"`
+	ReturnData    string             `json:"returnData,omitempty"`
+	Memory        *[]string          `json:"memory,omitempty"`
+	Storage       *map[string]string `json:"storage,omitempty"`
+	RefundCounter uint64             `json:"refund,omitempty"`
+}
+
+// DebugTraceTransactionPrestate traces the transaction with the prestate tracer in the default mode.
+func (ec *etherClient) DebugTraceTransactionPrestate(
+	ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+) (PrestateResult, error) {
+	traceCallConfig.Tracer = TracerPrestate
+	traceCallConfig.TracerConfig = withDiffMode(traceCallConfig.TracerConfig, false)
+	var result PrestateResult
+	if err := ec.DebugTraceTransaction(ctx, txHash, traceCallConfig, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DebugTraceTransactionPrestateDiff traces the transaction with the prestate tracer in the diff mode.
+func (ec *etherClient) DebugTraceTransactionPrestateDiff(
+	ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+) (*PrestateDiffResult, error) {
+	traceCallConfig.Tracer = TracerPrestate
+	traceCallConfig.TracerConfig = withDiffMode(traceCallConfig.TracerConfig, true)
+	var result PrestateDiffResult
+	if err := ec.DebugTraceTransaction(ctx, txHash, traceCallConfig, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// withDiffMode copies the tracer config so the caller's config is not mutated.
+func withDiffMode(tracerConfig *TracerConfig, diffMode bool) *TracerConfig {
+	var cfg TracerConfig
+	if tracerConfig != nil {
+		cfg = *tracerConfig
+	}
+	cfg.DiffMode = diffMode
+	return &cfg
+}
+
+// DebugTraceTransaction4Byte traces the transaction with the 4byte tracer.
+func (ec *etherClient) DebugTraceTransaction4Byte(ctx context.Context, txHash string) (FourByteResult, error) {
+	var result FourByteResult
+	if err := ec.DebugTraceTransaction(ctx, txHash, TraceCallConfig{Tracer: Tracer4Byte}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DebugTraceTransactionFlatCalls traces the transaction with the flat call tracer.
+func (ec *etherClient) DebugTraceTransactionFlatCalls(
+	ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+) ([]*FlatTrace, error) {
+	traceCallConfig.Tracer = TracerFlatCall
+	var result []*FlatTrace
+	if err := ec.DebugTraceTransaction(ctx, txHash, traceCallConfig, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DebugTraceTransactionStructLogs traces the transaction with the default struct logger.
+func (ec *etherClient) DebugTraceTransactionStructLogs(
+	ctx context.Context, txHash string, traceCallConfig TraceCallConfig,
+) (*StructLogResult, error) {
+	traceCallConfig.Tracer = ""
+	traceCallConfig.TracerConfig = nil
+	var result StructLogResult
+	if err := ec.DebugTraceTransaction(ctx, txHash, traceCallConfig, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,130 @@
+package etherclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/core-go/etherclient/provider"
+	"github.com/stretchr/testify/require"
+)
+
+type testDebugAPI struct {
+	configs []TraceCallConfig
+	results map[string]string
+}
+
+func (api *testDebugAPI) TraceTransaction(txHash string, config TraceCallConfig) (json.RawMessage, error) {
+	api.configs = append(api.configs, config)
+	return json.RawMessage(api.results[config.Tracer]), nil
+}
+
+func (api *testDebugAPI) TraceBlockByHash(blockHash common.Hash, config TraceCallConfig) (json.RawMessage, error) {
+	api.configs = append(api.configs, config)
+	if blockHash != common.HexToHash("0x1") {
+		return nil, errors.New("hash is not currently canonical")
+	}
+	return json.RawMessage(api.results[config.Tracer]), nil
+}
+
+func newTestClient(r *require.Assertions, namespace string, service interface{}) *etherClient {
+	server := rpc.NewServer()
+	r.NoError(server.RegisterName(namespace, service))
+	return &etherClient{
+		provider: provider.NewRingProvider(&ethClientWrapper{
+			Client: ethclient.NewClient(rpc.DialInProc(server)),
+		}),
+	}
+}
+
+func TestTracerResults(t *testing.T) {
+	r := require.New(t)
+
+	api := &testDebugAPI{
+		results: map[string]string{
+			TracerPrestate: `{
+				"pre": {"0x0000000000000000000000000000000000000001": {"balance": "0x10", "nonce": 1}},
+				"post": {"0x0000000000000000000000000000000000000001": {"balance": "0x5", "storage": {
+					"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"
+				}}}
+			}`,
+			Tracer4Byte: `{"0x27dc297e-128": 1}`,
+			TracerFlatCall: `[{
+				"action": {"callType": "call", "from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002", "gas": "0x5208", "input": "0x", "value": "0x1"},
+				"blockNumber": 10, "result": {"gasUsed": "0x0", "output": "0x"}, "subtraces": 0, "traceAddress": [], "type": "call"
+			}]`,
+			"": `{"gas": 21000, "failed": false, "returnValue": "", "structLogs": [{"pc": 0, "op": "STOP", "gas": 100, "gasCost": 0, "depth": 1, "stack": []}]}`,
+		},
+	}
+	ec := newTestClient(r, "debug", api)
+	ctx := context.Background()
+	addr1 := common.HexToAddress("0x1")
+
+	tracerConfig := &TracerConfig{DisableCode: true}
+	diff, err := ec.DebugTraceTransactionPrestateDiff(ctx, "0x1", TraceCallConfig{TracerConfig: tracerConfig, Timeout: "10s"})
+	r.NoError(err)
+	r.Equal(int64(16), diff.Pre[addr1].Balance.ToInt().Int64())
+	r.Equal(uint64(1), diff.Pre[addr1].Nonce)
+	r.Equal(common.HexToHash("0x2"), diff.Post[addr1].Storage[common.HexToHash("0x1")])
+	r.Equal(TracerPrestate, api.configs[0].Tracer)
+	r.Equal("10s", api.configs[0].Timeout)
+	r.True(api.configs[0].TracerConfig.DiffMode)
+	r.True(api.configs[0].TracerConfig.DisableCode)
+	r.False(tracerConfig.DiffMode, "caller's config should not be mutated")
+
+	fourByte, err := ec.DebugTraceTransaction4Byte(ctx, "0x1")
+	r.NoError(err)
+	r.Equal(1, fourByte["0x27dc297e-128"])
+
+	flatCalls, err := ec.DebugTraceTransactionFlatCalls(ctx, "0x1", TraceCallConfig{})
+	r.NoError(err)
+	r.Len(flatCalls, 1)
+	r.Equal("call", flatCalls[0].Action.CallType)
+	r.Equal(uint64(21000), uint64(*flatCalls[0].Action.Gas))
+	r.Equal(common.HexToAddress("0x2"), *flatCalls[0].Action.To)
+
+	structLogs, err := ec.DebugTraceTransactionStructLogs(ctx, "0x1", TraceCallConfig{Tracer: TracerCall, DisableStorage: true})
+	r.NoError(err)
+	r.Equal(uint64(21000), structLogs.Gas)
+	r.Len(structLogs.StructLogs, 1)
+	r.Equal("STOP", structLogs.StructLogs[0].Op)
+	r.True(api.configs[3].DisableStorage)
+}
+
+func TestDebugTraceBlockByHash(t *testing.T) {
+	r := require.New(t)
+
+	api := &testDebugAPI{
+		results: map[string]string{
+			TracerCall: `[
+				{"txHash": "0xaa", "result": {"from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002", "type": "CALL", "gasUsed": "0x5208", "value": "0x1", "calls": [
+					{"from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000003", "type": "STATICCALL", "gasUsed": "0x10"}
+				]}},
+				{"txHash": "0xbb", "result": {"from": "0x0000000000000000000000000000000000000003", "to": "0x0000000000000000000000000000000000000004", "type": "CALL", "error": "execution reverted"}}
+			]`,
+		},
+	}
+	ec := newTestClient(r, "debug", api)
+	ctx := context.Background()
+
+	var block TracedBlock
+	r.NoError(ec.DebugTraceBlockByHash(ctx, common.HexToHash("0x1"), TraceCallConfig{Tracer: TracerCall}, &block))
+	r.Equal(TracerCall, api.configs[0].Tracer)
+	r.Len(block, 2)
+	r.Equal("0xaa", block[0].TxHash)
+	r.Equal(common.HexToAddress("0x2"), block[0].Result.To)
+	r.Equal(int64(21000), block[0].Result.GasUsed.ToInt().Int64())
+	r.Len(block[0].Result.Calls, 1)
+	r.Equal("STATICCALL", block[0].Result.Calls[0].CallType)
+	r.Equal("0xbb", block[1].TxHash)
+	r.Equal("execution reverted", block[1].Result.Error)
+
+	// the non-canonical block hash is not retried
+	err := ec.DebugTraceBlockByHash(ctx, common.HexToHash("0x2"), TraceCallConfig{Tracer: TracerCall}, &block)
+	r.ErrorContains(err, "hash is not currently canonical")
+	r.Len(api.configs, 2)
+}
@@ -0,0 +1,154 @@
+// Package buffer buffers the items of the producers and sends them in batches.
+package buffer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Config configures a buffer.
+type Config struct {
+	// Size is the number of the items which can be buffered before Put blocks.
+	Size int
+	// FlushInterval is the maximum duration which the items are buffered for.
+	FlushInterval time.Duration
+	// ErrClosed is returned after the buffer is closed.
+	ErrClosed error
+}
+
+// Batcher collects the items into batches and sends them. Its methods are called by a single
+// goroutine and they return the errors of the items which could not be sent.
+type Batcher[T any] interface {
+	// Add adds the item to the batch and sends the batch if it is full.
+	Add(item T) []error
+	// Send sends the batch if it is not empty.
+	Send() []error
+}
+
+// flushRequest requests sending the buffered items and the errors since the previous flush.
+type flushRequest struct {
+	ctx    context.Context
+	result chan error
+}
+
+// Buffer buffers the items and passes them to the batcher. The batches are sent when they are
+// full or after the flush interval. The errors of the items which could not be sent are returned
+// by the next Flush or Close.
+type Buffer[T any] struct {
+	config   Config
+	batcher  Batcher[T]
+	items    chan T
+	flushes  chan *flushRequest
+	done     chan struct{}
+	closeErr error
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New creates a new buffer and starts sending the batches.
+func New[T any](config Config, batcher Batcher[T]) *Buffer[T] {
+	b := &Buffer[T]{
+		config:  config,
+		batcher: batcher,
+		items:   make(chan T, config.Size),
+		flushes: make(chan *flushRequest),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Put buffers the item.
+func (b *Buffer[T]) Put(ctx context.Context, item T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return b.config.ErrClosed
+	}
+	select {
+	case b.items <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush sends the buffered items and returns the errors of the items which could not be sent
+// since the previous flush. If the context is done before the errors are returned, they are
+// returned by the next flush.
+func (b *Buffer[T]) Flush(ctx context.Context) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return b.config.ErrClosed
+	}
+	req := &flushRequest{ctx: ctx, result: make(chan error)}
+	select {
+	case b.flushes <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends the buffered items and stops the buffer. It returns the errors of the items
+// which could not be sent since the previous flush.
+func (b *Buffer[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.items)
+	}
+	b.mu.Unlock()
+	select {
+	case <-b.done:
+		return b.closeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Buffer[T]) run() {
+	defer close(b.done)
+	var failures []error
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case item, ok := <-b.items:
+			if !ok {
+				failures = append(failures, b.batcher.Send()...)
+				b.closeErr = errors.Join(failures...)
+				return
+			}
+			failures = append(failures, b.batcher.Add(item)...)
+		case <-ticker.C:
+			failures = append(failures, b.batcher.Send()...)
+		case req := <-b.flushes:
+			// the channel is not closed while flushing
+			for drained := false; !drained; {
+				select {
+				case item := <-b.items:
+					failures = append(failures, b.batcher.Add(item)...)
+				default:
+					drained = true
+				}
+			}
+			failures = append(failures, b.batcher.Send()...)
+			select {
+			case req.result <- errors.Join(failures...):
+				failures = nil
+			case <-req.ctx.Done():
+				// the failures are kept for the next flush
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package shards
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/store/dynamo"
+)
+
+// checkpoint is the progress of a consumer in a shard.
+type checkpoint struct {
+	Consumer       string `dynamodbav:"consumer"`
+	ShardID        string `dynamodbav:"shardId"`
+	SequenceNumber string `dynamodbav:"sequenceNumber,omitempty"`
+	Finished       bool   `dynamodbav:"finished"`
+	// Skipped is set for the closed shards which were skipped when starting from the latest records.
+	Skipped   bool      `dynamodbav:"skipped,omitempty"`
+	UpdatedAt time.Time `dynamodbav:"updatedAt"`
+}
+
+func (checkpoint) GetPartitionKeyName() string {
+	return "consumer"
+}
+
+func (checkpoint) GetSortKeyName() string {
+	return "shardId"
+}
+
+// CheckpointTableSchema returns the schema of the checkpoint table.
+func CheckpointTableSchema(tableName string) *dynamo.TableSchema {
+	return dynamo.NewTableSchema[checkpoint](tableName)
+}
+
+type checkpoints struct {
+	consumer string
+	store    dynamo.Store[checkpoint]
+}
+
+func newCheckpoints(client aws.DynamoDBClient, tableName, consumer string) *checkpoints {
+	return &checkpoints{
+		consumer: consumer,
+		store:    dynamo.NewStore[checkpoint](client, tableName),
+	}
+}
+
+// get returns the checkpoint of the shard or nil if the shard was not consumed yet.
+func (c *checkpoints) get(ctx context.Context, shardID string) (*checkpoint, error) {
+	cp, err := c.store.Get(ctx, c.consumer, shardID)
+	if errors.Is(err, dynamo.ErrNotFound) {
+		return nil, nil
+	}
+	return cp, err
+}
+
+// skip finishes the shard without consuming it.
+func (c *checkpoints) skip(ctx context.Context, shardID string) error {
+	return c.store.Put(ctx, &checkpoint{
+		Consumer:  c.consumer,
+		ShardID:   shardID,
+		Finished:  true,
+		Skipped:   true,
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+func (c *checkpoints) put(ctx context.Context, shardID, sequenceNumber string, finished bool) error {
+	return c.store.Put(ctx, &checkpoint{
+		Consumer:       c.consumer,
+		ShardID:        shardID,
+		SequenceNumber: sequenceNumber,
+		Finished:       finished,
+		UpdatedAt:      time.Now().UTC(),
+	})
+}
@@ -0,0 +1,294 @@
+package shards
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrExpiredIterator is returned by the streams when a shard iterator is expired.
+var ErrExpiredIterator = errors.New("shard iterator is expired")
+
+// Shard is a shard of a DynamoDB or a Kinesis stream.
+type Shard struct {
+	ID string
+	// ParentIDs has two shards if the shard is merged from them.
+	ParentIDs []string
+	Closed    bool
+}
+
+// Records are the records which are read from a shard.
+type Records[R any] struct {
+	Records []R
+	// SequenceNumber is the sequence number of the last record.
+	SequenceNumber string
+	// NextIterator is nil if the shard is closed and all of its records are read.
+	NextIterator *string
+}
+
+// RecordError is the error of a record which cannot be converted. It stops the consumer.
+type RecordError struct {
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Stream reads the shards of a DynamoDB or a Kinesis stream.
+type Stream[R any] interface {
+	// ListShards lists all shards of the stream.
+	ListShards(ctx context.Context) ([]Shard, error)
+	// ShardIterator returns an iterator after the sequence number. If the sequence number is empty
+	// or no longer valid, the iterator starts from the oldest or the latest record.
+	ShardIterator(ctx context.Context, shardID, sequenceNumber string, latest bool) (*string, error)
+	// GetRecords reads the records from the iterator. It returns ErrExpiredIterator if the iterator
+	// is expired and a RecordError if a record cannot be converted.
+	GetRecords(ctx context.Context, shardID string, iterator *string) (*Records[R], error)
+}
+
+// Handler handles the records which are read from a shard.
+type Handler[R any] func(ctx context.Context, records []R) error
+
+// Config configures a consumer.
+type Config struct {
+	// StreamName identifies the stream in the logs.
+	StreamName          string
+	CheckpointTableName string
+	ConsumerName        string
+	StartFromLatest     bool
+	PollInterval        time.Duration
+	// ShardRefreshInterval is how often the new shards are discovered.
+	ShardRefreshInterval time.Duration
+}
+
+// Consumer consumes the shards of a stream and stores the progress in checkpoints. The child
+// shards are consumed after their parents are finished.
+type Consumer[R any] struct {
+	stream      Stream[R]
+	config      Config
+	handler     Handler[R]
+	checkpoints *checkpoints
+}
+
+// NewConsumer creates a new consumer.
+func NewConsumer[R any](client aws.DynamoDBClient, stream Stream[R], config Config, handler Handler[R]) *Consumer[R] {
+	return &Consumer[R]{
+		stream:      stream,
+		config:      config,
+		handler:     handler,
+		checkpoints: newCheckpoints(client, config.CheckpointTableName, config.ConsumerName),
+	}
+}
+
+// Run consumes the stream until the context is canceled or a record cannot be converted.
+// It returns nil when the context is canceled.
+func (c *Consumer[R]) Run(ctx context.Context) error {
+	g, ctx := errgroup.WithContext(ctx)
+	finishedShards := make(chan string)
+	g.Go(func() error {
+		started := make(map[string]bool)
+		finished := make(map[string]bool)
+		ticker := time.NewTicker(c.config.ShardRefreshInterval)
+		defer ticker.Stop()
+		for {
+			shards, err := c.stream.ListShards(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.WithError(err).WithField("stream", c.config.StreamName).Warn("failed to list shards")
+			}
+			for _, shard := range readyShards(shards, started, finished) {
+				shard := shard
+				started[shard.ID] = true
+				g.Go(func() error {
+					done, err := c.consumeShard(ctx, shard)
+					if err != nil || !done {
+						return err
+					}
+					select {
+					case finishedShards <- shard.ID:
+					case <-ctx.Done():
+					}
+					return nil
+				})
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			case shardID := <-finishedShards:
+				finished[shardID] = true
+			}
+		}
+	})
+	return g.Wait()
+}
+
+// readyShards returns the shards which are not started and whose parents are finished or trimmed.
+func readyShards(shards []Shard, started, finished map[string]bool) []Shard {
+	present := make(map[string]bool)
+	for _, shard := range shards {
+		present[shard.ID] = true
+	}
+	var ready []Shard
+	for _, shard := range shards {
+		if started[shard.ID] || waiting(shard, present, finished) {
+			continue
+		}
+		ready = append(ready, shard)
+	}
+	return ready
+}
+
+func waiting(shard Shard, present, finished map[string]bool) bool {
+	for _, parentID := range shard.ParentIDs {
+		if present[parentID] && !finished[parentID] {
+			return true
+		}
+	}
+	return false
+}
+
+// consumeShard consumes the shard until it is finished or the context is canceled.
+func (c *Consumer[R]) consumeShard(ctx context.Context, shard Shard) (bool, error) {
+	logger := log.WithField("stream", c.config.StreamName).WithField("shard", shard.ID)
+
+	var cp *checkpoint
+	if !c.retry(ctx, logger, "get checkpoint", func() (err error) {
+		cp, err = c.checkpoints.get(ctx, shard.ID)
+		return
+	}) {
+		return false, nil
+	}
+	if cp != nil && cp.Finished {
+		return true, nil
+	}
+	var (
+		sequenceNumber string
+		latest         bool
+	)
+	if cp != nil {
+		sequenceNumber = cp.SequenceNumber
+	} else if !c.retry(ctx, logger, "get parent checkpoint", func() (err error) {
+		latest, err = c.startsFromLatest(ctx, shard)
+		return
+	}) {
+		return false, nil
+	}
+	// skip the closed shards when starting from the latest records
+	if latest && shard.Closed {
+		return c.retry(ctx, logger, "put checkpoint", func() error {
+			return c.checkpoints.skip(ctx, shard.ID)
+		}), nil
+	}
+
+	var iterator *string
+	for {
+		if iterator == nil {
+			if !c.retry(ctx, logger, "get shard iterator", func() (err error) {
+				iterator, err = c.stream.ShardIterator(ctx, shard.ID, sequenceNumber, latest)
+				return
+			}) {
+				return false, nil
+			}
+		}
+
+		res, err := c.stream.GetRecords(ctx, shard.ID, iterator)
+		var recordErr *RecordError
+		switch {
+		case ctx.Err() != nil:
+			return false, nil
+		case errors.As(err, &recordErr):
+			return false, recordErr.Err
+		case errors.Is(err, ErrExpiredIterator):
+			iterator = nil
+			continue
+		case err != nil:
+			logger.WithError(err).Warn("failed to get records")
+			if !sleep(ctx, c.config.PollInterval) {
+				return false, nil
+			}
+			continue
+		}
+
+		if len(res.Records) > 0 {
+			if !c.retry(ctx, logger, "handle records", func() error {
+				return c.handler(ctx, res.Records)
+			}) {
+				return false, nil
+			}
+			sequenceNumber = res.SequenceNumber
+			if !c.retry(ctx, logger, "put checkpoint", func() error {
+				return c.checkpoints.put(ctx, shard.ID, sequenceNumber, false)
+			}) {
+				return false, nil
+			}
+		}
+
+		if res.NextIterator == nil {
+			return c.retry(ctx, logger, "put checkpoint", func() error {
+				return c.checkpoints.put(ctx, shard.ID, sequenceNumber, true)
+			}), nil
+		}
+		iterator = res.NextIterator
+		if len(res.Records) == 0 && !sleep(ctx, c.config.PollInterval) {
+			return false, nil
+		}
+	}
+}
+
+// startsFromLatest returns true if the shard which has no checkpoint is consumed from the latest
+// record. The child shards of the consumed shards are consumed from the oldest record, so the
+// records which are written to them before they are started are not skipped.
+func (c *Consumer[R]) startsFromLatest(ctx context.Context, shard Shard) (bool, error) {
+	if !c.config.StartFromLatest {
+		return false, nil
+	}
+	for _, parentID := range shard.ParentIDs {
+		cp, err := c.checkpoints.get(ctx, parentID)
+		if err != nil {
+			return false, err
+		}
+		if cp != nil && !cp.Skipped {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// retry runs the function until it succeeds or the context is canceled. It returns false if
+// the context is canceled.
+func (c *Consumer[R]) retry(ctx context.Context, logger *log.Entry, operation string, fn func() error) bool {
+	for {
+		err := fn()
+		if ctx.Err() != nil {
+			return false
+		}
+		if err == nil {
+			return true
+		}
+		logger.WithError(err).Warnf("failed to %s", operation)
+		if !sleep(ctx, c.config.PollInterval) {
+			return false
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
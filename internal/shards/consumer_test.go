@@ -0,0 +1,29 @@
+package shards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyShards(t *testing.T) {
+	r := require.New(t)
+	shards := []Shard{
+		{ID: "parent-1", ParentIDs: []string{"trimmed"}, Closed: true},
+		{ID: "parent-2", Closed: true},
+		{ID: "merged", ParentIDs: []string{"parent-1", "parent-2"}},
+	}
+	ids := func(shards []Shard) []string {
+		var ids []string
+		for _, shard := range shards {
+			ids = append(ids, shard.ID)
+		}
+		return ids
+	}
+
+	// the merged shard waits for both parents
+	r.Equal([]string{"parent-1", "parent-2"}, ids(readyShards(shards, nil, nil)))
+	started := map[string]bool{"parent-1": true, "parent-2": true}
+	r.Empty(readyShards(shards, started, map[string]bool{"parent-1": true}))
+	r.Equal([]string{"merged"}, ids(readyShards(shards, started, map[string]bool{"parent-1": true, "parent-2": true})))
+}
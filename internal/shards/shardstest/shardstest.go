@@ -0,0 +1,50 @@
+// Package shardstest has the test helpers of the shard consumers.
+package shardstest
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Handler keeps the handled records. It fails the first calls.
+type Handler[R any] struct {
+	mu      sync.Mutex
+	Records []R
+	fail    int
+	// Handled receives after every handled call.
+	Handled chan struct{}
+}
+
+// NewHandler creates a handler which fails the given number of calls first.
+func NewHandler[R any](fail int) *Handler[R] {
+	return &Handler[R]{fail: fail, Handled: make(chan struct{}, 10)}
+}
+
+// Handle keeps the records.
+func (h *Handler[R]) Handle(ctx context.Context, records []R) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fail > 0 {
+		h.fail--
+		return errors.New("test error")
+	}
+	h.Records = append(h.Records, records...)
+	h.Handled <- struct{}{}
+	return nil
+}
+
+// Consumer is a shard consumer.
+type Consumer interface {
+	Run(ctx context.Context) error
+}
+
+// Run runs the consumer until it is canceled and sends the result to the returned channel.
+func Run(consumer Consumer) (context.CancelFunc, chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- consumer.Run(ctx)
+	}()
+	return cancel, errCh
+}
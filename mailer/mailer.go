@@ -0,0 +1,205 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Mailer defaults
+const (
+	// DefaultMaxRecipients is the SES limit of the recipients per message.
+	DefaultMaxRecipients = 50
+)
+
+// SuppressionList checks if the addresses should not receive emails, e.g. after bounces and
+// complaints.
+type SuppressionList interface {
+	IsSuppressed(ctx context.Context, address string) (bool, error)
+}
+
+type staticSuppressionList map[string]bool
+
+// NewStaticSuppressionList creates a suppression list of the addresses. The addresses are
+// compared case-insensitively.
+func NewStaticSuppressionList(addresses ...string) SuppressionList {
+	list := make(staticSuppressionList)
+	for _, address := range addresses {
+		list[strings.ToLower(address)] = true
+	}
+	return list
+}
+
+func (list staticSuppressionList) IsSuppressed(ctx context.Context, address string) (bool, error) {
+	return list[strings.ToLower(address)], nil
+}
+
+// Config configures a mailer.
+type Config struct {
+	// From is the sender of the messages which have no sender.
+	From string
+	// ReplyTo is used for the messages which have no reply-to addresses.
+	ReplyTo []string
+	// MaxRecipients is the maximum number of the recipients per sent message. The messages which
+	// have more recipients are sent in batches.
+	MaxRecipients int
+	// SuppressionList is checked before sending if it is not nil. The suppressed recipients are skipped.
+	SuppressionList SuppressionList
+}
+
+// SendResult is the result of sending a message.
+type SendResult struct {
+	MessageIDs []string
+	// Suppressed is the recipients which were skipped.
+	Suppressed []string
+}
+
+// Recipient is a recipient of a personalized message.
+type Recipient struct {
+	Address string
+	Data    interface{}
+}
+
+// Mailer renders and sends the messages.
+type Mailer struct {
+	sender Sender
+	config Config
+}
+
+// NewMailer creates a new mailer.
+func NewMailer(sender Sender, config Config) *Mailer {
+	if config.MaxRecipients == 0 {
+		config.MaxRecipients = DefaultMaxRecipients
+	}
+	return &Mailer{sender: sender, config: config}
+}
+
+// Send sends the message. The suppressed recipients are skipped and the rest are split into batches.
+func (m *Mailer) Send(ctx context.Context, msg *Message) (*SendResult, error) {
+	prepared := *msg
+	if len(prepared.From) == 0 {
+		prepared.From = m.config.From
+	}
+	if len(prepared.ReplyTo) == 0 {
+		prepared.ReplyTo = m.config.ReplyTo
+	}
+	if err := prepared.validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		result SendResult
+		err    error
+	)
+	for _, recipients := range []*[]string{&prepared.To, &prepared.Cc, &prepared.Bcc} {
+		if *recipients, err = m.filterSuppressed(ctx, *recipients, &result); err != nil {
+			return nil, err
+		}
+	}
+	if len(result.Suppressed) > 0 {
+		log.WithField("suppressed", result.Suppressed).Info("skipped suppressed recipients")
+	}
+
+	for _, batch := range m.batches(&prepared) {
+		id, err := m.sender.Send(ctx, batch)
+		if err != nil {
+			return &result, fmt.Errorf("failed to send message to %d recipients: %w", len(batch.Recipients()), err)
+		}
+		result.MessageIDs = append(result.MessageIDs, id)
+	}
+	return &result, nil
+}
+
+// SendTemplate renders the template with the data and sends it to the recipients.
+func (m *Mailer) SendTemplate(ctx context.Context, t *Template, data interface{}, to ...string) (*SendResult, error) {
+	msg, err := t.Render(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render message: %w", err)
+	}
+	msg.To = to
+	return m.Send(ctx, msg)
+}
+
+// SendPersonalized renders the template with the data of each recipient and sends a separate
+// message to each of them. It sends to all recipients and returns the joined errors of the
+// failed ones.
+func (m *Mailer) SendPersonalized(ctx context.Context, t *Template, recipients []*Recipient) (*SendResult, error) {
+	var (
+		result SendResult
+		errs   []error
+	)
+	for _, recipient := range recipients {
+		recipientResult, err := m.SendTemplate(ctx, t, recipient.Data, recipient.Address)
+		if recipientResult != nil {
+			result.MessageIDs = append(result.MessageIDs, recipientResult.MessageIDs...)
+			result.Suppressed = append(result.Suppressed, recipientResult.Suppressed...)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to send to %s: %w", recipient.Address, err))
+		}
+	}
+	return &result, errors.Join(errs...)
+}
+
+func (m *Mailer) filterSuppressed(ctx context.Context, recipients []string, result *SendResult) ([]string, error) {
+	if m.config.SuppressionList == nil {
+		return recipients, nil
+	}
+	var allowed []string
+	for _, recipient := range recipients {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return nil, err
+		}
+		suppressed, err := m.config.SuppressionList.IsSuppressed(ctx, address.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check suppression of %s: %w", address.Address, err)
+		}
+		if suppressed {
+			result.Suppressed = append(result.Suppressed, recipient)
+			continue
+		}
+		allowed = append(allowed, recipient)
+	}
+	return allowed, nil
+}
+
+// batches splits the recipients of the message into messages which have at most the max recipients.
+func (m *Mailer) batches(msg *Message) []*Message {
+	var (
+		batches []*Message
+		batch   *Message
+		count   int
+	)
+	for _, kind := range []struct {
+		recipients []string
+		field      func(batch *Message) *[]string
+	}{
+		{msg.To, func(batch *Message) *[]string { return &batch.To }},
+		{msg.Cc, func(batch *Message) *[]string { return &batch.Cc }},
+		{msg.Bcc, func(batch *Message) *[]string { return &batch.Bcc }},
+	} {
+		for _, recipient := range kind.recipients {
+			if batch == nil || count == m.config.MaxRecipients {
+				batch = &Message{
+					From:        msg.From,
+					ReplyTo:     msg.ReplyTo,
+					Subject:     msg.Subject,
+					Text:        msg.Text,
+					HTML:        msg.HTML,
+					Attachments: msg.Attachments,
+				}
+				batches = append(batches, batch)
+				count = 0
+			}
+			field := kind.field(batch)
+			*field = append(*field, recipient)
+			count++
+		}
+	}
+	return batches
+}
@@ -0,0 +1,151 @@
+package mailer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/forta-network/core-go/mailer"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testTemplate = mailer.MustTemplate("alert",
+	`Alert: {{.Name}}`,
+	"Hello {{.User}},\n{{.Name}} was detected.",
+	`<p>Hello {{.User}},</p><p>{{.Name}} was detected.</p>`,
+)
+
+type testAlert struct {
+	User string
+	Name string
+}
+
+func TestTemplate(t *testing.T) {
+	r := require.New(t)
+
+	msg, err := testTemplate.Render(&testAlert{User: "<b>user</b>", Name: "exploit"})
+	r.NoError(err)
+	r.Equal("Alert: exploit", msg.Subject)
+	r.Equal("Hello <b>user</b>,\nexploit was detected.", msg.Text)
+	// the HTML is escaped
+	r.Equal(`<p>Hello &lt;b&gt;user&lt;/b&gt;,</p><p>exploit was detected.</p>`, msg.HTML)
+
+	_, err = testTemplate.Render(map[string]string{"User": "user"})
+	r.Error(err)
+
+	_, err = mailer.NewTemplate("empty", "subject", "", "")
+	r.Error(err)
+	_, err = mailer.NewTemplate("invalid", "{{", "text", "")
+	r.Error(err)
+	// the subject is a single line
+	msg, err = mailer.MustTemplate("lines", "a\n{{.}}\r\nb", "text", "").Render("x")
+	r.NoError(err)
+	r.Equal("a x b", msg.Subject)
+}
+
+func TestMailer_Send(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	sender := mailer.NewCaptureSender()
+	m := mailer.NewMailer(sender, mailer.Config{
+		From:            "Alerts <alerts@forta.org>",
+		ReplyTo:         []string{"support@forta.org"},
+		MaxRecipients:   3,
+		SuppressionList: mailer.NewStaticSuppressionList("Bounced@example.com"),
+	})
+
+	result, err := m.Send(ctx, &mailer.Message{
+		To:      []string{"a@example.com", "User B <b@example.com>", "bounced@example.com"},
+		Cc:      []string{"c@example.com"},
+		Bcc:     []string{"d@example.com", "e@example.com"},
+		Subject: "subject",
+		Text:    "text",
+	})
+	r.NoError(err)
+	r.Equal([]string{"bounced@example.com"}, result.Suppressed)
+	r.Equal([]string{"captured-1", "captured-2"}, result.MessageIDs)
+
+	messages := sender.Messages()
+	r.Len(messages, 2)
+	r.Equal([]string{"a@example.com", "User B <b@example.com>"}, messages[0].To)
+	r.Equal([]string{"c@example.com"}, messages[0].Cc)
+	r.Empty(messages[0].Bcc)
+	r.Empty(messages[1].To)
+	r.Equal([]string{"d@example.com", "e@example.com"}, messages[1].Bcc)
+	for _, msg := range messages {
+		r.Equal("Alerts <alerts@forta.org>", msg.From)
+		r.Equal([]string{"support@forta.org"}, msg.ReplyTo)
+		r.Equal("subject", msg.Subject)
+		r.Equal("text", msg.Text)
+	}
+
+	// all recipients are suppressed
+	sender.Reset()
+	result, err = m.Send(ctx, &mailer.Message{To: []string{"bounced@example.com"}, Text: "text"})
+	r.NoError(err)
+	r.Empty(result.MessageIDs)
+	r.Empty(sender.Messages())
+
+	_, err = m.Send(ctx, &mailer.Message{To: []string{"invalid"}, Text: "text"})
+	r.Error(err)
+	_, err = mailer.NewMailer(sender, mailer.Config{}).Send(ctx, &mailer.Message{To: []string{"a@example.com"}})
+	r.Error(err)
+}
+
+type testSuppressionList struct{}
+
+func (testSuppressionList) IsSuppressed(ctx context.Context, address string) (bool, error) {
+	return false, errors.New("unavailable")
+}
+
+func TestMailer_SuppressionListError(t *testing.T) {
+	r := require.New(t)
+	sender := mailer.NewCaptureSender()
+	m := mailer.NewMailer(sender, mailer.Config{From: "alerts@forta.org", SuppressionList: testSuppressionList{}})
+
+	_, err := m.Send(context.Background(), &mailer.Message{To: []string{"a@example.com"}, Text: "text"})
+	r.Error(err)
+	r.Empty(sender.Messages())
+}
+
+// failingSender fails for the given recipient.
+type failingSender struct {
+	*mailer.CaptureSender
+	recipient string
+}
+
+func (s *failingSender) Send(ctx context.Context, msg *mailer.Message) (string, error) {
+	if msg.To[0] == s.recipient {
+		return "", fmt.Errorf("rejected")
+	}
+	return s.CaptureSender.Send(ctx, msg)
+}
+
+func TestMailer_SendPersonalized(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	sender := &failingSender{CaptureSender: mailer.NewCaptureSender(), recipient: "b@example.com"}
+	m := mailer.NewMailer(sender, mailer.Config{
+		From:            "alerts@forta.org",
+		SuppressionList: mailer.NewStaticSuppressionList("c@example.com"),
+	})
+
+	result, err := m.SendPersonalized(ctx, testTemplate, []*mailer.Recipient{
+		{Address: "a@example.com", Data: &testAlert{User: "a", Name: "exploit"}},
+		{Address: "b@example.com", Data: &testAlert{User: "b", Name: "exploit"}},
+		{Address: "c@example.com", Data: &testAlert{User: "c", Name: "exploit"}},
+		{Address: "d@example.com", Data: &testAlert{User: "d", Name: "phishing"}},
+	})
+	r.ErrorContains(err, "b@example.com")
+	r.Equal([]string{"c@example.com"}, result.Suppressed)
+	r.Len(result.MessageIDs, 2)
+
+	messages := sender.Messages()
+	r.Len(messages, 2)
+	r.Equal([]string{"a@example.com"}, messages[0].To)
+	r.Contains(messages[0].Text, "Hello a,")
+	r.Equal([]string{"d@example.com"}, messages[1].To)
+	r.Equal("Alert: phishing", messages[1].Subject)
+}
@@ -0,0 +1,239 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is an email message. The text and the HTML bodies are sent as alternatives if both
+// are set.
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo []string
+	Subject string
+	Text    string
+	HTML    string
+
+	Attachments []*Attachment
+}
+
+// Attachment is a file which is attached to a message.
+type Attachment struct {
+	Filename string
+	// ContentType is detected from the file name if it is empty.
+	ContentType string
+	Data        []byte
+}
+
+// Recipients returns all recipients of the message.
+func (msg *Message) Recipients() []string {
+	var recipients []string
+	recipients = append(recipients, msg.To...)
+	recipients = append(recipients, msg.Cc...)
+	return append(recipients, msg.Bcc...)
+}
+
+// validate checks that the message has a sender and that all addresses are valid.
+func (msg *Message) validate() error {
+	if len(msg.From) == 0 {
+		return errors.New("sender is required")
+	}
+	addresses := append([]string{msg.From}, msg.ReplyTo...)
+	_, err := envelopeAddresses(append(addresses, msg.Recipients()...)...)
+	return err
+}
+
+// Raw returns the message in the MIME format. The Bcc recipients are not included.
+func (msg *Message) Raw() ([]byte, error) {
+	var buf bytes.Buffer
+	header := make(textproto.MIMEHeader)
+	for _, field := range []struct {
+		key       string
+		addresses []string
+	}{
+		{"From", []string{msg.From}},
+		{"To", msg.To},
+		{"Cc", msg.Cc},
+		{"Reply-To", msg.ReplyTo},
+	} {
+		if len(field.addresses) == 0 {
+			continue
+		}
+		formatted, err := formatAddresses(field.addresses...)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(field.key, strings.Join(formatted, ", "))
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("MIME-Version", "1.0")
+
+	bodyHeader, body, err := msg.body()
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Attachments) == 0 {
+		for key, values := range bodyHeader {
+			header[key] = values
+		}
+		writeHeader(&buf, header)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": w.Boundary()}))
+	writeHeader(&buf, header)
+	part, err := w.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(body); err != nil {
+		return nil, err
+	}
+	for _, attachment := range msg.Attachments {
+		if err := writeAttachment(w, attachment); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body returns the header and the content of the text and the HTML bodies.
+func (msg *Message) body() (textproto.MIMEHeader, []byte, error) {
+	switch {
+	case len(msg.HTML) == 0:
+		return textPart("text/plain", msg.Text)
+	case len(msg.Text) == 0:
+		return textPart("text/html", msg.HTML)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, contentType := range []string{"text/plain", "text/html"} {
+		content := msg.Text
+		if contentType == "text/html" {
+			content = msg.HTML
+		}
+		partHeader, partContent, err := textPart(contentType, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		part, err := w.CreatePart(partHeader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := part.Write(partContent); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, nil, err
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": w.Boundary()}))
+	return header, buf.Bytes(), nil
+}
+
+func textPart(contentType, content string) (textproto.MIMEHeader, []byte, error) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	var buf bytes.Buffer
+	qw := quotedprintable.NewWriter(&buf)
+	if _, err := qw.Write([]byte(content)); err != nil {
+		return nil, nil, err
+	}
+	if err := qw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return header, buf.Bytes(), nil
+}
+
+func writeAttachment(w *multipart.Writer, attachment *Attachment) error {
+	contentType := attachment.ContentType
+	if len(contentType) == 0 {
+		contentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	}
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	// the lines cannot be longer than 76 characters
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+// writeHeader writes the header in a stable order and the empty line after it.
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range []string{
+		"From", "To", "Cc", "Reply-To", "Subject", "Date", "MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+	} {
+		if value := header.Get(key); len(value) > 0 {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// formatAddresses returns the addresses in the header format. The display names are encoded
+// if they are not ASCII.
+func formatAddresses(addresses ...string) ([]string, error) {
+	var formatted []string
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		if len(parsed.Name) == 0 {
+			formatted = append(formatted, parsed.Address)
+			continue
+		}
+		formatted = append(formatted, parsed.String())
+	}
+	return formatted, nil
+}
+
+// envelopeAddresses returns the addresses without the display names.
+func envelopeAddresses(addresses ...string) ([]string, error) {
+	envelope := make([]string, len(addresses))
+	for i, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		envelope[i] = parsed.Address
+	}
+	return envelope, nil
+}
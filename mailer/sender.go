@@ -0,0 +1,278 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+const charsetUTF8 = "UTF-8"
+
+// Sender sends the messages and returns the message IDs.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) (string, error)
+}
+
+type sesSender struct {
+	client aws.SESClient
+}
+
+// NewSESSender creates a sender which sends the messages with SES. The messages with attachments
+// are sent as raw emails.
+func NewSESSender(client aws.SESClient) Sender {
+	return &sesSender{client: client}
+}
+
+func (s *sesSender) Send(ctx context.Context, msg *Message) (string, error) {
+	if err := msg.validate(); err != nil {
+		return "", err
+	}
+	if len(msg.Attachments) > 0 {
+		return s.sendRaw(ctx, msg)
+	}
+	// the addresses are validated above
+	from, _ := formatAddresses(msg.From)
+	to, _ := formatAddresses(msg.To...)
+	cc, _ := formatAddresses(msg.Cc...)
+	bcc, _ := formatAddresses(msg.Bcc...)
+	replyTo, _ := formatAddresses(msg.ReplyTo...)
+	input := &ses.SendEmailInput{
+		Source: &from[0],
+		Destination: &types.Destination{
+			ToAddresses:  to,
+			CcAddresses:  cc,
+			BccAddresses: bcc,
+		},
+		ReplyToAddresses: replyTo,
+		Message: &types.Message{
+			Subject: utf8Content(msg.Subject),
+			Body:    &types.Body{},
+		},
+	}
+	if len(msg.Text) > 0 {
+		input.Message.Body.Text = utf8Content(msg.Text)
+	}
+	if len(msg.HTML) > 0 {
+		input.Message.Body.Html = utf8Content(msg.HTML)
+	}
+	res, err := s.client.SendEmail(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return strValue(res.MessageId), nil
+}
+
+func (s *sesSender) sendRaw(ctx context.Context, msg *Message) (string, error) {
+	raw, err := msg.Raw()
+	if err != nil {
+		return "", err
+	}
+	from, err := formatAddresses(msg.From)
+	if err != nil {
+		return "", err
+	}
+	destinations, err := envelopeAddresses(msg.Recipients()...)
+	if err != nil {
+		return "", err
+	}
+	res, err := s.client.SendRawEmail(ctx, &ses.SendRawEmailInput{
+		Source:       &from[0],
+		Destinations: destinations,
+		RawMessage:   &types.RawMessage{Data: raw},
+	})
+	if err != nil {
+		return "", err
+	}
+	return strValue(res.MessageId), nil
+}
+
+func utf8Content(data string) *types.Content {
+	charset := charsetUTF8
+	return &types.Content{Data: &data, Charset: &charset}
+}
+
+type fileSender struct {
+	dir string
+}
+
+// NewFileSender creates a sender which writes the messages to .eml files in the directory for
+// local development.
+func NewFileSender(dir string) Sender {
+	return &fileSender{dir: dir}
+}
+
+func (s *fileSender) Send(ctx context.Context, msg *Message) (string, error) {
+	if err := msg.validate(); err != nil {
+		return "", err
+	}
+	raw, err := msg.Raw()
+	if err != nil {
+		return "", err
+	}
+	id, err := newMessageID()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, id+".eml"), raw, 0644); err != nil {
+		return "", fmt.Errorf("failed to write message %s: %w", id, err)
+	}
+	return id, nil
+}
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPSender creates a sender which sends the messages to an SMTP server, e.g. a local
+// capture server. The auth can be nil. STARTTLS is used if the server supports it.
+func NewSMTPSender(addr string, auth smtp.Auth) Sender {
+	return &smtpSender{addr: addr, auth: auth}
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg *Message) (string, error) {
+	if err := msg.validate(); err != nil {
+		return "", err
+	}
+	raw, err := msg.Raw()
+	if err != nil {
+		return "", err
+	}
+	envelope, err := envelopeAddresses(append([]string{msg.From}, msg.Recipients()...)...)
+	if err != nil {
+		return "", err
+	}
+	id, err := newMessageID()
+	if err != nil {
+		return "", err
+	}
+	if err := s.sendMail(ctx, envelope[0], envelope[1:], raw); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// sendMail works like smtp.SendMail and closes the connection when the context is done.
+func (s *smtpSender) sendMail(ctx context.Context, from string, to []string, raw []byte) error {
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err := c.Rcpt(address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// CaptureSender keeps the messages in memory for tests.
+type CaptureSender struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewCaptureSender creates a new capture sender.
+func NewCaptureSender() *CaptureSender {
+	return &CaptureSender{}
+}
+
+// Send captures the message.
+func (s *CaptureSender) Send(ctx context.Context, msg *Message) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return fmt.Sprintf("captured-%d", len(s.messages)), nil
+}
+
+// Messages returns the captured messages.
+func (s *CaptureSender) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message{}, s.messages...)
+}
+
+// Reset removes the captured messages.
+func (s *CaptureSender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// newMessageID returns a time ordered ID for the local messages.
+func newMessageID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(b)), nil
+}
+
+func strValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
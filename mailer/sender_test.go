@@ -0,0 +1,266 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/mailer"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// readParts reads the parts of a multipart entity by content type.
+func readParts(t *testing.T, header textproto.MIMEHeader, body io.Reader, parts map[string]string) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	require.NoError(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		parts[mediaType] = string(data)
+		return
+	}
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+		// the quoted-printable parts are decoded by the reader
+		readParts(t, part.Header, part, parts)
+	}
+}
+
+func TestMessage_Raw(t *testing.T) {
+	r := require.New(t)
+	msg := &mailer.Message{
+		From:    "Alerts <alerts@forta.org>",
+		To:      []string{"a@example.com", "Ünïcode B <b@example.com>"},
+		Cc:      []string{"c@example.com"},
+		Bcc:     []string{"hidden@example.com"},
+		ReplyTo: []string{"support@forta.org"},
+		Subject: "Alert: ünïcode",
+		Text:    "text body with a long line " + strings.Repeat("x", 100),
+		HTML:    "<p>html body</p>",
+		Attachments: []*mailer.Attachment{
+			{Filename: "report.json", Data: []byte(`{"alerts":1}`)},
+			{Filename: "data.bin", ContentType: "application/x-test", Data: make([]byte, 100)},
+		},
+	}
+	raw, err := msg.Raw()
+	r.NoError(err)
+	r.NotContains(string(raw), "hidden@example.com")
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	r.NoError(err)
+	r.Equal(`"Alerts" <alerts@forta.org>`, parsed.Header.Get("From"))
+	// the display names are encoded
+	r.Equal("a@example.com, =?utf-8?q?=C3=9Cn=C3=AFcode_B?= <b@example.com>", parsed.Header.Get("To"))
+	to, err := parsed.Header.AddressList("To")
+	r.NoError(err)
+	r.Equal([]*mail.Address{{Address: "a@example.com"}, {Name: "Ünïcode B", Address: "b@example.com"}}, to)
+	r.Equal("c@example.com", parsed.Header.Get("Cc"))
+	r.Equal("support@forta.org", parsed.Header.Get("Reply-To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	r.NoError(err)
+	r.Equal("Alert: ünïcode", subject)
+
+	parts := make(map[string]string)
+	readParts(t, textproto.MIMEHeader(parsed.Header), parsed.Body, parts)
+	r.Equal(msg.Text, parts["text/plain"])
+	r.Equal(msg.HTML, parts["text/html"])
+	r.Contains(parts, "application/json")
+	r.Contains(parts, "application/x-test")
+
+	// a single body
+	raw, err = (&mailer.Message{From: "alerts@forta.org", To: []string{"a@example.com"}, HTML: "<p>html</p>"}).Raw()
+	r.NoError(err)
+	parsed, err = mail.ReadMessage(strings.NewReader(string(raw)))
+	r.NoError(err)
+	parts = make(map[string]string)
+	readParts(t, textproto.MIMEHeader(parsed.Header), parsed.Body, parts)
+	r.Equal(map[string]string{"text/html": "<p>html</p>"}, parts)
+
+	_, err = (&mailer.Message{From: "alerts@forta.org", To: []string{"a@example.com\r\nBcc: x@example.com"}, Text: "text"}).Raw()
+	r.Error(err)
+}
+
+func TestSESSender(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSESClient(ctrl)
+	sender := mailer.NewSESSender(client)
+
+	msg := &mailer.Message{
+		From:    "alerts@forta.org",
+		To:      []string{"a@example.com"},
+		Bcc:     []string{"b@example.com"},
+		ReplyTo: []string{"support@forta.org"},
+		Subject: "subject",
+		Text:    "text",
+		HTML:    "<p>html</p>",
+	}
+	client.EXPECT().SendEmail(gomock.Any(), &ses.SendEmailInput{
+		Source: aws.String("alerts@forta.org"),
+		Destination: &types.Destination{
+			ToAddresses:  []string{"a@example.com"},
+			BccAddresses: []string{"b@example.com"},
+		},
+		ReplyToAddresses: []string{"support@forta.org"},
+		Message: &types.Message{
+			Subject: &types.Content{Data: aws.String("subject"), Charset: aws.String("UTF-8")},
+			Body: &types.Body{
+				Text: &types.Content{Data: aws.String("text"), Charset: aws.String("UTF-8")},
+				Html: &types.Content{Data: aws.String("<p>html</p>"), Charset: aws.String("UTF-8")},
+			},
+		},
+	}).Return(&ses.SendEmailOutput{MessageId: aws.String("id-1")}, nil)
+	id, err := sender.Send(ctx, msg)
+	r.NoError(err)
+	r.Equal("id-1", id)
+
+	// the messages with attachments are sent as raw emails
+	msg.To = []string{"User A <a@example.com>"}
+	msg.Attachments = []*mailer.Attachment{{Filename: "report.txt", Data: []byte("report")}}
+	client.EXPECT().SendRawEmail(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *ses.SendRawEmailInput, _ ...func(*ses.Options)) (*ses.SendRawEmailOutput, error) {
+			r.Equal("alerts@forta.org", *input.Source)
+			r.Equal([]string{"a@example.com", "b@example.com"}, input.Destinations)
+			r.Contains(string(input.RawMessage.Data), `filename=report.txt`)
+			return &ses.SendRawEmailOutput{MessageId: aws.String("id-2")}, nil
+		})
+	id, err = sender.Send(ctx, msg)
+	r.NoError(err)
+	r.Equal("id-2", id)
+
+	// the addresses are validated
+	msg.Bcc = []string{"invalid"}
+	_, err = sender.Send(ctx, msg)
+	r.Error(err)
+}
+
+func TestFileSender(t *testing.T) {
+	r := require.New(t)
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender := mailer.NewFileSender(dir)
+
+	id, err := sender.Send(context.Background(), &mailer.Message{
+		From:    "alerts@forta.org",
+		To:      []string{"a@example.com"},
+		Subject: "subject",
+		Text:    "text",
+	})
+	r.NoError(err)
+	data, err := os.ReadFile(filepath.Join(dir, id+".eml"))
+	r.NoError(err)
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	r.NoError(err)
+	r.Equal("subject", parsed.Header.Get("Subject"))
+}
+
+// serveSMTP accepts one message and returns its envelope and data.
+func serveSMTP(listener net.Listener) chan []string {
+	result := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var received []string
+		tp.PrintfLine("220 localhost")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+				tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(line, "MAIL FROM:"), strings.HasPrefix(line, "RCPT TO:"):
+				received = append(received, line)
+				tp.PrintfLine("250 OK")
+			case line == "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				received = append(received, string(data))
+				tp.PrintfLine("250 OK")
+			case line == "QUIT":
+				tp.PrintfLine("221 bye")
+				result <- received
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return result
+}
+
+func TestSMTPSender(t *testing.T) {
+	r := require.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer listener.Close()
+	result := serveSMTP(listener)
+
+	sender := mailer.NewSMTPSender(listener.Addr().String(), nil)
+	_, err = sender.Send(context.Background(), &mailer.Message{
+		From:    "Alerts <alerts@forta.org>",
+		To:      []string{"User A <a@example.com>"},
+		Bcc:     []string{"b@example.com"},
+		Subject: "subject",
+		Text:    "text",
+	})
+	r.NoError(err)
+	received := <-result
+	r.Len(received, 4)
+	r.True(strings.HasPrefix(received[0], "MAIL FROM:<alerts@forta.org>"))
+	r.Equal("RCPT TO:<a@example.com>", received[1])
+	r.Equal("RCPT TO:<b@example.com>", received[2])
+	parsed, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(received[3])))
+	r.NoError(err)
+	r.Equal(`"User A" <a@example.com>`, parsed.Header.Get("To"))
+}
+
+func TestSMTPSender_Canceled(t *testing.T) {
+	r := require.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer listener.Close()
+	// the server never responds
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	sender := mailer.NewSMTPSender(listener.Addr().String(), nil)
+	_, err = sender.Send(ctx, &mailer.Message{From: "alerts@forta.org", To: []string{"a@example.com"}, Text: "text"})
+	r.ErrorIs(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template renders the subject and the bodies of the messages. The HTML body is rendered with
+// html/template so the data is escaped.
+type Template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// NewTemplate parses a template. One of the text and the HTML templates is required.
+func NewTemplate(name, subject, text, html string) (*Template, error) {
+	if len(text) == 0 && len(html) == 0 {
+		return nil, errors.New("text or html template is required")
+	}
+	var (
+		t   Template
+		err error
+	)
+	if t.subject, err = texttemplate.New(name + ".subject").Option("missingkey=error").Parse(subject); err != nil {
+		return nil, err
+	}
+	if len(text) > 0 {
+		if t.text, err = texttemplate.New(name + ".text").Option("missingkey=error").Parse(text); err != nil {
+			return nil, err
+		}
+	}
+	if len(html) > 0 {
+		if t.html, err = htmltemplate.New(name + ".html").Option("missingkey=error").Parse(html); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// MustTemplate parses a template and panics if it fails.
+func MustTemplate(name, subject, text, html string) *Template {
+	t, err := NewTemplate(name, subject, text, html)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render renders a message with the data. The senders and the recipients are not set.
+func (t *Template) Render(data interface{}) (*Message, error) {
+	var (
+		msg Message
+		buf bytes.Buffer
+	)
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	// the subject is a single line
+	msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	if t.text != nil {
+		buf.Reset()
+		if err := t.text.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		msg.Text = buf.String()
+	}
+	if t.html != nil {
+		buf.Reset()
+		if err := t.html.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		msg.HTML = buf.String()
+	}
+	return &msg, nil
+}
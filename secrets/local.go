@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is prepended to the environment variables of the local secrets.
+const EnvPrefix = "SECRET_"
+
+type localSource struct {
+	dir string
+}
+
+// NewLocalSource creates a source for local development. A secret is read from the environment
+// variable whose name is EnvPrefix followed by the secret name in upper case, with the other
+// characters than letters and digits replaced by underscores, e.g. SECRET_PROD_DB_PASSWORD
+// for prod/db-password. Otherwise, it is read from the file in the directory with the secret
+// name as its path. The directory is not used if it is empty.
+func NewLocalSource(dir string) Source {
+	return &localSource{dir: dir}
+}
+
+// NewLocalProvider creates a cached provider of the local secrets. The changes in the files and
+// the environment are noticed on refresh.
+func NewLocalProvider(dir string, config Config) Provider {
+	return NewCachedProvider(NewLocalSource(dir), config)
+}
+
+// EnvName returns the environment variable name of a local secret.
+func EnvName(name string) string {
+	return EnvPrefix + strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
+
+func (s *localSource) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	if value, ok := os.LookupEnv(EnvName(name)); ok {
+		return newLocalSecret(name, []byte(value)), nil
+	}
+	if len(s.dir) == 0 {
+		return nil, ErrNotFound
+	}
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+	// the secrets cannot be outside of the directory
+	if !strings.HasPrefix(path, filepath.Clean(s.dir)+string(filepath.Separator)) {
+		return nil, fmt.Errorf("invalid secret name %s", name)
+	}
+	value, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return newLocalSecret(name, value), nil
+}
+
+// newLocalSecret creates a secret whose version is the hash of the value.
+func newLocalSecret(name string, value []byte) *Secret {
+	sum := sha256.Sum256(value)
+	return &Secret{
+		Name:      name,
+		VersionID: hex.EncodeToString(sum[:16]),
+		Value:     value,
+	}
+}
@@ -0,0 +1,45 @@
+package secrets_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/forta-network/core-go/secrets"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalSource(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := secrets.NewLocalSource(dir)
+
+	r.Equal("SECRET_PROD_DB_PASSWORD", secrets.EnvName("prod/db-password"))
+
+	r.NoError(os.MkdirAll(filepath.Join(dir, "prod"), 0700))
+	r.NoError(os.WriteFile(filepath.Join(dir, "prod", "db-password"), []byte("from-file"), 0600))
+	secret, err := source.GetSecret(ctx, "prod/db-password")
+	r.NoError(err)
+	r.Equal("from-file", secret.String())
+	r.NotEmpty(secret.VersionID)
+
+	// the environment overrides the files
+	t.Setenv("SECRET_PROD_DB_PASSWORD", "from-env")
+	envSecret, err := source.GetSecret(ctx, "prod/db-password")
+	r.NoError(err)
+	r.Equal("from-env", envSecret.String())
+	r.NotEqual(secret.VersionID, envSecret.VersionID)
+
+	_, err = source.GetSecret(ctx, "missing")
+	r.ErrorIs(err, secrets.ErrNotFound)
+	_, err = source.GetSecret(ctx, "../outside")
+	r.Error(err)
+	r.NotErrorIs(err, secrets.ErrNotFound)
+
+	// only the environment is used without a directory
+	_, err = secrets.NewLocalSource("").GetSecret(ctx, "missing")
+	r.ErrorIs(err, secrets.ErrNotFound)
+}
@@ -0,0 +1,285 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// Provider defaults
+const (
+	DefaultTTL                     = time.Minute * 10
+	DefaultRefreshInterval         = time.Minute * 5
+	DefaultRotationRefreshInterval = time.Second * 30
+	// fetchTimeout limits the reads which are shared by the concurrent callers.
+	fetchTimeout = time.Minute
+	// maxPendingNotifications is the number of the changes which can wait for the slow subscribers
+	// before the fetches wait too.
+	maxPendingNotifications = 100
+)
+
+// ErrNotFound is returned when a secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// Secret is a version of a secret.
+type Secret struct {
+	Name      string
+	VersionID string
+	Value     []byte
+	// Pending is the version which is being rotated to. It is nil if the secret is not being rotated
+	// or the source does not read the pending versions.
+	Pending   *Secret
+	FetchedAt time.Time
+}
+
+// String returns the value as a string.
+func (secret *Secret) String() string {
+	return string(secret.Value)
+}
+
+// Values returns the current value and the pending value if there is one. During rotation, the
+// clients can try both values until the pending value becomes current.
+func (secret *Secret) Values() [][]byte {
+	values := [][]byte{secret.Value}
+	if secret.Pending != nil {
+		values = append(values, secret.Pending.Value)
+	}
+	return values
+}
+
+// DecodeJSON decodes a JSON secret.
+func DecodeJSON[T any](secret *Secret) (*T, error) {
+	var value T
+	if err := json.Unmarshal(secret.Value, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode secret %s: %w", secret.Name, err)
+	}
+	return &value, nil
+}
+
+// GetJSON gets a JSON secret from the provider and decodes it.
+func GetJSON[T any](ctx context.Context, provider Provider, name string) (*T, error) {
+	secret, err := provider.GetSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeJSON[T](secret)
+}
+
+// Source reads the secrets.
+type Source interface {
+	GetSecret(ctx context.Context, name string) (*Secret, error)
+}
+
+// Provider provides the secrets and notifies the subscribers when they change.
+type Provider interface {
+	Source
+	// Subscribe calls the function with the new version whenever the secret changes. The returned
+	// function cancels the subscription.
+	Subscribe(name string, fn func(secret *Secret)) (unsubscribe func())
+	Close()
+}
+
+// Config configures a provider.
+type Config struct {
+	// TTL is how long a secret is used before it is read again on access.
+	TTL time.Duration
+	// RefreshInterval is how often the secrets which were read are refreshed in the background.
+	RefreshInterval time.Duration
+	// RotationRefreshInterval is the refresh interval of the secrets which are being rotated.
+	RotationRefreshInterval time.Duration
+}
+
+type subscription struct {
+	fn func(secret *Secret)
+}
+
+// change is a new version of a secret which the subscribers are notified of.
+type change struct {
+	name   string
+	secret *Secret
+}
+
+type cachedProvider struct {
+	source Source
+	config Config
+	group  singleflight.Group
+
+	mu            sync.RWMutex
+	secrets       map[string]*Secret
+	subscriptions map[string][]*subscription
+	changes       chan *change
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewCachedProvider creates a provider which caches the secrets of the source and refreshes them
+// in the background. A cached secret is returned if it cannot be refreshed.
+func NewCachedProvider(source Source, config Config) Provider {
+	if config.TTL == 0 {
+		config.TTL = DefaultTTL
+	}
+	if config.RefreshInterval == 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+	if config.RotationRefreshInterval == 0 {
+		config.RotationRefreshInterval = DefaultRotationRefreshInterval
+	}
+	p := &cachedProvider{
+		source:        source,
+		config:        config,
+		secrets:       make(map[string]*Secret),
+		subscriptions: make(map[string][]*subscription),
+		changes:       make(chan *change, maxPendingNotifications),
+		done:          make(chan struct{}),
+	}
+	go p.refreshLoop()
+	go p.notifyLoop()
+	return p
+}
+
+func (p *cachedProvider) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	p.mu.RLock()
+	cached, ok := p.secrets[name]
+	p.mu.RUnlock()
+	if ok && time.Since(cached.FetchedAt) < p.config.TTL {
+		return cached, nil
+	}
+	secret, err := p.fetch(ctx, name)
+	if err != nil && ok && !errors.Is(err, ErrNotFound) {
+		log.WithError(err).WithField("secret", name).Warn("failed to read secret, using the cached version")
+		return cached, nil
+	}
+	return secret, err
+}
+
+// fetch reads the secret from the source, caches it and queues the change for the subscribers.
+// The concurrent reads of a secret are shared and detached from the contexts of the callers so
+// that a canceled caller does not fail the others.
+func (p *cachedProvider) fetch(ctx context.Context, name string) (*Secret, error) {
+	results := p.group.DoChan(name, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		secret, err := p.source.GetSecret(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if secret.FetchedAt.IsZero() {
+			secret.FetchedAt = time.Now()
+		}
+		p.mu.Lock()
+		previous, ok := p.secrets[name]
+		p.secrets[name] = secret
+		p.mu.Unlock()
+
+		if ok && changed(previous, secret) {
+			select {
+			case p.changes <- &change{name: name, secret: secret}:
+			case <-p.done:
+			}
+		}
+		return secret, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-results:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Secret), nil
+	}
+}
+
+// notifyLoop notifies the subscribers of the changes in order, so the slow subscribers do not
+// block the readers.
+func (p *cachedProvider) notifyLoop() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case change := <-p.changes:
+			p.mu.RLock()
+			subscriptions := append([]*subscription{}, p.subscriptions[change.name]...)
+			p.mu.RUnlock()
+			for _, sub := range subscriptions {
+				sub.fn(change.secret)
+			}
+		}
+	}
+}
+
+func changed(previous, secret *Secret) bool {
+	if previous.VersionID != secret.VersionID {
+		return true
+	}
+	return (previous.Pending == nil) != (secret.Pending == nil) ||
+		(previous.Pending != nil && previous.Pending.VersionID != secret.Pending.VersionID)
+}
+
+func (p *cachedProvider) Subscribe(name string, fn func(secret *Secret)) func() {
+	sub := &subscription{fn: fn}
+	p.mu.Lock()
+	p.subscriptions[name] = append(p.subscriptions[name], sub)
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		subs := p.subscriptions[name]
+		for i, s := range subs {
+			if s == sub {
+				p.subscriptions[name] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (p *cachedProvider) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// refreshLoop refreshes the cached secrets. The secrets which are being rotated are refreshed more
+// often so the new versions are used soon after the rotation.
+func (p *cachedProvider) refreshLoop() {
+	interval := p.config.RefreshInterval
+	if p.config.RotationRefreshInterval < interval {
+		interval = p.config.RotationRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.RLock()
+		var names []string
+		for name, secret := range p.secrets {
+			refreshInterval := p.config.RefreshInterval
+			if secret.Pending != nil {
+				refreshInterval = p.config.RotationRefreshInterval
+			}
+			if time.Since(secret.FetchedAt) >= refreshInterval {
+				names = append(names, name)
+			}
+		}
+		p.mu.RUnlock()
+
+		for _, name := range names {
+			if _, err := p.fetch(context.Background(), name); err != nil {
+				log.WithError(err).WithField("secret", name).Warn("failed to refresh secret")
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forta-network/core-go/secrets"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSource serves the secrets from a map and counts the reads.
+type testSource struct {
+	mu      sync.Mutex
+	secrets map[string]*secrets.Secret
+	err     error
+	reads   int
+}
+
+func newTestSource() *testSource {
+	return &testSource{secrets: make(map[string]*secrets.Secret)}
+}
+
+func (s *testSource) set(name, versionID, value string, pending *secrets.Secret) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[name] = &secrets.Secret{Name: name, VersionID: versionID, Value: []byte(value), Pending: pending}
+}
+
+func (s *testSource) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *testSource) readCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
+
+func (s *testSource) GetSecret(ctx context.Context, name string) (*secrets.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	secret, ok := s.secrets[name]
+	if !ok {
+		return nil, secrets.ErrNotFound
+	}
+	copied := *secret
+	return &copied, nil
+}
+
+func TestCachedProvider(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	source := newTestSource()
+	provider := secrets.NewCachedProvider(source, secrets.Config{
+		TTL:             time.Millisecond * 50,
+		RefreshInterval: time.Hour,
+	})
+	defer provider.Close()
+
+	source.set("a", "v1", "value-1", nil)
+	secret, err := provider.GetSecret(ctx, "a")
+	r.NoError(err)
+	r.Equal("value-1", secret.String())
+	r.False(secret.FetchedAt.IsZero())
+
+	// the cached secret is used until it expires
+	source.set("a", "v2", "value-2", nil)
+	secret, err = provider.GetSecret(ctx, "a")
+	r.NoError(err)
+	r.Equal("value-1", secret.String())
+	r.Equal(1, source.readCount())
+
+	time.Sleep(time.Millisecond * 60)
+	secret, err = provider.GetSecret(ctx, "a")
+	r.NoError(err)
+	r.Equal("value-2", secret.String())
+
+	// the expired secret is used if it cannot be read
+	time.Sleep(time.Millisecond * 60)
+	source.setErr(errors.New("unavailable"))
+	secret, err = provider.GetSecret(ctx, "a")
+	r.NoError(err)
+	r.Equal("value-2", secret.String())
+
+	_, err = provider.GetSecret(ctx, "b")
+	r.Error(err)
+	source.setErr(nil)
+	_, err = provider.GetSecret(ctx, "b")
+	r.ErrorIs(err, secrets.ErrNotFound)
+}
+
+func TestCachedProvider_Subscribe(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	source := newTestSource()
+	provider := secrets.NewCachedProvider(source, secrets.Config{
+		RefreshInterval:         time.Millisecond * 10,
+		RotationRefreshInterval: time.Millisecond * 10,
+	})
+	defer provider.Close()
+
+	source.set("a", "v1", "value-1", nil)
+	_, err := provider.GetSecret(ctx, "a")
+	r.NoError(err)
+
+	changes := make(chan *secrets.Secret, 10)
+	unsubscribe := provider.Subscribe("a", func(secret *secrets.Secret) {
+		changes <- secret
+	})
+	otherChanges := make(chan *secrets.Secret, 10)
+	provider.Subscribe("b", func(secret *secrets.Secret) {
+		otherChanges <- secret
+	})
+
+	// a rotation starts and finishes
+	source.set("a", "v1", "value-1", &secrets.Secret{Name: "a", VersionID: "v2", Value: []byte("value-2")})
+	secret := <-changes
+	r.Equal([][]byte{[]byte("value-1"), []byte("value-2")}, secret.Values())
+	source.set("a", "v2", "value-2", nil)
+	secret = <-changes
+	r.Equal("v2", secret.VersionID)
+	r.Nil(secret.Pending)
+
+	// the refreshed secret is returned
+	secret, err = provider.GetSecret(ctx, "a")
+	r.NoError(err)
+	r.Equal("value-2", secret.String())
+
+	unsubscribe()
+	source.set("a", "v3", "value-3", nil)
+	r.Eventually(func() bool {
+		secret, err := provider.GetSecret(ctx, "a")
+		return err == nil && secret.VersionID == "v3"
+	}, time.Second, time.Millisecond*10)
+	r.Empty(changes)
+	r.Empty(otherChanges)
+}
+
+// blockingSource blocks the reads until it is released.
+type blockingSource struct {
+	*testSource
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSource) GetSecret(ctx context.Context, name string) (*secrets.Secret, error) {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+		return s.testSource.GetSecret(ctx, name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestCachedProvider_CanceledCaller(t *testing.T) {
+	r := require.New(t)
+	source := &blockingSource{testSource: newTestSource(), started: make(chan struct{}, 1), release: make(chan struct{})}
+	source.set("a", "v1", "value-1", nil)
+	provider := secrets.NewCachedProvider(source, secrets.Config{RefreshInterval: time.Hour})
+	defer provider.Close()
+
+	// the first caller gives up while the secret is read
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := provider.GetSecret(ctx, "a")
+		canceled <- err
+	}()
+	<-source.started
+	waited := make(chan error)
+	go func() {
+		_, err := provider.GetSecret(context.Background(), "a")
+		waited <- err
+	}()
+	cancel()
+	r.ErrorIs(<-canceled, context.Canceled)
+
+	// the other caller still gets the secret
+	close(source.release)
+	r.NoError(<-waited)
+}
+
+func TestCachedProvider_SlowSubscriber(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	source := newTestSource()
+	provider := secrets.NewCachedProvider(source, secrets.Config{TTL: time.Millisecond, RefreshInterval: time.Hour})
+	defer provider.Close()
+
+	source.set("a", "v1", "value-1", nil)
+	_, err := provider.GetSecret(ctx, "a")
+	r.NoError(err)
+
+	release := make(chan struct{})
+	notified := make(chan string, 10)
+	provider.Subscribe("a", func(secret *secrets.Secret) {
+		<-release
+		notified <- secret.VersionID
+	})
+
+	// the readers are not blocked by the subscriber
+	for _, version := range []string{"v2", "v3"} {
+		source.set("a", version, "value", nil)
+		time.Sleep(time.Millisecond * 2)
+		secret, err := provider.GetSecret(ctx, "a")
+		r.NoError(err)
+		r.Equal(version, secret.VersionID)
+	}
+
+	// the subscriber is notified in order
+	close(release)
+	r.Equal("v2", <-notified)
+	r.Equal("v3", <-notified)
+}
+
+type testCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func TestGetJSON(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	source := newTestSource()
+	provider := secrets.NewCachedProvider(source, secrets.Config{})
+	defer provider.Close()
+
+	source.set("db", "v1", `{"username":"user","password":"pass"}`, nil)
+	source.set("invalid", "v1", `not json`, nil)
+
+	credentials, err := secrets.GetJSON[testCredentials](ctx, provider, "db")
+	r.NoError(err)
+	r.Equal(&testCredentials{Username: "user", Password: "pass"}, credentials)
+
+	_, err = secrets.GetJSON[testCredentials](ctx, provider, "invalid")
+	r.Error(err)
+	_, err = secrets.GetJSON[testCredentials](ctx, provider, "missing")
+	r.ErrorIs(err, secrets.ErrNotFound)
+}
@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/forta-network/core-go/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// Secrets Manager version stages
+const (
+	VersionStageCurrent = "AWSCURRENT"
+	VersionStagePending = "AWSPENDING"
+)
+
+type secretsManagerSource struct {
+	client      aws.SecretsManager
+	readPending bool
+}
+
+// NewSecretsManagerSource creates a source which reads the current versions of the secrets from
+// Secrets Manager. If readPending is true, the pending versions are read as well.
+func NewSecretsManagerSource(client aws.SecretsManager, readPending bool) Source {
+	return &secretsManagerSource{client: client, readPending: readPending}
+}
+
+// NewSecretsManagerProvider creates a cached provider of the Secrets Manager secrets which reads
+// the pending versions during rotation.
+func NewSecretsManagerProvider(client aws.SecretsManager, config Config) Provider {
+	return NewCachedProvider(NewSecretsManagerSource(client, true), config)
+}
+
+func (s *secretsManagerSource) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	secret, err := s.getVersion(ctx, name, VersionStageCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if !s.readPending {
+		return secret, nil
+	}
+	pending, err := s.getVersion(ctx, name, VersionStagePending)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// not being rotated
+	case err != nil:
+		return nil, err
+	// the pending stage can be attached to the current version
+	case pending.VersionID != secret.VersionID:
+		secret.Pending = pending
+	}
+	return secret, nil
+}
+
+func (s *secretsManagerSource) getVersion(ctx context.Context, name, versionStage string) (*Secret, error) {
+	res, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     &name,
+		VersionStage: &versionStage,
+	})
+	var notFoundErr *types.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s version of secret %s: %w", versionStage, name, err)
+	}
+	secret := &Secret{Name: name, Value: res.SecretBinary}
+	if res.SecretString != nil {
+		secret.Value = []byte(*res.SecretString)
+	}
+	if res.VersionId != nil {
+		secret.VersionID = *res.VersionId
+	}
+	return secret, nil
+}
@@ -0,0 +1,91 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/secrets"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectSecretVersion(client *mock_aws.MockSecretsManager, stage string, output *secretsmanager.GetSecretValueOutput, err error) {
+	client.EXPECT().GetSecretValue(gomock.Any(), &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String("secret"),
+		VersionStage: aws.String(stage),
+	}).Return(output, err)
+}
+
+func TestSecretsManagerSource(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSecretsManager(ctrl)
+	source := secrets.NewSecretsManagerSource(client, true)
+
+	// not being rotated
+	expectSecretVersion(client, secrets.VersionStageCurrent, &secretsmanager.GetSecretValueOutput{
+		VersionId:    aws.String("v1"),
+		SecretString: aws.String("value-1"),
+	}, nil)
+	expectSecretVersion(client, secrets.VersionStagePending, nil, &types.ResourceNotFoundException{})
+	secret, err := source.GetSecret(ctx, "secret")
+	r.NoError(err)
+	r.Equal(&secrets.Secret{Name: "secret", VersionID: "v1", Value: []byte("value-1")}, secret)
+
+	// being rotated
+	expectSecretVersion(client, secrets.VersionStageCurrent, &secretsmanager.GetSecretValueOutput{
+		VersionId:    aws.String("v1"),
+		SecretString: aws.String("value-1"),
+	}, nil)
+	expectSecretVersion(client, secrets.VersionStagePending, &secretsmanager.GetSecretValueOutput{
+		VersionId:    aws.String("v2"),
+		SecretBinary: []byte("value-2"),
+	}, nil)
+	secret, err = source.GetSecret(ctx, "secret")
+	r.NoError(err)
+	r.Equal(&secrets.Secret{Name: "secret", VersionID: "v2", Value: []byte("value-2")}, secret.Pending)
+
+	// the pending stage is on the current version
+	for _, stage := range []string{secrets.VersionStageCurrent, secrets.VersionStagePending} {
+		expectSecretVersion(client, stage, &secretsmanager.GetSecretValueOutput{
+			VersionId:    aws.String("v2"),
+			SecretString: aws.String("value-2"),
+		}, nil)
+	}
+	secret, err = source.GetSecret(ctx, "secret")
+	r.NoError(err)
+	r.Nil(secret.Pending)
+
+	expectSecretVersion(client, secrets.VersionStageCurrent, nil, &types.ResourceNotFoundException{})
+	_, err = source.GetSecret(ctx, "secret")
+	r.ErrorIs(err, secrets.ErrNotFound)
+
+	requestErr := errors.New("request failed")
+	expectSecretVersion(client, secrets.VersionStageCurrent, nil, requestErr)
+	_, err = source.GetSecret(ctx, "secret")
+	r.ErrorIs(err, requestErr)
+}
+
+func TestSecretsManagerSource_CurrentOnly(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := mock_aws.NewMockSecretsManager(ctrl)
+	provider := secrets.NewCachedProvider(secrets.NewSecretsManagerSource(client, false), secrets.Config{})
+	defer provider.Close()
+
+	expectSecretVersion(client, secrets.VersionStageCurrent, &secretsmanager.GetSecretValueOutput{
+		VersionId:    aws.String("v1"),
+		SecretString: aws.String(`{"username":"user","password":"pass"}`),
+	}, nil)
+	credentials, err := secrets.GetJSON[testCredentials](ctx, provider, "secret")
+	r.NoError(err)
+	r.Equal("pass", credentials.Password)
+}
@@ -0,0 +1,241 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cenkalti/backoff"
+)
+
+// DynamoDB limits for the number of items per batch request
+const (
+	batchGetLimit   = 100
+	batchWriteLimit = 25
+)
+
+const (
+	batchRetryInitialInterval = time.Millisecond * 50
+	batchRetryMaxInterval     = time.Second * 5
+	batchRetryMaxElapsedTime  = time.Minute
+)
+
+// ErrUnprocessed is returned when DynamoDB keeps leaving some of the batch items unprocessed.
+var ErrUnprocessed = errors.New("unprocessed batch items")
+
+func (s *store[I]) BatchGet(ctx context.Context, keys []Key) ([]*I, error) {
+	resItems, err := s.batchGetItems(ctx, keys, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch get: %w", err)
+	}
+	if err := s.restore(ctx, resItems...); err != nil {
+		return nil, fmt.Errorf("failed to batch get: %w", err)
+	}
+	return unmarshalItems[I](resItems)
+}
+
+// batchGetItems gets the stored attributes of the items with the keys.
+func (s *store[I]) batchGetItems(ctx context.Context, keys []Key, consistentRead bool) ([]map[string]types.AttributeValue, error) {
+	var resItems []map[string]types.AttributeValue
+	// duplicate keys are rejected by DynamoDB
+	for _, chunk := range chunkKeys(uniqueKeys(keys), batchGetLimit) {
+		primaryKeys := make([]map[string]types.AttributeValue, len(chunk))
+		for i, key := range chunk {
+			primaryKeys[i] = makeItemKey[I](key)
+		}
+		requestItems := map[string]types.KeysAndAttributes{
+			s.tableName: {Keys: primaryKeys, ConsistentRead: &consistentRead},
+		}
+		err := retryUnprocessed(ctx, func() (int, error) {
+			res, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return 0, err
+			}
+			resItems = append(resItems, res.Responses[s.tableName]...)
+			requestItems = res.UnprocessedKeys
+			return len(requestItems[s.tableName].Keys), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resItems, nil
+}
+
+// BatchPut puts the items in batches. The items cannot have duplicate keys. The batch writes
+// cannot be conditioned, so the versions of the Versioned items are neither checked nor
+// incremented.
+func (s *store[I]) BatchPut(ctx context.Context, items []*I) error {
+	seen := make(map[Key]bool, len(items))
+	keys := make([]Key, 0, len(items))
+	for _, item := range items {
+		key, err := getItemKey(item)
+		if err != nil {
+			return err
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate item key in batch put: %+v", key)
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	// the batch writes do not return the replaced items
+	var oldItems []map[string]types.AttributeValue
+	if s.offloader != nil {
+		var err error
+		if oldItems, err = s.batchGetItems(ctx, keys, true); err != nil {
+			return fmt.Errorf("failed to get replaced items: %w", err)
+		}
+	}
+
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		marshaled, err := s.marshalItem(ctx, item)
+		if err != nil {
+			s.discardBatchObjects(ctx, requests)
+			return err
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: marshaled}})
+	}
+	if err := s.batchWrite(ctx, requests); err != nil {
+		s.discardBatchObjects(ctx, requests)
+		return fmt.Errorf("failed to batch put: %w", err)
+	}
+	if s.offloader != nil {
+		return s.cleanUpReplaced(ctx, oldItems, requests)
+	}
+	return nil
+}
+
+// discardBatchObjects discards the uploaded objects of the batch items which failed to be written.
+func (s *store[I]) discardBatchObjects(ctx context.Context, requests []types.WriteRequest) {
+	if s.offloader == nil {
+		return
+	}
+	for _, request := range requests {
+		s.discardObjects(ctx, request.PutRequest.Item)
+	}
+}
+
+// cleanUpReplaced deletes the objects of the replaced items which are not used by the written items.
+func (s *store[I]) cleanUpReplaced(ctx context.Context, oldItems []map[string]types.AttributeValue, requests []types.WriteRequest) error {
+	keyNames := []string{s.item.GetPartitionKeyName(), s.item.GetSortKeyName()}
+	written := make(map[string]map[string]types.AttributeValue, len(requests))
+	for _, request := range requests {
+		written[s.offloader.objectPrefix(request.PutRequest.Item, keyNames...)] = request.PutRequest.Item
+	}
+	for _, oldItem := range oldItems {
+		newItem := written[s.offloader.objectPrefix(oldItem, keyNames...)]
+		if err := s.offloader.cleanUp(ctx, oldItem, newItem, keyNames...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchDelete deletes the items with the keys in batches.
+func (s *store[I]) BatchDelete(ctx context.Context, keys []Key) error {
+	// duplicate keys are rejected by DynamoDB
+	keys = uniqueKeys(keys)
+	requests := make([]types.WriteRequest, len(keys))
+	for i, key := range keys {
+		requests[i] = types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: makeItemKey[I](key)}}
+	}
+	if err := s.batchWrite(ctx, requests); err != nil {
+		return fmt.Errorf("failed to batch delete: %w", err)
+	}
+	for _, request := range requests {
+		if err := s.deleteObjects(ctx, request.DeleteRequest.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *store[I]) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	for start := 0; start < len(requests); start += batchWriteLimit {
+		end := start + batchWriteLimit
+		if end > len(requests) {
+			end = len(requests)
+		}
+		requestItems := map[string][]types.WriteRequest{
+			s.tableName: requests[start:end],
+		}
+		err := retryUnprocessed(ctx, func() (int, error) {
+			res, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return 0, err
+			}
+			requestItems = res.UnprocessedItems
+			return len(requestItems[s.tableName]), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryUnprocessed retries the batch operation with backoff until it reports no unprocessed items
+// or the context is canceled.
+func retryUnprocessed(ctx context.Context, operation func() (int, error)) error {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = batchRetryInitialInterval
+	bo.MaxInterval = batchRetryMaxInterval
+	bo.MaxElapsedTime = batchRetryMaxElapsedTime
+	var unprocessed int
+	err := backoff.Retry(func() error {
+		if ctx.Err() != nil {
+			return backoff.Permanent(ctx.Err())
+		}
+		var err error
+		unprocessed, err = operation()
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if unprocessed > 0 {
+			return ErrUnprocessed
+		}
+		return nil
+	}, backoff.WithContext(bo, ctx))
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, ErrUnprocessed) {
+		return fmt.Errorf("%w: %d items left", ErrUnprocessed, unprocessed)
+	}
+	return err
+}
+
+func uniqueKeys(keys []Key) []Key {
+	seen := make(map[Key]bool, len(keys))
+	var unique []Key
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, key)
+	}
+	return unique
+}
+
+func chunkKeys(keys []Key, size int) [][]Key {
+	var chunks [][]Key
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunks = append(chunks, keys[start:end])
+	}
+	return chunks
+}
@@ -0,0 +1,202 @@
+package dynamo_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/cache"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestKeys(n int) []dynamo.Key {
+	keys := make([]dynamo.Key, n)
+	for i := range keys {
+		keys[i] = dynamo.NewKey(fmt.Sprintf("pkey%d", i), testSortKeyVal)
+	}
+	return keys
+}
+
+func makeTestAttributes(key dynamo.Key) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pkey": &types.AttributeValueMemberS{Value: key.PartitionKey},
+		"skey": &types.AttributeValueMemberS{Value: key.SortKey},
+	}
+}
+
+func TestBatchGet(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	keys := makeTestKeys(150)
+	var requestSizes []int
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			requestKeys := input.RequestItems[testTableName].Keys
+			requestSizes = append(requestSizes, len(requestKeys))
+			res := &dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{
+					testTableName: requestKeys[:len(requestKeys)-1],
+				},
+			}
+			// leave the last one unprocessed in the first response
+			if len(requestKeys) > 1 {
+				res.UnprocessedKeys = map[string]types.KeysAndAttributes{
+					testTableName: {Keys: requestKeys[len(requestKeys)-1:]},
+				}
+			} else {
+				res.Responses[testTableName] = requestKeys
+			}
+			return res, nil
+		}).Times(4)
+
+	// duplicates are removed
+	items, err := store.BatchGet(context.Background(), append(keys, keys[0]))
+	r.NoError(err)
+	r.Len(items, 150)
+	r.Equal([]int{100, 1, 50, 1}, requestSizes)
+}
+
+func TestBatchGet_CacheLayer(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName).WithCache(cache.NewTemp[testItem]())
+
+	keys := makeTestKeys(2)
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+			requestKeys := input.RequestItems[testTableName].Keys
+			r.Len(requestKeys, 1)
+			return &dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{
+					testTableName: requestKeys,
+				},
+			}, nil
+		}).Times(2)
+
+	items, err := store.BatchGet(context.Background(), keys[:1])
+	r.NoError(err)
+	r.Len(items, 1)
+
+	// the first one is served from the cache
+	items, err = store.BatchGet(context.Background(), keys)
+	r.NoError(err)
+	r.Len(items, 2)
+
+	// all are served from the cache
+	items, err = store.BatchGet(context.Background(), keys)
+	r.NoError(err)
+	r.Len(items, 2)
+}
+
+func TestBatchPut(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	var items []*testItem
+	for _, key := range makeTestKeys(30) {
+		items = append(items, &testItem{Pkey: key.PartitionKey, Skey: key.SortKey})
+	}
+	var requestSizes []int
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems[testTableName]
+			requestSizes = append(requestSizes, len(requests))
+			r.NotNil(requests[0].PutRequest)
+			// leave two unprocessed in the first response
+			if len(requests) == 25 {
+				return &dynamodb.BatchWriteItemOutput{
+					UnprocessedItems: map[string][]types.WriteRequest{
+						testTableName: requests[:2],
+					},
+				}, nil
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		}).Times(3)
+
+	r.NoError(store.BatchPut(context.Background(), items))
+	r.Equal([]int{25, 2, 5}, requestSizes)
+}
+
+func TestBatchPut_Invalid(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	// duplicate keys are rejected before writing
+	keys := makeTestKeys(2)
+	items := []*testItem{
+		{Pkey: keys[0].PartitionKey, Skey: keys[0].SortKey},
+		{Pkey: keys[1].PartitionKey, Skey: keys[1].SortKey},
+		{Pkey: keys[0].PartitionKey, Skey: keys[0].SortKey},
+	}
+	r.ErrorContains(store.BatchPut(context.Background(), items), "duplicate")
+}
+
+func TestBatchDelete(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	keys := makeTestKeys(1)
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems[testTableName]
+			r.Len(requests, 1)
+			r.Equal(makeTestAttributes(keys[0]), requests[0].DeleteRequest.Key)
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		})
+
+	// the duplicate keys are deleted once
+	r.NoError(store.BatchDelete(context.Background(), append(keys, keys...)))
+}
+
+func TestBatchDelete_Canceled(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	keys := makeTestKeys(1)
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			cancel()
+			return &dynamodb.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+		})
+
+	r.ErrorIs(store.BatchDelete(ctx, keys), context.Canceled)
+}
+
+func TestBatchDelete_CanceledDuringBackoff(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*200, cancel)
+	client.EXPECT().BatchWriteItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+			return &dynamodb.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+		}).AnyTimes()
+
+	// the backoff is interrupted long before the retries time out
+	start := time.Now()
+	r.ErrorIs(store.BatchDelete(ctx, makeTestKeys(1)), context.Canceled)
+	r.Less(time.Since(start), time.Second*5)
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/forta-network/core-go/store/dynamo"
@@ -11,13 +12,29 @@ import (
 
 type inMemory[I dynamo.Item] struct {
 	cache *cache.Cache
+
+	mu      sync.Mutex
+	queries queryIndex
 }
 
 // NewInMemoryWithTTL creates new in memory cache with TTL.
 func NewInMemoryWithTTL[I dynamo.Item](expire time.Duration, checkEvery time.Duration) dynamo.Cache[I] {
-	return &inMemory[I]{
-		cache: cache.New(expire, checkEvery),
+	inMemory := &inMemory[I]{
+		cache:   cache.New(expire, checkEvery),
+		queries: make(queryIndex),
 	}
+	// the expired and the deleted queries are removed from the index unless they are put again
+	inMemory.cache.OnEvicted(func(key string, value interface{}) {
+		if _, ok := value.([]*I); !ok {
+			return
+		}
+		inMemory.mu.Lock()
+		defer inMemory.mu.Unlock()
+		if _, ok := inMemory.cache.Get(key); !ok {
+			inMemory.queries.remove(key)
+		}
+	})
+	return inMemory
 }
 
 func (cache *inMemory[I]) Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, bool) {
@@ -29,7 +46,7 @@ func (cache *inMemory[I]) Get(ctx context.Context, partitionKey string, sortKey
 }
 
 func (cache *inMemory[I]) Put(ctx context.Context, item *I, partitionKey string, sortKey ...string) {
-	_ = cache.cache.Add(makeCacheKey(partitionKey, sortKey...), item, 0)
+	cache.cache.SetDefault(makeCacheKey(partitionKey, sortKey...), item)
 }
 
 func (cache *inMemory[I]) GetQuery(ctx context.Context, queryKey string) ([]*I, bool) {
@@ -41,5 +58,22 @@ func (cache *inMemory[I]) GetQuery(ctx context.Context, queryKey string) ([]*I,
 }
 
 func (cache *inMemory[I]) PutQuery(ctx context.Context, queryKey string, items []*I) {
-	_ = cache.cache.Add(queryKey, items, 0)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.queries.add(queryKey)
+	cache.cache.SetDefault(queryKey, items)
+}
+
+func (cache *inMemory[I]) Invalidate(ctx context.Context, partitionKey string, sortKey ...string) {
+	cache.cache.Delete(makeCacheKey(partitionKey, sortKey...))
+}
+
+func (cache *inMemory[I]) InvalidateQueries(ctx context.Context, queryKeyPrefix string) {
+	cache.mu.Lock()
+	keys := cache.queries.match(queryKeyPrefix)
+	cache.mu.Unlock()
+	// the deleted keys are removed from the index by the eviction callback
+	for _, key := range keys {
+		cache.cache.Delete(key)
+	}
 }
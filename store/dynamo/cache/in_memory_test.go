@@ -36,6 +36,12 @@ func TestInMemory(t *testing.T) {
 	r.NotNil(it)
 	r.True(ok)
 
+	cache.Invalidate(context.Background(), "pkeyval", "skeyval")
+
+	it, ok = cache.Get(context.Background(), "pkeyval", "skeyval")
+	r.Nil(it)
+	r.False(ok)
+
 	its, ok := cache.GetQuery(context.Background(), "querykey")
 	r.Nil(its)
 	r.False(ok)
@@ -46,3 +52,31 @@ func TestInMemory(t *testing.T) {
 	r.NotNil(its)
 	r.True(ok)
 }
+
+func TestInMemory_InvalidateQueries(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	cache := NewInMemoryWithTTL[testItem](time.Minute*60, time.Minute*60)
+
+	cache.PutQuery(ctx, "table@@a", []*testItem{{}})
+	cache.PutQuery(ctx, "table@@b", []*testItem{{}})
+	cache.PutQuery(ctx, "other@@a", []*testItem{{}})
+	cache.Put(ctx, &testItem{}, "table@@item")
+
+	cache.InvalidateQueries(ctx, "table@@b")
+	_, ok := cache.GetQuery(ctx, "table@@b")
+	r.False(ok)
+	_, ok = cache.GetQuery(ctx, "table@@a")
+	r.True(ok)
+
+	cache.InvalidateQueries(ctx, "table@@")
+	_, ok = cache.GetQuery(ctx, "table@@a")
+	r.False(ok)
+	// the other tables and the items are kept
+	_, ok = cache.GetQuery(ctx, "other@@a")
+	r.True(ok)
+	_, ok = cache.Get(ctx, "table@@item")
+	r.True(ok)
+	r.Equal(queryIndex{"other@@": {"other@@a": true}}, cache.(*inMemory[testItem]).queries)
+}
@@ -1,5 +1,11 @@
 package cache
 
+import (
+	"strings"
+
+	"github.com/forta-network/core-go/store/dynamo"
+)
+
 func makeCacheKey(partitionKey string, sortKey ...string) string {
 	cacheKey := partitionKey
 	if sortKey != nil {
@@ -7,3 +13,50 @@ func makeCacheKey(partitionKey string, sortKey ...string) string {
 	}
 	return cacheKey
 }
+
+// queryIndex groups the cached query keys by their table prefix so that the queries of a table
+// can be invalidated without scanning all cached entries.
+type queryIndex map[string]map[string]bool
+
+func queryTablePrefix(queryKey string) string {
+	if i := strings.Index(queryKey, dynamo.QueryCacheKeySeparator); i >= 0 {
+		return queryKey[:i+len(dynamo.QueryCacheKeySeparator)]
+	}
+	return queryKey
+}
+
+func (index queryIndex) add(queryKey string) {
+	tablePrefix := queryTablePrefix(queryKey)
+	keys, ok := index[tablePrefix]
+	if !ok {
+		keys = make(map[string]bool)
+		index[tablePrefix] = keys
+	}
+	keys[queryKey] = true
+}
+
+func (index queryIndex) remove(queryKey string) {
+	tablePrefix := queryTablePrefix(queryKey)
+	delete(index[tablePrefix], queryKey)
+	if len(index[tablePrefix]) == 0 {
+		delete(index, tablePrefix)
+	}
+}
+
+// match returns the indexed query keys which have the prefix. Only the groups of the tables which
+// can have the prefix are checked.
+func (index queryIndex) match(prefix string) []string {
+	var matched []string
+	for tablePrefix, keys := range index {
+		matchesAll := strings.HasPrefix(tablePrefix, prefix)
+		if !matchesAll && !strings.HasPrefix(prefix, tablePrefix) {
+			continue
+		}
+		for key := range keys {
+			if matchesAll || strings.HasPrefix(key, prefix) {
+				matched = append(matched, key)
+			}
+		}
+	}
+	return matched
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/forta-network/core-go/store/dynamo"
+)
+
+// LRUConfig configures an LRU cache.
+type LRUConfig struct {
+	// Size is the maximum number of cached items and query results.
+	Size int
+	// TTL is the lifetime of the entries. The entries never expire if it is zero.
+	TTL time.Duration
+	// NegativeTTL is the lifetime of the not found entries. Negative caching is
+	// disabled if it is zero.
+	NegativeTTL time.Duration
+}
+
+// LRUStats contains the cache metrics.
+type LRUStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// LRU is a size-bounded and thread-safe cache which evicts the least recently used entries.
+type LRU[I dynamo.Item] struct {
+	config  LRUConfig
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	queries queryIndex
+	stats   LRUStats
+}
+
+// notFound is cached for the items which are known to be missing.
+type notFound struct{}
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+	query     bool
+}
+
+// NewLRU creates a new LRU cache.
+func NewLRU[I dynamo.Item](config LRUConfig) *LRU[I] {
+	if config.Size <= 0 {
+		config.Size = 1
+	}
+	return &LRU[I]{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		queries: make(queryIndex),
+	}
+}
+
+func (cache *LRU[I]) get(key string) (interface{}, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	el, ok := cache.entries[key]
+	if !ok {
+		cache.stats.Misses++
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		cache.remove(el)
+		cache.stats.Misses++
+		return nil, false
+	}
+	cache.order.MoveToFront(el)
+	cache.stats.Hits++
+	return entry.value, true
+}
+
+func (cache *LRU[I]) set(key string, value interface{}, ttl time.Duration, query bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if el, ok := cache.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		if query && !entry.query {
+			entry.query = true
+			cache.queries.add(key)
+		}
+		cache.order.MoveToFront(el)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt, query: query})
+	if query {
+		cache.queries.add(key)
+	}
+	for cache.order.Len() > cache.config.Size {
+		cache.remove(cache.order.Back())
+		cache.stats.Evictions++
+	}
+}
+
+func (cache *LRU[I]) remove(el *list.Element) {
+	entry := el.Value.(*lruEntry)
+	cache.order.Remove(el)
+	delete(cache.entries, entry.key)
+	if entry.query {
+		cache.queries.remove(entry.key)
+	}
+}
+
+// Get returns a nil item and true if the item is known to be missing.
+func (cache *LRU[I]) Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, bool) {
+	v, ok := cache.get(makeCacheKey(partitionKey, sortKey...))
+	if !ok {
+		return nil, false
+	}
+	if _, ok := v.(notFound); ok {
+		return nil, true
+	}
+	it, ok := v.(*I)
+	return it, ok
+}
+
+func (cache *LRU[I]) Put(ctx context.Context, item *I, partitionKey string, sortKey ...string) {
+	cache.set(makeCacheKey(partitionKey, sortKey...), item, cache.config.TTL, false)
+}
+
+// PutNotFound caches the item as missing if the negative caching is enabled.
+func (cache *LRU[I]) PutNotFound(ctx context.Context, partitionKey string, sortKey ...string) {
+	if cache.config.NegativeTTL <= 0 {
+		return
+	}
+	cache.set(makeCacheKey(partitionKey, sortKey...), notFound{}, cache.config.NegativeTTL, false)
+}
+
+func (cache *LRU[I]) GetQuery(ctx context.Context, queryKey string) ([]*I, bool) {
+	v, ok := cache.get(queryKey)
+	if !ok {
+		return nil, false
+	}
+	return v.([]*I), true
+}
+
+func (cache *LRU[I]) PutQuery(ctx context.Context, queryKey string, items []*I) {
+	cache.set(queryKey, items, cache.config.TTL, true)
+}
+
+func (cache *LRU[I]) Invalidate(ctx context.Context, partitionKey string, sortKey ...string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if el, ok := cache.entries[makeCacheKey(partitionKey, sortKey...)]; ok {
+		cache.remove(el)
+	}
+}
+
+func (cache *LRU[I]) InvalidateQueries(ctx context.Context, queryKeyPrefix string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for _, key := range cache.queries.match(queryKeyPrefix) {
+		cache.remove(cache.entries[key])
+	}
+}
+
+// Len returns the number of cached entries.
+func (cache *LRU[I]) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.order.Len()
+}
+
+// Stats returns the cache metrics.
+func (cache *LRU[I]) Stats() LRUStats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.stats
+}
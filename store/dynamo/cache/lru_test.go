@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	cache := NewLRU[testItem](LRUConfig{Size: 2})
+
+	cache.Put(ctx, &testItem{Pkey: "1"}, "1")
+	cache.Put(ctx, &testItem{Pkey: "2"}, "2")
+
+	// makes "1" the most recently used
+	it, ok := cache.Get(ctx, "1")
+	r.True(ok)
+	r.Equal("1", it.Pkey)
+
+	// evicts "2"
+	cache.PutQuery(ctx, "table@@query", []*testItem{{}})
+	_, ok = cache.Get(ctx, "2")
+	r.False(ok)
+	r.Equal(2, cache.Len())
+
+	// overwrites
+	cache.Put(ctx, &testItem{Pkey: "1", Skey: "updated"}, "1")
+	it, ok = cache.Get(ctx, "1")
+	r.True(ok)
+	r.Equal("updated", it.Skey)
+
+	cache.InvalidateQueries(ctx, "table@@")
+	_, ok = cache.GetQuery(ctx, "table@@query")
+	r.False(ok)
+
+	cache.Invalidate(ctx, "1")
+	_, ok = cache.Get(ctx, "1")
+	r.False(ok)
+
+	r.Equal(LRUStats{Hits: 2, Misses: 3, Evictions: 1}, cache.Stats())
+}
+
+func TestLRU_TTL(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	cache := NewLRU[testItem](LRUConfig{Size: 10, TTL: time.Millisecond * 50})
+
+	cache.Put(ctx, &testItem{}, "1")
+	// negative caching is disabled
+	cache.PutNotFound(ctx, "2")
+
+	_, ok := cache.Get(ctx, "1")
+	r.True(ok)
+	_, ok = cache.Get(ctx, "2")
+	r.False(ok)
+
+	time.Sleep(time.Millisecond * 100)
+	_, ok = cache.Get(ctx, "1")
+	r.False(ok)
+	r.Equal(0, cache.Len())
+}
+
+func TestLRU_NegativeCaching(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	cache := NewLRU[testItem](LRUConfig{Size: 10, NegativeTTL: time.Millisecond * 50})
+
+	cache.PutNotFound(ctx, "1")
+	it, ok := cache.Get(ctx, "1")
+	r.True(ok)
+	r.Nil(it)
+
+	time.Sleep(time.Millisecond * 100)
+	_, ok = cache.Get(ctx, "1")
+	r.False(ok)
+
+	// the other values are not negative hits
+	cache.PutQuery(ctx, "2", []*testItem{{}})
+	_, ok = cache.Get(ctx, "2")
+	r.False(ok)
+}
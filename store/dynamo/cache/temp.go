@@ -6,33 +6,51 @@ import (
 	"github.com/forta-network/core-go/store/dynamo"
 )
 
-type tempCache[I dynamo.Item] map[string]interface{}
+type tempCache[I dynamo.Item] struct {
+	entries map[string]interface{}
+	queries queryIndex
+}
 
 // NewTemp creates a new temp cache.
 func NewTemp[I dynamo.Item]() dynamo.Cache[I] {
-	return tempCache[I]{}
+	return &tempCache[I]{
+		entries: make(map[string]interface{}),
+		queries: make(queryIndex),
+	}
 }
 
-func (cache tempCache[I]) Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, bool) {
-	it, ok := cache[makeCacheKey(partitionKey, sortKey...)]
+func (cache *tempCache[I]) Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, bool) {
+	it, ok := cache.entries[makeCacheKey(partitionKey, sortKey...)]
 	if !ok {
 		return nil, false
 	}
 	return it.(*I), true
 }
 
-func (cache tempCache[I]) Put(ctx context.Context, item *I, partitionKey string, sortKey ...string) {
-	cache[makeCacheKey(partitionKey, sortKey...)] = item
+func (cache *tempCache[I]) Put(ctx context.Context, item *I, partitionKey string, sortKey ...string) {
+	cache.entries[makeCacheKey(partitionKey, sortKey...)] = item
 }
 
-func (cache tempCache[I]) GetQuery(ctx context.Context, queryKey string) ([]*I, bool) {
-	its, ok := cache[queryKey]
+func (cache *tempCache[I]) GetQuery(ctx context.Context, queryKey string) ([]*I, bool) {
+	its, ok := cache.entries[queryKey]
 	if !ok {
 		return nil, false
 	}
 	return its.([]*I), ok
 }
 
-func (cache tempCache[I]) PutQuery(ctx context.Context, queryKey string, items []*I) {
-	cache[queryKey] = items
+func (cache *tempCache[I]) PutQuery(ctx context.Context, queryKey string, items []*I) {
+	cache.entries[queryKey] = items
+	cache.queries.add(queryKey)
+}
+
+func (cache *tempCache[I]) Invalidate(ctx context.Context, partitionKey string, sortKey ...string) {
+	delete(cache.entries, makeCacheKey(partitionKey, sortKey...))
+}
+
+func (cache *tempCache[I]) InvalidateQueries(ctx context.Context, queryKeyPrefix string) {
+	for _, key := range cache.queries.match(queryKeyPrefix) {
+		delete(cache.entries, key)
+		cache.queries.remove(key)
+	}
 }
@@ -22,6 +22,12 @@ func TestTemp(t *testing.T) {
 	r.NotNil(it)
 	r.True(ok)
 
+	cache.Invalidate(context.Background(), "pkeyval", "skeyval")
+
+	it, ok = cache.Get(context.Background(), "pkeyval", "skeyval")
+	r.Nil(it)
+	r.False(ok)
+
 	its, ok := cache.GetQuery(context.Background(), "querykey")
 	r.Nil(its)
 	r.False(ok)
@@ -0,0 +1,218 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Condition is a filter condition.
+type Condition struct {
+	build func(ea *expressionAttributes) (string, error)
+}
+
+func compare(path, operator string, value interface{}) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		v, err := ea.value(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", ea.path(path), operator, v), nil
+	}}
+}
+
+// Equal checks if the attribute is equal to the value.
+func Equal(path string, value interface{}) Condition {
+	return compare(path, "=", value)
+}
+
+// NotEqual checks if the attribute is not equal to the value.
+func NotEqual(path string, value interface{}) Condition {
+	return compare(path, "<>", value)
+}
+
+// LessThan checks if the attribute is less than the value.
+func LessThan(path string, value interface{}) Condition {
+	return compare(path, "<", value)
+}
+
+// LessThanOrEqual checks if the attribute is less than or equal to the value.
+func LessThanOrEqual(path string, value interface{}) Condition {
+	return compare(path, "<=", value)
+}
+
+// GreaterThan checks if the attribute is greater than the value.
+func GreaterThan(path string, value interface{}) Condition {
+	return compare(path, ">", value)
+}
+
+// GreaterThanOrEqual checks if the attribute is greater than or equal to the value.
+func GreaterThanOrEqual(path string, value interface{}) Condition {
+	return compare(path, ">=", value)
+}
+
+// Between checks if the attribute is between the lower and the upper values, inclusive.
+func Between(path string, lower, upper interface{}) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		return buildBetween(ea, ea.path(path), lower, upper)
+	}}
+}
+
+// BeginsWith checks if the string attribute begins with the prefix.
+func BeginsWith(path string, prefix string) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		return buildFunction(ea, "begins_with", ea.path(path), prefix)
+	}}
+}
+
+// Contains checks if the string attribute contains the substring or the set or list attribute
+// contains the element.
+func Contains(path string, value interface{}) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		return buildFunction(ea, "contains", ea.path(path), value)
+	}}
+}
+
+// In checks if the attribute is equal to any of the values.
+func In(path string, values ...interface{}) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		if len(values) == 0 {
+			return "", errors.New("no values provided for IN condition")
+		}
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			v, err := ea.value(value)
+			if err != nil {
+				return "", err
+			}
+			placeholders[i] = v
+		}
+		return fmt.Sprintf("%s IN (%s)", ea.path(path), strings.Join(placeholders, ", ")), nil
+	}}
+}
+
+// AttributeExists checks if the attribute exists.
+func AttributeExists(path string) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		return fmt.Sprintf("attribute_exists(%s)", ea.path(path)), nil
+	}}
+}
+
+// AttributeNotExists checks if the attribute does not exist.
+func AttributeNotExists(path string) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		return fmt.Sprintf("attribute_not_exists(%s)", ea.path(path)), nil
+	}}
+}
+
+func join(operator string, conditions []Condition) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		if len(conditions) == 0 {
+			return "", fmt.Errorf("no conditions provided for %s", operator)
+		}
+		exprs := make([]string, len(conditions))
+		for i, condition := range conditions {
+			expr, err := condition.build(ea)
+			if err != nil {
+				return "", err
+			}
+			exprs[i] = "(" + expr + ")"
+		}
+		return strings.Join(exprs, " "+operator+" "), nil
+	}}
+}
+
+// And checks if all of the conditions are true.
+func And(conditions ...Condition) Condition {
+	return join("AND", conditions)
+}
+
+// Or checks if any of the conditions is true.
+func Or(conditions ...Condition) Condition {
+	return join("OR", conditions)
+}
+
+// Not negates the condition.
+func Not(condition Condition) Condition {
+	return Condition{build: func(ea *expressionAttributes) (string, error) {
+		expr, err := condition.build(ea)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("NOT (%s)", expr), nil
+	}}
+}
+
+// KeyCondition is a condition on the sort key of a query.
+type KeyCondition struct {
+	build func(ea *expressionAttributes, name string) (string, error)
+}
+
+func compareKey(operator string, value interface{}) KeyCondition {
+	return KeyCondition{build: func(ea *expressionAttributes, name string) (string, error) {
+		v, err := ea.value(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", name, operator, v), nil
+	}}
+}
+
+// KeyEqual checks if the sort key is equal to the value.
+func KeyEqual(value interface{}) KeyCondition {
+	return compareKey("=", value)
+}
+
+// KeyLessThan checks if the sort key is less than the value.
+func KeyLessThan(value interface{}) KeyCondition {
+	return compareKey("<", value)
+}
+
+// KeyLessThanOrEqual checks if the sort key is less than or equal to the value.
+func KeyLessThanOrEqual(value interface{}) KeyCondition {
+	return compareKey("<=", value)
+}
+
+// KeyGreaterThan checks if the sort key is greater than the value.
+func KeyGreaterThan(value interface{}) KeyCondition {
+	return compareKey(">", value)
+}
+
+// KeyGreaterThanOrEqual checks if the sort key is greater than or equal to the value.
+func KeyGreaterThanOrEqual(value interface{}) KeyCondition {
+	return compareKey(">=", value)
+}
+
+// KeyBetween checks if the sort key is between the lower and the upper values, inclusive.
+func KeyBetween(lower, upper interface{}) KeyCondition {
+	return KeyCondition{build: func(ea *expressionAttributes, name string) (string, error) {
+		return buildBetween(ea, name, lower, upper)
+	}}
+}
+
+// KeyBeginsWith checks if the sort key begins with the prefix.
+func KeyBeginsWith(prefix string) KeyCondition {
+	return KeyCondition{build: func(ea *expressionAttributes, name string) (string, error) {
+		return buildFunction(ea, "begins_with", name, prefix)
+	}}
+}
+
+func buildBetween(ea *expressionAttributes, name string, lower, upper interface{}) (string, error) {
+	l, err := ea.value(lower)
+	if err != nil {
+		return "", err
+	}
+	u, err := ea.value(upper)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s BETWEEN %s AND %s", name, l, u), nil
+}
+
+func buildFunction(ea *expressionAttributes, function, name string, value interface{}) (string, error) {
+	v, err := ea.value(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s, %s)", function, name, v), nil
+}
@@ -0,0 +1,418 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// Encryption defaults
+const (
+	DefaultDataKeyTTL = time.Minute * 5
+	// maxDataKeys limits the number of the decrypted data keys which are kept in memory.
+	maxDataKeys = 1000
+	dataKeySize = 32
+)
+
+// EncryptedTag marks the encrypted fields of the items, e.g. `dynamodbav:"secret" encrypted:"true"`.
+const EncryptedTag = "encrypted"
+
+// Encrypted attributes are replaced by maps with these keys.
+const (
+	ciphertextAttributeKey = "@enc"
+	keyIDAttributeKey      = "@kid"
+	dataKeyAttributeKey    = "@dk"
+)
+
+// Encryption errors
+var (
+	ErrUnknownKey         = errors.New("unknown encryption key")
+	ErrEncryptedAttribute = errors.New("encrypted attribute")
+	ErrDecryptionFailed   = errors.New("decryption failed")
+)
+
+// DataKey is a data encryption key. The plaintext key encrypts the attributes and
+// the encrypted key is stored next to them.
+type DataKey struct {
+	// KeyID identifies the master key which encrypted the data key.
+	KeyID     string
+	Plaintext []byte
+	Encrypted []byte
+}
+
+// KeyProvider generates and decrypts the data keys with the master keys. The key ID which is
+// stored with the attributes allows rotating the master keys: the new data keys are generated
+// with the current master key while the old ones can still be decrypted.
+type KeyProvider interface {
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+	DecryptDataKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error)
+}
+
+// EncryptionConfig configures the encryption of the item fields.
+type EncryptionConfig struct {
+	KeyProvider KeyProvider
+	// DataKeyTTL is how long a data key is used for encrypting before a new one is generated.
+	DataKeyTTL time.Duration
+}
+
+type encryptor struct {
+	config     EncryptionConfig
+	tableName  string
+	keyNames   []string
+	attributes map[string]bool
+
+	mu               sync.Mutex
+	dataKey          *DataKey
+	dataKeyExpiresAt time.Time
+	// dataKeys contains the decrypted data keys by key ID and encrypted key.
+	dataKeys map[string][]byte
+}
+
+// NewEncryptedStore creates a store which encrypts the item fields which are tagged with
+// `encrypted:"true"`. The fields are encrypted with AES-GCM using data keys from the key provider
+// and decrypted when the items are read. The same data key is reused for the data key TTL.
+// The ciphertexts are bound to the table, the item key and the attribute name, so they cannot be
+// copied to other items and the items can only be decrypted if they are read with their keys.
+// The encrypted attributes cannot be used in the keys, the conditions, the filters and the updates,
+// and the items which have encrypted fields cannot be put in the transactions. The large attributes
+// are offloaded after encryption if an offload config is provided.
+func NewEncryptedStore[I Item](
+	client aws.DynamoDBClient, tableName string, config EncryptionConfig, offloadConfig ...OffloadConfig,
+) Store[I] {
+	if config.DataKeyTTL == 0 {
+		config.DataKeyTTL = DefaultDataKeyTTL
+	}
+	s := &store[I]{
+		client:    client,
+		tableName: tableName,
+		encryptor: &encryptor{
+			config:     config,
+			tableName:  tableName,
+			attributes: make(map[string]bool),
+			dataKeys:   make(map[string][]byte),
+		},
+	}
+	s.encryptor.keyNames = []string{s.item.GetPartitionKeyName(), s.item.GetSortKeyName()}
+	for _, name := range encryptedAttributes[I]() {
+		if name == s.item.GetPartitionKeyName() || name == s.item.GetSortKeyName() {
+			panic(fmt.Sprintf("key attribute %s of table %s cannot be encrypted", name, tableName))
+		}
+		s.encryptor.attributes[name] = true
+	}
+	if len(offloadConfig) > 0 {
+		s.offloader = newOffloader(tableName, offloadConfig[0])
+	}
+	return s
+}
+
+// encryptedAttributes returns the names of the attributes of the fields which have the encrypted tag.
+func encryptedAttributes[I Item]() []string {
+	var item I
+	return encryptedFields(reflect.TypeOf(item))
+}
+
+func encryptedFields(t reflect.Type) []string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("dynamodbav"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		// the fields of the embedded structs are flattened
+		if field.Anonymous && len(name) == 0 {
+			names = append(names, encryptedFields(field.Type)...)
+			continue
+		}
+		if field.Tag.Get(EncryptedTag) != "true" {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// checkEncryptedUpdate returns an error if the update modifies an encrypted attribute.
+func checkEncryptedUpdate[I Item](update *Update) error {
+	encrypted := encryptedAttributes[I]()
+	if len(encrypted) == 0 || update == nil {
+		return nil
+	}
+	for _, name := range update.attributes() {
+		for _, encryptedName := range encrypted {
+			if name == encryptedName {
+				return fmt.Errorf("%w %s cannot be updated", ErrEncryptedAttribute, name)
+			}
+		}
+	}
+	return nil
+}
+
+// encrypt replaces the encrypted attributes of the item with their ciphertexts.
+func (e *encryptor) encrypt(ctx context.Context, item map[string]types.AttributeValue) error {
+	var dataKey *DataKey
+	for _, name := range sortedKeys(item) {
+		if !e.attributes[name] {
+			continue
+		}
+		if _, ok := item[name].(*types.AttributeValueMemberNULL); ok {
+			continue
+		}
+		if dataKey == nil {
+			var err error
+			if dataKey, err = e.currentDataKey(ctx); err != nil {
+				return err
+			}
+		}
+		plaintext, err := encodeAttributeValue(item[name])
+		if err != nil {
+			return fmt.Errorf("failed to encode attribute %s: %w", name, err)
+		}
+		ciphertext, err := seal(dataKey.Plaintext, plaintext, e.additionalData(item, name))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt attribute %s: %w", name, err)
+		}
+		item[name] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			ciphertextAttributeKey: &types.AttributeValueMemberB{Value: ciphertext},
+			keyIDAttributeKey:      &types.AttributeValueMemberS{Value: dataKey.KeyID},
+			dataKeyAttributeKey:    &types.AttributeValueMemberB{Value: dataKey.Encrypted},
+		}}
+	}
+	return nil
+}
+
+// decrypt replaces the encrypted attributes of the item with the original values.
+func (e *encryptor) decrypt(ctx context.Context, item map[string]types.AttributeValue) error {
+	for name, av := range item {
+		ciphertext, keyID, encryptedKey, ok := encryptedValue(av)
+		if !ok {
+			continue
+		}
+		key, err := e.decryptDataKey(ctx, keyID, encryptedKey)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt the data key of attribute %s: %w", name, err)
+		}
+		plaintext, err := open(key, ciphertext, e.additionalData(item, name))
+		if err != nil {
+			return fmt.Errorf("%w: attribute %s: %v", ErrDecryptionFailed, name, err)
+		}
+		if item[name], err = decodeAttributeValue(plaintext); err != nil {
+			return fmt.Errorf("failed to decode attribute %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// additionalData returns the authenticated data of the attribute which binds its ciphertext to
+// the table and the item key.
+func (e *encryptor) additionalData(item map[string]types.AttributeValue, name string) []byte {
+	parts := []string{e.tableName, name}
+	for _, keyName := range e.keyNames {
+		var keyValue string
+		if av, ok := item[keyName]; ok && len(keyName) > 0 {
+			keyValue = formatAttributeValue(av)
+		}
+		parts = append(parts, keyValue)
+	}
+	var buf bytes.Buffer
+	for _, part := range parts {
+		// the parts are prefixed with their lengths so that they cannot be shifted
+		fmt.Fprintf(&buf, "%d:%s", len(part), part)
+	}
+	return buf.Bytes()
+}
+
+// encryptedValue returns the parts of the attribute if it is encrypted.
+func encryptedValue(av types.AttributeValue) (ciphertext []byte, keyID string, encryptedKey []byte, ok bool) {
+	m, isMap := av.(*types.AttributeValueMemberM)
+	if !isMap || len(m.Value) != 3 {
+		return
+	}
+	ciphertextValue, ok1 := m.Value[ciphertextAttributeKey].(*types.AttributeValueMemberB)
+	keyIDValue, ok2 := m.Value[keyIDAttributeKey].(*types.AttributeValueMemberS)
+	encryptedKeyValue, ok3 := m.Value[dataKeyAttributeKey].(*types.AttributeValueMemberB)
+	if !ok1 || !ok2 || !ok3 {
+		return
+	}
+	return ciphertextValue.Value, keyIDValue.Value, encryptedKeyValue.Value, true
+}
+
+// currentDataKey returns the data key for encrypting and generates a new one after the TTL.
+func (e *encryptor) currentDataKey(ctx context.Context) (*DataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dataKey != nil && time.Now().Before(e.dataKeyExpiresAt) {
+		return e.dataKey, nil
+	}
+	dataKey, err := e.config.KeyProvider.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	e.dataKey = dataKey
+	e.dataKeyExpiresAt = time.Now().Add(e.config.DataKeyTTL)
+	e.cacheDataKey(dataKey.KeyID, dataKey.Encrypted, dataKey.Plaintext)
+	return dataKey, nil
+}
+
+func (e *encryptor) decryptDataKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	cacheKey := keyID + "/" + string(encryptedKey)
+	e.mu.Lock()
+	key, ok := e.dataKeys[cacheKey]
+	e.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	key, err := e.config.KeyProvider.DecryptDataKey(ctx, keyID, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.cacheDataKey(keyID, encryptedKey, key)
+	e.mu.Unlock()
+	return key, nil
+}
+
+// cacheDataKey caches the decrypted data key. The cache is reset when it is full.
+func (e *encryptor) cacheDataKey(keyID string, encryptedKey, key []byte) {
+	if len(e.dataKeys) >= maxDataKeys {
+		e.dataKeys = make(map[string][]byte)
+	}
+	e.dataKeys[keyID+"/"+string(encryptedKey)] = key
+}
+
+// seal encrypts the plaintext with AES-GCM and prepends the nonce.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the ciphertext which is sealed with seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// localKeyProvider encrypts the data keys with local master keys.
+type localKeyProvider struct {
+	keyID string
+	keys  map[string][]byte
+}
+
+// NewLocalKeyProvider creates a key provider which encrypts the data keys with the given AES keys.
+// The data keys are generated with the key which has the given ID and the other keys are used only
+// for decrypting the existing data keys.
+func NewLocalKeyProvider(keyID string, keys map[string][]byte) (KeyProvider, error) {
+	if _, ok := keys[keyID]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	for id, key := range keys {
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("invalid key %s: %v", id, err)
+		}
+	}
+	return &localKeyProvider{keyID: keyID, keys: keys}, nil
+}
+
+func (p *localKeyProvider) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	encrypted, err := seal(p.keys[p.keyID], key, []byte(p.keyID))
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: p.keyID, Plaintext: key, Encrypted: encrypted}, nil
+}
+
+func (p *localKeyProvider) DecryptDataKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	masterKey, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	key, err := open(masterKey, encryptedKey, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: data key: %v", ErrDecryptionFailed, err)
+	}
+	return key, nil
+}
+
+// kmsKeyProvider generates the data keys with AWS KMS.
+type kmsKeyProvider struct {
+	client aws.KMSClient
+	keyID  string
+}
+
+// NewKMSKeyProvider creates a key provider which generates the data keys with the given KMS key.
+// The key ID can be a key ID, a key ARN or an alias. The data keys which were generated with
+// the previous keys are decrypted with the key ARNs which are stored with them.
+func NewKMSKeyProvider(client aws.KMSClient, keyID string) KeyProvider {
+	return &kmsKeyProvider{client: client, keyID: keyID}
+}
+
+func (p *kmsKeyProvider) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	res, err := p.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   &p.keyID,
+		KeySpec: kmstypes.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: *res.KeyId, Plaintext: res.Plaintext, Encrypted: res.CiphertextBlob}, nil
+}
+
+func (p *kmsKeyProvider) DecryptDataKey(ctx context.Context, keyID string, encryptedKey []byte) ([]byte, error) {
+	res, err := p.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          &keyID,
+		CiphertextBlob: encryptedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Plaintext, nil
+}
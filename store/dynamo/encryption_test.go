@@ -0,0 +1,216 @@
+package dynamo_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testSecretItem struct {
+	Pkey    string            `dynamodbav:"pkey"`
+	Skey    string            `dynamodbav:"skey"`
+	Name    string            `dynamodbav:"name"`
+	Secret  string            `dynamodbav:"secret" encrypted:"true"`
+	APIKeys map[string]string `dynamodbav:"apiKeys,omitempty" encrypted:"true"`
+}
+
+func (testSecretItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (testSecretItem) GetSortKeyName() string {
+	return "skey"
+}
+
+var (
+	testMasterKey1 = bytes.Repeat([]byte{1}, 32)
+	testMasterKey2 = bytes.Repeat([]byte{2}, 32)
+)
+
+func newTestEncryptedStore(t *testing.T, client *memdb.Client, keyID string) dynamo.Store[testSecretItem] {
+	provider, err := dynamo.NewLocalKeyProvider(keyID, map[string][]byte{
+		"key1": testMasterKey1,
+		"key2": testMasterKey2,
+	})
+	require.NoError(t, err)
+	return dynamo.NewEncryptedStore[testSecretItem](client, testTableName, dynamo.EncryptionConfig{KeyProvider: provider})
+}
+
+func TestEncryptedStore(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+	memdb.NewStore[testSecretItem](client, testTableName)
+	store := newTestEncryptedStore(t, client, "key1")
+
+	item := &testSecretItem{
+		Pkey:    testPartitionKeyVal,
+		Skey:    testSortKeyVal,
+		Name:    "webhook",
+		Secret:  "very-secret",
+		APIKeys: map[string]string{"a": "key-a"},
+	}
+	r.NoError(store.Put(ctx, item))
+
+	stored := client.Items(testTableName)[0]
+	r.Equal(&types.AttributeValueMemberS{Value: "webhook"}, stored["name"])
+	for _, name := range []string{"secret", "apiKeys"} {
+		encrypted := stored[name].(*types.AttributeValueMemberM).Value
+		r.Equal(&types.AttributeValueMemberS{Value: "key1"}, encrypted["@kid"])
+		r.NotContains(string(encrypted["@enc"].(*types.AttributeValueMemberB).Value), "key-a")
+		r.NotContains(string(encrypted["@enc"].(*types.AttributeValueMemberB).Value), "very-secret")
+	}
+
+	found, err := store.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(item, found)
+
+	items, err := store.Scan(ctx)
+	r.NoError(err)
+	r.Equal([]*testSecretItem{item}, items)
+
+	r.NoError(store.BatchPut(ctx, []*testSecretItem{{Pkey: "other", Skey: "other", Secret: "other-secret"}}))
+	items, err = store.BatchGet(ctx, []dynamo.Key{dynamo.NewKey("other", "other")})
+	r.NoError(err)
+	r.Equal([]*testSecretItem{{Pkey: "other", Skey: "other", Secret: "other-secret"}}, items)
+
+	// the plaintext attributes can be updated but not the encrypted ones
+	updated, err := store.Update(ctx, dynamo.NewKey(testPartitionKeyVal, testSortKeyVal), dynamo.NewUpdate().Set("name", "renamed"))
+	r.NoError(err)
+	r.Equal("very-secret", updated.Secret)
+	_, err = store.Update(ctx, dynamo.NewKey(testPartitionKeyVal, testSortKeyVal), dynamo.NewUpdate().Set("apiKeys.b", "key-b"))
+	r.ErrorIs(err, dynamo.ErrEncryptedAttribute)
+
+	// the encrypted items cannot be written in transactions
+	err = dynamo.NewTransaction(client).Add(dynamo.TxPut[testSecretItem](store, item)).Commit(ctx)
+	r.ErrorIs(err, dynamo.ErrEncryptedAttribute)
+	err = dynamo.NewTransaction(client).Add(
+		dynamo.TxUpdate[testSecretItem](store, dynamo.NewKey(testPartitionKeyVal, testSortKeyVal), dynamo.NewUpdate().Remove("secret")),
+	).Commit(ctx)
+	r.ErrorIs(err, dynamo.ErrEncryptedAttribute)
+}
+
+func TestEncryptedStore_CopiedCiphertext(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+	memdb.NewStore[testSecretItem](client, testTableName)
+	store := newTestEncryptedStore(t, client, "key1")
+
+	r.NoError(store.Put(ctx, &testSecretItem{Pkey: "victim", Skey: "victim", Secret: "victim-secret"}))
+	r.NoError(store.Put(ctx, &testSecretItem{Pkey: "attacker", Skey: "attacker", Secret: "attacker-secret"}))
+
+	// the ciphertext of another item does not decrypt
+	var victim map[string]types.AttributeValue
+	for _, stored := range client.Items(testTableName) {
+		if stored["pkey"].(*types.AttributeValueMemberS).Value == "victim" {
+			victim = stored
+		}
+	}
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(testTableName),
+		Key: map[string]types.AttributeValue{
+			"pkey": &types.AttributeValueMemberS{Value: "attacker"},
+			"skey": &types.AttributeValueMemberS{Value: "attacker"},
+		},
+		UpdateExpression:          aws.String("SET secret = :secret"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":secret": victim["secret"]},
+	})
+	r.NoError(err)
+	_, err = store.Get(ctx, "attacker", "attacker")
+	r.ErrorIs(err, dynamo.ErrDecryptionFailed)
+}
+
+func TestEncryptedStore_KeyRotation(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+	memdb.NewStore[testSecretItem](client, testTableName)
+
+	oldItem := &testSecretItem{Pkey: "old", Skey: "old", Secret: "old-secret"}
+	r.NoError(newTestEncryptedStore(t, client, "key1").Put(ctx, oldItem))
+
+	// the items which are encrypted with the previous key can be read after rotation
+	store := newTestEncryptedStore(t, client, "key2")
+	newItem := &testSecretItem{Pkey: "new", Skey: "new", Secret: "new-secret"}
+	r.NoError(store.Put(ctx, newItem))
+	found, err := store.Get(ctx, "old", "old")
+	r.NoError(err)
+	r.Equal(oldItem, found)
+	found, err = store.Get(ctx, "new", "new")
+	r.NoError(err)
+	r.Equal(newItem, found)
+
+	// re-encrypt the old items with the current key
+	changed, err := dynamo.Backfill(ctx, store, func(item *testSecretItem) bool {
+		return true
+	})
+	r.NoError(err)
+	r.Equal(2, changed)
+	for _, item := range client.Items(testTableName) {
+		r.Equal(&types.AttributeValueMemberS{Value: "key2"}, item["secret"].(*types.AttributeValueMemberM).Value["@kid"])
+	}
+
+	// the old key cannot be dropped before re-encrypting
+	provider, err := dynamo.NewLocalKeyProvider("key1", map[string][]byte{"key1": testMasterKey1})
+	r.NoError(err)
+	store = dynamo.NewEncryptedStore[testSecretItem](client, testTableName, dynamo.EncryptionConfig{KeyProvider: provider})
+	_, err = store.Get(ctx, "new", "new")
+	r.ErrorIs(err, dynamo.ErrUnknownKey)
+
+	// the ciphertext cannot be moved to another attribute
+	moved := client.Items(testTableName)[0]
+	moved["pkey"] = &types.AttributeValueMemberS{Value: "moved"}
+	moved["skey"] = &types.AttributeValueMemberS{Value: "moved"}
+	moved["apiKeys"] = moved["secret"]
+	delete(moved, "secret")
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(testTableName), Item: moved})
+	r.NoError(err)
+	_, err = newTestEncryptedStore(t, client, "key2").Get(ctx, "moved", "moved")
+	r.ErrorIs(err, dynamo.ErrDecryptionFailed)
+}
+
+func TestLocalKeyProvider(t *testing.T) {
+	r := require.New(t)
+
+	_, err := dynamo.NewLocalKeyProvider("missing", map[string][]byte{"key1": testMasterKey1})
+	r.ErrorIs(err, dynamo.ErrUnknownKey)
+	_, err = dynamo.NewLocalKeyProvider("key1", map[string][]byte{"key1": {1, 2, 3}})
+	r.Error(err)
+}
+
+func TestKMSKeyProvider(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	kmsClient := mock_aws.NewMockKMSClient(ctrl)
+	provider := dynamo.NewKMSKeyProvider(kmsClient, "alias/test")
+
+	keyARN := "arn:aws:kms:us-east-1:123456789012:key/test"
+	plaintext := bytes.Repeat([]byte{3}, 32)
+	kmsClient.EXPECT().GenerateDataKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *kms.GenerateDataKeyInput, _ ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+			r.Equal("alias/test", *input.KeyId)
+			return &kms.GenerateDataKeyOutput{KeyId: aws.String(keyARN), Plaintext: plaintext, CiphertextBlob: []byte("blob")}, nil
+		})
+	dataKey, err := provider.GenerateDataKey(ctx)
+	r.NoError(err)
+	r.Equal(&dynamo.DataKey{KeyID: keyARN, Plaintext: plaintext, Encrypted: []byte("blob")}, dataKey)
+
+	kmsClient.EXPECT().Decrypt(gomock.Any(), &kms.DecryptInput{KeyId: aws.String(keyARN), CiphertextBlob: []byte("blob")}).
+		Return(&kms.DecryptOutput{Plaintext: plaintext}, nil)
+	key, err := provider.DecryptDataKey(ctx, keyARN, []byte("blob"))
+	r.NoError(err)
+	r.Equal(plaintext, key)
+}
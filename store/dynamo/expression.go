@@ -0,0 +1,107 @@
+package dynamo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// expressionAttributes allocates the placeholders for the attribute names and values used in
+// an expression. The placeholders are numbered in the order of use so the same expression
+// is always built the same.
+type expressionAttributes struct {
+	prefix       string
+	placeholders map[string]string
+	names        map[string]string
+	values       map[string]types.AttributeValue
+}
+
+func newExpressionAttributes(prefix string) *expressionAttributes {
+	return &expressionAttributes{
+		prefix:       prefix,
+		placeholders: make(map[string]string),
+		names:        make(map[string]string),
+		values:       make(map[string]types.AttributeValue),
+	}
+}
+
+// name returns the placeholder for an attribute name.
+func (ea *expressionAttributes) name(name string) string {
+	placeholder, ok := ea.placeholders[name]
+	if ok {
+		return placeholder
+	}
+	placeholder = fmt.Sprintf("#%s%d", ea.prefix, len(ea.placeholders))
+	ea.placeholders[name] = placeholder
+	ea.names[placeholder] = name
+	return placeholder
+}
+
+// path returns the placeholder form of a document path like "a.b[0].c".
+func (ea *expressionAttributes) path(path string) string {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		name, index := part, ""
+		if j := strings.IndexByte(part, '['); j >= 0 {
+			name, index = part[:j], part[j:]
+		}
+		parts[i] = ea.name(name) + index
+	}
+	return strings.Join(parts, ".")
+}
+
+// value marshals the value and returns its placeholder.
+func (ea *expressionAttributes) value(value interface{}) (string, error) {
+	av, err := attributevalue.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	placeholder := fmt.Sprintf(":%s%d", ea.prefix, len(ea.values))
+	ea.values[placeholder] = av
+	return placeholder, nil
+}
+
+// namesOrNil returns nil instead of an empty map because DynamoDB rejects empty maps.
+func (ea *expressionAttributes) namesOrNil() map[string]string {
+	if len(ea.names) == 0 {
+		return nil
+	}
+	return ea.names
+}
+
+func (ea *expressionAttributes) valuesOrNil() map[string]types.AttributeValue {
+	if len(ea.values) == 0 {
+		return nil
+	}
+	return ea.values
+}
+
+// mergeExpressionValues merges the value maps of multiple expressions.
+func mergeExpressionValues(valueMaps ...map[string]types.AttributeValue) map[string]types.AttributeValue {
+	var merged map[string]types.AttributeValue
+	for _, values := range valueMaps {
+		for placeholder, value := range values {
+			if merged == nil {
+				merged = make(map[string]types.AttributeValue)
+			}
+			merged[placeholder] = value
+		}
+	}
+	return merged
+}
+
+// mergeExpressionNames merges the name maps of multiple expressions.
+func mergeExpressionNames(nameMaps ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, names := range nameMaps {
+		for placeholder, name := range names {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[placeholder] = name
+		}
+	}
+	return merged
+}
@@ -0,0 +1,57 @@
+package dynamo
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Key is the primary key of an item.
+type Key struct {
+	PartitionKey string
+	// SortKey is empty if the table has no sort key.
+	SortKey string
+}
+
+// NewKey creates a new key.
+func NewKey(partitionKey string, sortKey ...string) Key {
+	key := Key{PartitionKey: partitionKey}
+	if sortKey != nil {
+		key.SortKey = sortKey[0]
+	}
+	return key
+}
+
+// SortKeys returns the sort key in the optional argument form that the store and the cache methods accept.
+func (key Key) SortKeys() []string {
+	if len(key.SortKey) == 0 {
+		return nil
+	}
+	return []string{key.SortKey}
+}
+
+func makeItemKey[I Item](key Key) map[string]types.AttributeValue {
+	var item I
+	return makePrimaryKey(&item, key.PartitionKey, key.SortKeys()...)
+}
+
+// getItemKey extracts the primary key from an item.
+func getItemKey[I Item](item *I) (Key, error) {
+	marshaled, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return Key{}, err
+	}
+	var key Key
+	partitionKey, ok := marshaled[(*item).GetPartitionKeyName()].(*types.AttributeValueMemberS)
+	if !ok {
+		return Key{}, fmt.Errorf("item has no string partition key '%s'", (*item).GetPartitionKeyName())
+	}
+	key.PartitionKey = partitionKey.Value
+	if sortKeyName := (*item).GetSortKeyName(); len(sortKeyName) > 0 {
+		if sortKey, ok := marshaled[sortKeyName].(*types.AttributeValueMemberS); ok {
+			key.SortKey = sortKey.Value
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,75 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LeaderCallbacks are invoked by the leader election.
+type LeaderCallbacks struct {
+	// OnStartedLeading runs while this instance is the leader. The context is canceled when the
+	// leadership is lost or the election is stopped, and the callback should return soon after.
+	OnStartedLeading func(ctx context.Context, lock *Lock)
+	// OnStoppedLeading is invoked after OnStartedLeading returns.
+	OnStoppedLeading func()
+}
+
+// RunLeaderElection campaigns for the leadership of the named election until the context is
+// canceled. The leadership is held by holding the lock with the election name, and it is
+// campaigned for again after the retry interval of the locker when it is lost. It returns nil when
+// the context is canceled.
+func RunLeaderElection(ctx context.Context, locker *Locker, name string, callbacks LeaderCallbacks) error {
+	if callbacks.OnStartedLeading == nil {
+		return errors.New("OnStartedLeading callback is required")
+	}
+	for {
+		lock, err := locker.Acquire(ctx, name)
+		if err != nil {
+			// acquire only fails if the context is canceled
+			return nil
+		}
+		log.WithField("election", name).WithField("token", lock.Token()).Info("started leading")
+		lead(ctx, lock, callbacks)
+		log.WithField("election", name).Info("stopped leading")
+
+		// do not campaign again right away if the leader returned immediately
+		timer := time.NewTimer(locker.config.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+func lead(ctx context.Context, lock *Lock, callbacks LeaderCallbacks) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		callbacks.OnStartedLeading(leaderCtx, lock)
+	}()
+
+	select {
+	case <-finished:
+	case <-lock.Lost():
+	case <-ctx.Done():
+	}
+	cancel()
+	<-finished
+
+	if callbacks.OnStoppedLeading != nil {
+		callbacks.OnStoppedLeading()
+	}
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer releaseCancel()
+	if err := lock.Release(releaseCtx); err != nil && !errors.Is(err, ErrLockLost) {
+		log.WithError(err).WithField("election", lock.Name()).Warn("failed to release leadership")
+	}
+}
@@ -0,0 +1,378 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/store/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Defaults
+const (
+	DefaultLeaseDuration = time.Second * 30
+	DefaultRetryInterval = time.Second
+	releaseTimeout       = time.Second * 5
+)
+
+// Lock errors
+var (
+	ErrLocked   = errors.New("already locked")
+	ErrLockLost = errors.New("lock lost")
+)
+
+// lease is the lock item. The fencing token is incremented on every acquisition
+// and the item is never deleted so that the tokens keep increasing.
+type lease struct {
+	Name      string `dynamodbav:"lockName"`
+	Owner     string `dynamodbav:"lockOwner,omitempty"`
+	Token     int64  `dynamodbav:"fencingToken"`
+	ExpiresAt int64  `dynamodbav:"leaseExpiresAt"`
+}
+
+func (lease) GetPartitionKeyName() string {
+	return "lockName"
+}
+
+func (lease) GetSortKeyName() string {
+	return ""
+}
+
+// TableSchema returns the schema of the lock table.
+func TableSchema(tableName string) *dynamo.TableSchema {
+	return dynamo.NewTableSchema[lease](tableName)
+}
+
+// Config configures a locker.
+type Config struct {
+	TableName string
+	// Owner identifies the lock holder. It is the host name with a random suffix if empty.
+	Owner string
+	// LeaseDuration is how long a lock is held without renewal. The clocks of the lock
+	// holders should be synchronized much more precisely than this.
+	LeaseDuration time.Duration
+	// HeartbeatInterval is the lease renewal interval. It is one third of the lease duration if zero.
+	HeartbeatInterval time.Duration
+	// RetryInterval is how often Acquire tries to acquire a held lock.
+	RetryInterval time.Duration
+	// SafetyMargin is how long before the local lease expiry the lock is considered lost so that
+	// the holder stops before another owner can acquire it. It is one tenth of the lease duration if zero.
+	SafetyMargin time.Duration
+}
+
+// Locker acquires lease-based locks which are stored in a DynamoDB table.
+type Locker struct {
+	store  dynamo.Store[lease]
+	config Config
+}
+
+// NewLocker creates a new locker.
+func NewLocker(client aws.DynamoDBClient, config Config) (*Locker, error) {
+	if len(config.TableName) == 0 {
+		return nil, errors.New("lock table name is required")
+	}
+	if len(config.Owner) == 0 {
+		owner, err := makeOwner()
+		if err != nil {
+			return nil, err
+		}
+		config.Owner = owner
+	}
+	if config.LeaseDuration == 0 {
+		config.LeaseDuration = DefaultLeaseDuration
+	}
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = config.LeaseDuration / 3
+	}
+	if config.SafetyMargin == 0 {
+		config.SafetyMargin = config.LeaseDuration / 10
+	}
+	if config.HeartbeatInterval+config.SafetyMargin >= config.LeaseDuration {
+		return nil, fmt.Errorf("heartbeat interval (%s) and safety margin (%s) must be shorter than the lease duration (%s)",
+			config.HeartbeatInterval, config.SafetyMargin, config.LeaseDuration)
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+	return &Locker{
+		store:  dynamo.NewStore[lease](client, config.TableName),
+		config: config,
+	}, nil
+}
+
+func makeOwner() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the host name: %v", err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s", hostname, hex.EncodeToString(suffix)), nil
+}
+
+// Owner returns the owner name of the locker.
+func (l *Locker) Owner() string {
+	return l.config.Owner
+}
+
+// TryAcquire acquires the lock if it is not held. Otherwise, it returns ErrLocked. The lock is
+// renewed until it is released or the context is canceled. The lock is released when the context
+// is canceled.
+func (l *Locker) TryAcquire(ctx context.Context, name string) (*Lock, error) {
+	expiresAt := time.Now().Add(l.config.LeaseDuration)
+	acquired, err := l.store.Update(ctx, dynamo.NewKey(name),
+		dynamo.NewUpdate().
+			Set("lockOwner", l.config.Owner).
+			Set("leaseExpiresAt", expiresAt.UnixMilli()).
+			Increment("fencingToken", 1),
+		dynamo.ConditionExpression{
+			Expression: "attribute_not_exists(lockName) OR leaseExpiresAt < :now",
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":now": millisValue(time.Now()),
+			},
+		},
+	)
+	if errors.Is(err, dynamo.ErrConditionFailed) {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+
+	lock := &Lock{
+		locker:    l,
+		name:      name,
+		token:     acquired.Token,
+		expiresAt: expiresAt,
+		lost:      make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go lock.heartbeat(ctx)
+	go lock.watchExpiry()
+	return lock, nil
+}
+
+// Acquire waits until the lock is acquired or the context is canceled.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lock, error) {
+	ticker := time.NewTicker(l.config.RetryInterval)
+	defer ticker.Stop()
+	for {
+		lock, err := l.TryAcquire(ctx, name)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, ErrLocked) {
+			log.WithError(err).WithField("lock", name).Warn("failed to acquire lock")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Lock is an acquired lock.
+type Lock struct {
+	locker *Locker
+	name   string
+	token  int64
+
+	mu        sync.Mutex
+	expiresAt time.Time
+	released  bool
+	lostOnce  sync.Once
+	lost      chan struct{}
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// Name returns the lock name.
+func (lock *Lock) Name() string {
+	return lock.name
+}
+
+// Token returns the fencing token. The tokens of a lock increase with every acquisition so the
+// resources can reject the writes which have smaller tokens than the last seen one.
+func (lock *Lock) Token() int64 {
+	return lock.token
+}
+
+// Lost returns a channel which is closed when the lock is lost or released.
+func (lock *Lock) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Held tells if the lock is still held according to the local clock. The lock is not held after
+// the safety margin before the lease expiry.
+func (lock *Lock) Held() bool {
+	select {
+	case <-lock.lost:
+		return false
+	default:
+		return time.Now().Before(lock.deadline())
+	}
+}
+
+// deadline returns the time when the lock is considered lost unless it is renewed.
+func (lock *Lock) deadline() time.Time {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	return lock.expiresAt.Add(-lock.locker.config.SafetyMargin)
+}
+
+func (lock *Lock) markLost() {
+	lock.lostOnce.Do(func() {
+		close(lock.lost)
+	})
+}
+
+// watchExpiry marks the lock lost at the deadline if it is not renewed until then, regardless of
+// an ongoing renewal which may be blocked by the network.
+func (lock *Lock) watchExpiry() {
+	for {
+		wait := time.Until(lock.deadline())
+		if wait <= 0 {
+			select {
+			case <-lock.lost:
+			default:
+				log.WithField("lock", lock.name).Warn("lock lease expired before renewal")
+				lock.markLost()
+			}
+			return
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-lock.lost:
+			timer.Stop()
+			return
+		case <-timer.C:
+			// check again in case the lease was renewed meanwhile
+		}
+	}
+}
+
+func (lock *Lock) heartbeat(ctx context.Context) {
+	defer close(lock.done)
+	ticker := time.NewTicker(lock.locker.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+			defer cancel()
+			if err := lock.release(ctx); err != nil {
+				log.WithError(err).WithField("lock", lock.name).Warn("failed to release lock")
+			}
+			return
+		case <-lock.stop:
+			return
+		case <-lock.lost:
+			return
+		case <-ticker.C:
+		}
+
+		// the renewal is pointless after the deadline
+		renewCtx, cancel := context.WithDeadline(ctx, lock.deadline())
+		err := lock.renew(renewCtx)
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			// released in the next iteration
+		case errors.Is(err, ErrLockLost):
+			log.WithField("lock", lock.name).Warn("lock lost")
+			lock.markLost()
+			return
+		case err != nil && !lock.Held():
+			log.WithError(err).WithField("lock", lock.name).Warn("failed to renew lock before expiry")
+			lock.markLost()
+			return
+		case err != nil:
+			log.WithError(err).WithField("lock", lock.name).Warn("failed to renew lock")
+		}
+	}
+}
+
+func (lock *Lock) holderCondition() dynamo.ConditionExpression {
+	return dynamo.ConditionExpression{
+		Expression: "lockOwner = :owner AND fencingToken = :token",
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: lock.locker.config.Owner},
+			":token": &types.AttributeValueMemberN{Value: strconv.FormatInt(lock.token, 10)},
+		},
+	}
+}
+
+func (lock *Lock) renew(ctx context.Context) error {
+	expiresAt := time.Now().Add(lock.locker.config.LeaseDuration)
+	_, err := lock.locker.store.Update(ctx, dynamo.NewKey(lock.name),
+		dynamo.NewUpdate().Set("leaseExpiresAt", expiresAt.UnixMilli()),
+		lock.holderCondition(),
+	)
+	if errors.Is(err, dynamo.ErrConditionFailed) {
+		return fmt.Errorf("%w: %s", ErrLockLost, lock.name)
+	}
+	if err != nil {
+		return err
+	}
+	lock.mu.Lock()
+	lock.expiresAt = expiresAt
+	lock.mu.Unlock()
+	return nil
+}
+
+// Release stops renewing the lock and releases it. It returns ErrLockLost if the lock
+// was taken over by another owner.
+func (lock *Lock) Release(ctx context.Context) error {
+	lock.stopOnce.Do(func() {
+		close(lock.stop)
+	})
+	<-lock.done
+	return lock.release(ctx)
+}
+
+func (lock *Lock) release(ctx context.Context) error {
+	lock.mu.Lock()
+	released := lock.released
+	lock.released = true
+	lock.mu.Unlock()
+	if released {
+		return nil
+	}
+	defer lock.markLost()
+
+	select {
+	case <-lock.lost:
+		return fmt.Errorf("%w: %s", ErrLockLost, lock.name)
+	default:
+	}
+	_, err := lock.locker.store.Update(ctx, dynamo.NewKey(lock.name),
+		dynamo.NewUpdate().Set("leaseExpiresAt", 0).Remove("lockOwner"),
+		lock.holderCondition(),
+	)
+	if errors.Is(err, dynamo.ErrConditionFailed) {
+		return fmt.Errorf("%w: %s", ErrLockLost, lock.name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lock.name, err)
+	}
+	return nil
+}
+
+func millisValue(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMilli(), 10)}
+}
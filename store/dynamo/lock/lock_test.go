@@ -0,0 +1,298 @@
+package lock_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/lock"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTableName = "test-locks"
+	testLockName  = "test-lock"
+)
+
+func newTestLockers(t *testing.T, owners ...string) (*memdb.Client, []*lock.Locker) {
+	r := require.New(t)
+	client := memdb.NewClient()
+	r.NoError(dynamo.EnsureTable(context.Background(), client, lock.TableSchema(testTableName)))
+
+	var lockers []*lock.Locker
+	for _, owner := range owners {
+		locker, err := lock.NewLocker(client, lock.Config{
+			TableName:     testTableName,
+			Owner:         owner,
+			LeaseDuration: time.Millisecond * 300,
+			RetryInterval: time.Millisecond * 10,
+		})
+		r.NoError(err)
+		lockers = append(lockers, locker)
+	}
+	return client, lockers
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestLock(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, lockers := newTestLockers(t, "a", "b")
+
+	lockA, err := lockers[0].TryAcquire(ctx, testLockName)
+	r.NoError(err)
+	r.Equal(int64(1), lockA.Token())
+	r.True(lockA.Held())
+
+	_, err = lockers[1].TryAcquire(ctx, testLockName)
+	r.ErrorIs(err, lock.ErrLocked)
+
+	// the lock is renewed beyond the lease duration
+	time.Sleep(time.Millisecond * 500)
+	r.True(lockA.Held())
+	_, err = lockers[1].TryAcquire(ctx, testLockName)
+	r.ErrorIs(err, lock.ErrLocked)
+
+	r.NoError(lockA.Release(ctx))
+	r.False(lockA.Held())
+	r.True(isClosed(lockA.Lost()))
+	r.NoError(lockA.Release(ctx))
+
+	lockB, err := lockers[1].TryAcquire(ctx, testLockName)
+	r.NoError(err)
+	r.Equal(int64(2), lockB.Token())
+	r.NoError(lockB.Release(ctx))
+}
+
+func TestLock_Acquire(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, lockers := newTestLockers(t, "a", "b")
+
+	lockA, err := lockers[0].TryAcquire(ctx, testLockName)
+	r.NoError(err)
+
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		lockA.Release(ctx)
+	}()
+	lockB, err := lockers[1].Acquire(ctx, testLockName)
+	r.NoError(err)
+	r.Greater(lockB.Token(), lockA.Token())
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer cancel()
+	_, err = lockers[0].Acquire(timeoutCtx, testLockName)
+	r.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func TestLock_ReleaseOnCancel(t *testing.T) {
+	r := require.New(t)
+	_, lockers := newTestLockers(t, "a", "b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lockA, err := lockers[0].TryAcquire(ctx, testLockName)
+	r.NoError(err)
+
+	cancel()
+	select {
+	case <-lockA.Lost():
+	case <-time.After(time.Second):
+		r.FailNow("lock is not released")
+	}
+
+	lockB, err := lockers[1].TryAcquire(context.Background(), testLockName)
+	r.NoError(err)
+	r.NoError(lockB.Release(context.Background()))
+}
+
+func TestLock_Lost(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client, lockers := newTestLockers(t, "a")
+
+	lockA, err := lockers[0].TryAcquire(ctx, testLockName)
+	r.NoError(err)
+
+	// another owner takes over
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(testTableName),
+		Key:              map[string]types.AttributeValue{"lockName": &types.AttributeValueMemberS{Value: testLockName}},
+		UpdateExpression: aws.String("SET lockOwner = :owner"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: "other"},
+		},
+	})
+	r.NoError(err)
+
+	select {
+	case <-lockA.Lost():
+	case <-time.After(time.Second):
+		r.FailNow("lock is not lost")
+	}
+	r.False(lockA.Held())
+	r.ErrorIs(lockA.Release(ctx), lock.ErrLockLost)
+}
+
+// hangingClient blocks the lock updates after the lock is acquired.
+type hangingClient struct {
+	*memdb.Client
+	hang chan struct{}
+}
+
+func (c *hangingClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	select {
+	case <-c.hang:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	default:
+		return c.Client.UpdateItem(ctx, input, optFns...)
+	}
+}
+
+func TestLock_ExpiresWhileRenewing(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := &hangingClient{Client: memdb.NewClient(), hang: make(chan struct{})}
+	r.NoError(dynamo.EnsureTable(ctx, client, lock.TableSchema(testTableName)))
+	locker, err := lock.NewLocker(client, lock.Config{
+		TableName:     testTableName,
+		LeaseDuration: time.Millisecond * 300,
+		SafetyMargin:  time.Millisecond * 50,
+	})
+	r.NoError(err)
+
+	acquiredAt := time.Now()
+	lockA, err := locker.TryAcquire(ctx, testLockName)
+	r.NoError(err)
+	close(client.hang)
+
+	select {
+	case <-lockA.Lost():
+	case <-time.After(time.Second):
+		r.FailNow("lock is not lost")
+	}
+	r.Less(time.Since(acquiredAt), time.Millisecond*300, "lost before the lease expiry")
+	r.False(lockA.Held())
+}
+
+func TestRunLeaderElection(t *testing.T) {
+	r := require.New(t)
+	_, lockers := newTestLockers(t, "a", "b")
+
+	var (
+		mu         sync.Mutex
+		leading    = make(map[string]bool)
+		concurrent bool
+		started    = make(chan string, 2)
+		stopped    = make(chan string, 2)
+	)
+	callbacks := func(owner string) lock.LeaderCallbacks {
+		return lock.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context, l *lock.Lock) {
+				mu.Lock()
+				concurrent = concurrent || len(leading) > 0
+				leading[owner] = true
+				mu.Unlock()
+				started <- owner
+				<-ctx.Done()
+				mu.Lock()
+				delete(leading, owner)
+				mu.Unlock()
+			},
+			OnStoppedLeading: func() {
+				stopped <- owner
+			},
+		}
+	}
+
+	ctxs := make(map[string]context.CancelFunc)
+	errs := make(chan error, 2)
+	for i, owner := range []string{"a", "b"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		ctxs[owner] = cancel
+		go func(locker *lock.Locker, owner string) {
+			errs <- lock.RunLeaderElection(ctx, locker, "test-election", callbacks(owner))
+		}(lockers[i], owner)
+	}
+
+	leader := <-started
+	follower := "a"
+	if leader == "a" {
+		follower = "b"
+	}
+
+	ctxs[leader]()
+	r.Equal(leader, <-stopped)
+	r.NoError(<-errs)
+	r.Equal(follower, <-started)
+
+	ctxs[follower]()
+	r.Equal(follower, <-stopped)
+	r.NoError(<-errs)
+
+	r.False(concurrent, "only one leader at a time")
+
+	r.Error(lock.RunLeaderElection(context.Background(), lockers[0], "test-election", lock.LeaderCallbacks{}))
+}
+
+func TestRunLeaderElection_ReturnedLeader(t *testing.T) {
+	r := require.New(t)
+	_, lockers := newTestLockers(t, "a")
+
+	var (
+		mu      sync.Mutex
+		started int
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	r.NoError(lock.RunLeaderElection(ctx, lockers[0], "test-election", lock.LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context, l *lock.Lock) {
+			mu.Lock()
+			started++
+			mu.Unlock()
+		},
+	}))
+	// the leadership is campaigned for again after the retry interval
+	mu.Lock()
+	defer mu.Unlock()
+	r.LessOrEqual(started, 11)
+	r.GreaterOrEqual(started, 2)
+}
+
+func TestNewLocker(t *testing.T) {
+	r := require.New(t)
+	client := memdb.NewClient()
+
+	_, err := lock.NewLocker(client, lock.Config{})
+	r.Error(err)
+
+	_, err = lock.NewLocker(client, lock.Config{
+		TableName: testTableName, LeaseDuration: time.Second, HeartbeatInterval: time.Second,
+	})
+	r.Error(err)
+
+	_, err = lock.NewLocker(client, lock.Config{
+		TableName: testTableName, LeaseDuration: time.Second, SafetyMargin: time.Second,
+	})
+	r.Error(err)
+
+	locker, err := lock.NewLocker(client, lock.Config{TableName: testTableName})
+	r.NoError(err)
+	r.NotEmpty(locker.Owner())
+}
@@ -0,0 +1,416 @@
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type item = map[string]types.AttributeValue
+
+// pathElem is a map key or a list index in a document path.
+type pathElem struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+func (elem pathElem) String() string {
+	if elem.isIndex {
+		return fmt.Sprintf("[%d]", elem.index)
+	}
+	return elem.name
+}
+
+func formatPath(path []pathElem) string {
+	var sb strings.Builder
+	for i, elem := range path {
+		if i > 0 && !elem.isIndex {
+			sb.WriteString(".")
+		}
+		sb.WriteString(elem.String())
+	}
+	return sb.String()
+}
+
+func copyItem(it item) item {
+	if it == nil {
+		return nil
+	}
+	copied := make(item, len(it))
+	for name, value := range it {
+		copied[name] = copyValue(value)
+	}
+	return copied
+}
+
+func copyValue(av types.AttributeValue) types.AttributeValue {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: append([]byte{}, v.Value...)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberBS:
+		values := make([][]byte, len(v.Value))
+		for i, b := range v.Value {
+			values[i] = append([]byte{}, b...)
+		}
+		return &types.AttributeValueMemberBS{Value: values}
+	case *types.AttributeValueMemberL:
+		values := make([]types.AttributeValue, len(v.Value))
+		for i, value := range v.Value {
+			values[i] = copyValue(value)
+		}
+		return &types.AttributeValueMemberL{Value: values}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(v.Value)}
+	default:
+		return av
+	}
+}
+
+func parseNumber(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", s)
+	}
+	return r, nil
+}
+
+func formatNumber(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := r.FloatString(38)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// compareValues compares two scalar values of the same type. The result is false if the
+// values are not comparable.
+func compareValues(a, b types.AttributeValue) (int, bool) {
+	switch av := a.(type) {
+	case *types.AttributeValueMemberS:
+		bv, ok := b.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av.Value, bv.Value), true
+	case *types.AttributeValueMemberN:
+		bv, ok := b.(*types.AttributeValueMemberN)
+		if !ok {
+			return 0, false
+		}
+		an, err := parseNumber(av.Value)
+		if err != nil {
+			return 0, false
+		}
+		bn, err := parseNumber(bv.Value)
+		if err != nil {
+			return 0, false
+		}
+		return an.Cmp(bn), true
+	case *types.AttributeValueMemberB:
+		bv, ok := b.(*types.AttributeValueMemberB)
+		if !ok {
+			return 0, false
+		}
+		return bytes.Compare(av.Value, bv.Value), true
+	default:
+		return 0, false
+	}
+}
+
+func equalValues(a, b types.AttributeValue) bool {
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp == 0
+	}
+	switch av := a.(type) {
+	case *types.AttributeValueMemberBOOL:
+		bv, ok := b.(*types.AttributeValueMemberBOOL)
+		return ok && av.Value == bv.Value
+	case *types.AttributeValueMemberNULL:
+		_, ok := b.(*types.AttributeValueMemberNULL)
+		return ok
+	case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+		as, bs := setElems(a), setElems(b)
+		if as == nil || bs == nil || fmt.Sprintf("%T", a) != fmt.Sprintf("%T", b) || len(as) != len(bs) {
+			return false
+		}
+		for _, elem := range as {
+			if !containsValue(bs, elem) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberL:
+		bv, ok := b.(*types.AttributeValueMemberL)
+		if !ok || len(av.Value) != len(bv.Value) {
+			return false
+		}
+		for i := range av.Value {
+			if !equalValues(av.Value[i], bv.Value[i]) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberM:
+		bv, ok := b.(*types.AttributeValueMemberM)
+		if !ok || len(av.Value) != len(bv.Value) {
+			return false
+		}
+		for name, value := range av.Value {
+			other, ok := bv.Value[name]
+			if !ok || !equalValues(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// setElems returns the set elements as scalar values.
+func setElems(av types.AttributeValue) []types.AttributeValue {
+	var elems []types.AttributeValue
+	switch v := av.(type) {
+	case *types.AttributeValueMemberSS:
+		for _, s := range v.Value {
+			elems = append(elems, &types.AttributeValueMemberS{Value: s})
+		}
+	case *types.AttributeValueMemberNS:
+		for _, n := range v.Value {
+			elems = append(elems, &types.AttributeValueMemberN{Value: n})
+		}
+	case *types.AttributeValueMemberBS:
+		for _, b := range v.Value {
+			elems = append(elems, &types.AttributeValueMemberB{Value: b})
+		}
+	default:
+		return nil
+	}
+	if elems == nil {
+		elems = []types.AttributeValue{}
+	}
+	return elems
+}
+
+// makeSet makes a set of the same type as the given set from the elements.
+func makeSet(like types.AttributeValue, elems []types.AttributeValue) types.AttributeValue {
+	switch like.(type) {
+	case *types.AttributeValueMemberSS:
+		set := &types.AttributeValueMemberSS{}
+		for _, elem := range elems {
+			set.Value = append(set.Value, elem.(*types.AttributeValueMemberS).Value)
+		}
+		return set
+	case *types.AttributeValueMemberNS:
+		set := &types.AttributeValueMemberNS{}
+		for _, elem := range elems {
+			set.Value = append(set.Value, elem.(*types.AttributeValueMemberN).Value)
+		}
+		return set
+	default:
+		set := &types.AttributeValueMemberBS{}
+		for _, elem := range elems {
+			set.Value = append(set.Value, elem.(*types.AttributeValueMemberB).Value)
+		}
+		return set
+	}
+}
+
+func containsValue(values []types.AttributeValue, value types.AttributeValue) bool {
+	for _, v := range values {
+		if equalValues(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueSize(av types.AttributeValue) (int, bool) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value), true
+	case *types.AttributeValueMemberB:
+		return len(v.Value), true
+	case *types.AttributeValueMemberL:
+		return len(v.Value), true
+	case *types.AttributeValueMemberM:
+		return len(v.Value), true
+	default:
+		if elems := setElems(av); elems != nil {
+			return len(elems), true
+		}
+		return 0, false
+	}
+}
+
+func valueType(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	default:
+		return ""
+	}
+}
+
+// getPath returns the value at the path.
+func getPath(it item, path []pathElem) (types.AttributeValue, bool) {
+	var current types.AttributeValue = &types.AttributeValueMemberM{Value: it}
+	for _, elem := range path {
+		switch v := current.(type) {
+		case *types.AttributeValueMemberM:
+			if elem.isIndex {
+				return nil, false
+			}
+			next, ok := v.Value[elem.name]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case *types.AttributeValueMemberL:
+			if !elem.isIndex || elem.index >= len(v.Value) {
+				return nil, false
+			}
+			current = v.Value[elem.index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// setPath sets the value at the path. The parent of the path must exist. Setting a list
+// index beyond the end of the list appends the value.
+func setPath(it item, path []pathElem, value types.AttributeValue) error {
+	parent, ok := getPath(it, path[:len(path)-1])
+	if !ok {
+		return fmt.Errorf("the document path provided in the update expression is invalid for update: %s", formatPath(path))
+	}
+	last := path[len(path)-1]
+	switch v := parent.(type) {
+	case *types.AttributeValueMemberM:
+		if last.isIndex {
+			return fmt.Errorf("invalid document path: %s", formatPath(path))
+		}
+		v.Value[last.name] = value
+	case *types.AttributeValueMemberL:
+		if !last.isIndex {
+			return fmt.Errorf("invalid document path: %s", formatPath(path))
+		}
+		if last.index >= len(v.Value) {
+			v.Value = append(v.Value, value)
+		} else {
+			v.Value[last.index] = value
+		}
+	default:
+		return fmt.Errorf("invalid document path: %s", formatPath(path))
+	}
+	return nil
+}
+
+// removePath removes the value at the path if it exists.
+func removePath(it item, path []pathElem) {
+	parent, ok := getPath(it, path[:len(path)-1])
+	if !ok {
+		return
+	}
+	last := path[len(path)-1]
+	switch v := parent.(type) {
+	case *types.AttributeValueMemberM:
+		if !last.isIndex {
+			delete(v.Value, last.name)
+		}
+	case *types.AttributeValueMemberL:
+		if last.isIndex && last.index < len(v.Value) {
+			v.Value = append(v.Value[:last.index], v.Value[last.index+1:]...)
+		}
+	}
+}
+
+// projectItem returns an item which only contains the given paths. The list elements are
+// projected with the whole list.
+func projectItem(it item, paths [][]pathElem) item {
+	projected := make(item)
+	for _, path := range paths {
+		for i, elem := range path {
+			if elem.isIndex {
+				path = path[:i]
+				break
+			}
+		}
+		value, ok := getPath(it, path)
+		if !ok {
+			continue
+		}
+		current := projected
+		for _, elem := range path[:len(path)-1] {
+			next, ok := current[elem.name].(*types.AttributeValueMemberM)
+			if !ok {
+				next = &types.AttributeValueMemberM{Value: make(item)}
+				current[elem.name] = next
+			}
+			current = next.Value
+		}
+		current[path[len(path)-1].name] = copyValue(value)
+	}
+	return projected
+}
+
+// keyString makes a string from the key values which can be used as a map key.
+func keyString(it item, names ...string) string {
+	var parts []string
+	for _, name := range names {
+		if len(name) == 0 {
+			continue
+		}
+		value := it[name]
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			parts = append(parts, "S:"+v.Value)
+		case *types.AttributeValueMemberN:
+			n, err := parseNumber(v.Value)
+			if err == nil {
+				parts = append(parts, "N:"+formatNumber(n))
+			} else {
+				parts = append(parts, "N:"+v.Value)
+			}
+		case *types.AttributeValueMemberB:
+			parts = append(parts, fmt.Sprintf("B:%x", v.Value))
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,602 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/store/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// DynamoDB limits
+const (
+	batchGetLimit    = 100
+	batchWriteLimit  = 25
+	transactionLimit = 100
+)
+
+// Client is an in-memory DynamoDB client for tests and local development. It supports
+// the common cases of the condition, filter, key condition, projection and update expressions.
+type Client struct {
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+var _ aws.DynamoDBClient = &Client{}
+
+// NewClient creates a new in-memory client.
+func NewClient() *Client {
+	return &Client{tables: make(map[string]*table)}
+}
+
+// NewStore creates a store on top of the in-memory client. The table is added with the
+// key names of the item if it does not exist.
+func NewStore[I dynamo.Item](client *Client, tableName string, indexes ...Index) dynamo.Store[I] {
+	var item I
+	client.AddTable(TableDefinition{
+		Name:         tableName,
+		PartitionKey: item.GetPartitionKeyName(),
+		SortKey:      item.GetSortKeyName(),
+		Indexes:      indexes,
+	})
+	return dynamo.NewStore[I](client, tableName)
+}
+
+// AddTable adds the table if it does not exist.
+func (c *Client) AddTable(def TableDefinition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.tables[def.Name]; !ok {
+		c.tables[def.Name] = newTable(def)
+	}
+}
+
+// Items returns all items of the table in the key order.
+func (c *Client) Items(tableName string) []map[string]types.AttributeValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tables[tableName]
+	if !ok {
+		return nil
+	}
+	var items []map[string]types.AttributeValue
+	for _, it := range t.sortedItems(nil) {
+		items = append(items, copyItem(it))
+	}
+	return items
+}
+
+func (c *Client) table(name *string) (*table, error) {
+	if name == nil {
+		return nil, validationError("table name is required")
+	}
+	t, ok := c.tables[*name]
+	if !ok {
+		return nil, tableNotFound(*name)
+	}
+	return t, nil
+}
+
+func validationError(format string, args ...interface{}) error {
+	return &smithy.GenericAPIError{
+		Code:    "ValidationException",
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// checkCondition evaluates the condition on the existing item or on an empty item if it does not exist.
+func checkCondition(
+	existing item, expr *string, names map[string]string, values map[string]types.AttributeValue,
+	returnOnFailure types.ReturnValuesOnConditionCheckFailure,
+) error {
+	if expr == nil {
+		return nil
+	}
+	cond, err := parseCondition(*expr, names, values)
+	if err != nil {
+		return validationError("invalid ConditionExpression: %v", err)
+	}
+	target := existing
+	if target == nil {
+		target = make(item)
+	}
+	ok, err := cond.eval(target)
+	if err != nil {
+		return validationError("invalid ConditionExpression: %v", err)
+	}
+	if ok {
+		return nil
+	}
+	ccfErr := &types.ConditionalCheckFailedException{Message: strPtr("The conditional request failed")}
+	if returnOnFailure == types.ReturnValuesOnConditionCheckFailureAllOld {
+		ccfErr.Item = copyItem(existing)
+	}
+	return ccfErr
+}
+
+func (c *Client) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.lookupKey(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	it, ok := t.items[key]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	if params.ProjectionExpression != nil {
+		projection, err := parseProjection(*params.ProjectionExpression, params.ExpressionAttributeNames)
+		if err != nil {
+			return nil, validationError("invalid ProjectionExpression: %v", err)
+		}
+		return &dynamodb.GetItemOutput{Item: projectItem(it, projection)}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: copyItem(it)}, nil
+}
+
+func (c *Client) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.itemKey(params.Item)
+	if err != nil {
+		return nil, err
+	}
+	existing := t.items[key]
+	if err := checkCondition(
+		existing, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues,
+		params.ReturnValuesOnConditionCheckFailure,
+	); err != nil {
+		return nil, err
+	}
+	t.items[key] = copyItem(params.Item)
+	out := &dynamodb.PutItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		out.Attributes = existing
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.lookupKey(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	existing := t.items[key]
+	if err := checkCondition(
+		existing, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues,
+		params.ReturnValuesOnConditionCheckFailure,
+	); err != nil {
+		return nil, err
+	}
+	delete(t.items, key)
+	out := &dynamodb.DeleteItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		out.Attributes = existing
+	}
+	return out, nil
+}
+
+func (c *Client) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.lookupKey(params.Key)
+	if err != nil {
+		return nil, err
+	}
+	if params.UpdateExpression == nil {
+		return nil, validationError("update expression is required")
+	}
+	actions, err := parseUpdate(*params.UpdateExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, validationError("invalid UpdateExpression: %v", err)
+	}
+	existing := t.items[key]
+	if err := checkCondition(
+		existing, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues,
+		params.ReturnValuesOnConditionCheckFailure,
+	); err != nil {
+		return nil, err
+	}
+	updated, err := t.update(existing, params.Key, actions)
+	if err != nil {
+		return nil, err
+	}
+	t.items[key] = updated
+
+	out := &dynamodb.UpdateItemOutput{}
+	switch params.ReturnValues {
+	case types.ReturnValueAllOld, types.ReturnValueUpdatedOld:
+		out.Attributes = copyItem(existing)
+	case types.ReturnValueAllNew, types.ReturnValueUpdatedNew:
+		out.Attributes = copyItem(updated)
+	}
+	return out, nil
+}
+
+// update applies the update actions to the existing item or to a new item with the given key.
+func (t *table) update(existing, key item, actions []updateAction) (item, error) {
+	target := existing
+	if target == nil {
+		target = copyItem(key)
+	}
+	for _, a := range actions {
+		for _, name := range t.keyNames() {
+			if a.path[0].name == name {
+				return nil, validationError("cannot update attribute %s: this attribute is part of the key", name)
+			}
+		}
+	}
+	updated, err := applyUpdate(target, actions)
+	if err != nil {
+		return nil, validationError("invalid UpdateExpression: %v", err)
+	}
+	return updated, nil
+}
+
+func (c *Client) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if params.KeyConditionExpression == nil {
+		return nil, validationError("either the KeyConditions or KeyConditionExpression parameter must be specified")
+	}
+	result, err := t.read(&readRequest{
+		indexName:         params.IndexName,
+		keyCondition:      params.KeyConditionExpression,
+		filter:            params.FilterExpression,
+		projection:        params.ProjectionExpression,
+		names:             params.ExpressionAttributeNames,
+		values:            params.ExpressionAttributeValues,
+		exclusiveStartKey: params.ExclusiveStartKey,
+		limit:             params.Limit,
+		descending:        params.ScanIndexForward != nil && !*params.ScanIndexForward,
+		countOnly:         params.Select == types.SelectCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{
+		Items:            result.items,
+		Count:            result.count,
+		ScannedCount:     result.scannedCount,
+		LastEvaluatedKey: result.lastEvaluatedKey,
+	}, nil
+}
+
+func (c *Client) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	result, err := t.read(&readRequest{
+		indexName:         params.IndexName,
+		filter:            params.FilterExpression,
+		projection:        params.ProjectionExpression,
+		names:             params.ExpressionAttributeNames,
+		values:            params.ExpressionAttributeValues,
+		exclusiveStartKey: params.ExclusiveStartKey,
+		limit:             params.Limit,
+		segment:           params.Segment,
+		totalSegments:     params.TotalSegments,
+		countOnly:         params.Select == types.SelectCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{
+		Items:            result.items,
+		Count:            result.count,
+		ScannedCount:     result.scannedCount,
+		LastEvaluatedKey: result.lastEvaluatedKey,
+	}, nil
+}
+
+func (c *Client) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total int
+	for _, request := range params.RequestItems {
+		total += len(request.Keys)
+	}
+	if total == 0 || total > batchGetLimit {
+		return nil, validationError("too many items requested for the BatchGetItem call: %d", total)
+	}
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: make(map[string][]map[string]types.AttributeValue),
+	}
+	for tableName, request := range params.RequestItems {
+		t, err := c.table(&tableName)
+		if err != nil {
+			return nil, err
+		}
+		var projection [][]pathElem
+		if request.ProjectionExpression != nil {
+			if projection, err = parseProjection(*request.ProjectionExpression, request.ExpressionAttributeNames); err != nil {
+				return nil, validationError("invalid ProjectionExpression: %v", err)
+			}
+		}
+		seen := make(map[string]bool)
+		for _, requestKey := range request.Keys {
+			key, err := t.lookupKey(requestKey)
+			if err != nil {
+				return nil, err
+			}
+			if seen[key] {
+				return nil, validationError("provided list of item keys contains duplicates")
+			}
+			seen[key] = true
+			it, ok := t.items[key]
+			if !ok {
+				continue
+			}
+			if projection != nil {
+				it = projectItem(it, projection)
+			} else {
+				it = copyItem(it)
+			}
+			out.Responses[tableName] = append(out.Responses[tableName], it)
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total int
+	for _, requests := range params.RequestItems {
+		total += len(requests)
+	}
+	if total == 0 || total > batchWriteLimit {
+		return nil, validationError("too many items requested for the BatchWriteItem call: %d", total)
+	}
+
+	// validate all before writing
+	type write struct {
+		t   *table
+		key string
+		it  item
+	}
+	var writes []write
+	seen := make(map[string]bool)
+	for tableName, requests := range params.RequestItems {
+		t, err := c.table(&tableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, request := range requests {
+			var w write
+			switch {
+			case request.PutRequest != nil:
+				w.key, err = t.itemKey(request.PutRequest.Item)
+				w.it = request.PutRequest.Item
+			case request.DeleteRequest != nil:
+				w.key, err = t.lookupKey(request.DeleteRequest.Key)
+			default:
+				err = validationError("empty write request")
+			}
+			if err != nil {
+				return nil, err
+			}
+			if seen[tableName+"\x00"+w.key] {
+				return nil, validationError("provided list of item keys contains duplicates")
+			}
+			seen[tableName+"\x00"+w.key] = true
+			w.t = t
+			writes = append(writes, w)
+		}
+	}
+	for _, w := range writes {
+		if w.it != nil {
+			w.t.items[w.key] = copyItem(w.it)
+		} else {
+			delete(w.t.items, w.key)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (c *Client) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(params.TransactItems) == 0 || len(params.TransactItems) > transactionLimit {
+		return nil, validationError("invalid number of transaction items: %d", len(params.TransactItems))
+	}
+
+	type write struct {
+		t       *table
+		key     string
+		it      item
+		deleted bool
+	}
+	var (
+		writes  []write
+		reasons = make([]types.CancellationReason, len(params.TransactItems))
+		failed  bool
+		seen    = make(map[string]bool)
+	)
+	for i, transactItem := range params.TransactItems {
+		var (
+			w         = write{}
+			tableName *string
+			key       string
+			err       error
+			condErr   error
+		)
+		switch op := transactItem; {
+		case op.Put != nil:
+			tableName = op.Put.TableName
+			if w.t, err = c.table(tableName); err != nil {
+				return nil, err
+			}
+			if key, err = w.t.itemKey(op.Put.Item); err != nil {
+				return nil, err
+			}
+			condErr = checkCondition(
+				w.t.items[key], op.Put.ConditionExpression, op.Put.ExpressionAttributeNames,
+				op.Put.ExpressionAttributeValues, op.Put.ReturnValuesOnConditionCheckFailure,
+			)
+			w.it = copyItem(op.Put.Item)
+		case op.Update != nil:
+			tableName = op.Update.TableName
+			if w.t, err = c.table(tableName); err != nil {
+				return nil, err
+			}
+			if key, err = w.t.lookupKey(op.Update.Key); err != nil {
+				return nil, err
+			}
+			if op.Update.UpdateExpression == nil {
+				return nil, validationError("update expression is required")
+			}
+			actions, err := parseUpdate(*op.Update.UpdateExpression, op.Update.ExpressionAttributeNames, op.Update.ExpressionAttributeValues)
+			if err != nil {
+				return nil, validationError("invalid UpdateExpression: %v", err)
+			}
+			condErr = checkCondition(
+				w.t.items[key], op.Update.ConditionExpression, op.Update.ExpressionAttributeNames,
+				op.Update.ExpressionAttributeValues, op.Update.ReturnValuesOnConditionCheckFailure,
+			)
+			if condErr == nil {
+				if w.it, err = w.t.update(w.t.items[key], op.Update.Key, actions); err != nil {
+					return nil, err
+				}
+			}
+		case op.Delete != nil:
+			tableName = op.Delete.TableName
+			if w.t, err = c.table(tableName); err != nil {
+				return nil, err
+			}
+			if key, err = w.t.lookupKey(op.Delete.Key); err != nil {
+				return nil, err
+			}
+			condErr = checkCondition(
+				w.t.items[key], op.Delete.ConditionExpression, op.Delete.ExpressionAttributeNames,
+				op.Delete.ExpressionAttributeValues, op.Delete.ReturnValuesOnConditionCheckFailure,
+			)
+			w.deleted = true
+		case op.ConditionCheck != nil:
+			tableName = op.ConditionCheck.TableName
+			if w.t, err = c.table(tableName); err != nil {
+				return nil, err
+			}
+			if key, err = w.t.lookupKey(op.ConditionCheck.Key); err != nil {
+				return nil, err
+			}
+			if op.ConditionCheck.ConditionExpression == nil {
+				return nil, validationError("condition expression is required for condition checks")
+			}
+			condErr = checkCondition(
+				w.t.items[key], op.ConditionCheck.ConditionExpression, op.ConditionCheck.ExpressionAttributeNames,
+				op.ConditionCheck.ExpressionAttributeValues, op.ConditionCheck.ReturnValuesOnConditionCheckFailure,
+			)
+		default:
+			return nil, validationError("empty transaction item")
+		}
+		if seen[*tableName+"\x00"+key] {
+			return nil, validationError("transaction request cannot include multiple operations on one item")
+		}
+		seen[*tableName+"\x00"+key] = true
+
+		reasons[i].Code = strPtr(dynamo.CancellationReasonNone)
+		if condErr != nil {
+			ccfErr, ok := condErr.(*types.ConditionalCheckFailedException)
+			if !ok {
+				return nil, condErr
+			}
+			failed = true
+			reasons[i].Code = strPtr(dynamo.CancellationReasonConditionalCheck)
+			reasons[i].Message = ccfErr.Message
+			reasons[i].Item = ccfErr.Item
+			continue
+		}
+		if w.it != nil || w.deleted {
+			w.key = key
+			writes = append(writes, w)
+		}
+	}
+	if failed {
+		return nil, &types.TransactionCanceledException{
+			Message:             strPtr("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+	for _, w := range writes {
+		if w.deleted {
+			delete(w.t.items, w.key)
+		} else {
+			w.t.items[w.key] = w.it
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
@@ -0,0 +1,292 @@
+package memdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTableName = "test-table"
+	testIndexName = "test-index"
+)
+
+type testItem struct {
+	Pkey    string `dynamodbav:"pkey"`
+	Skey    string `dynamodbav:"skey"`
+	Owner   string `dynamodbav:"owner"`
+	Count   int    `dynamodbav:"count"`
+	Version int64  `dynamodbav:"version"`
+}
+
+func (testItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (testItem) GetSortKeyName() string {
+	return "skey"
+}
+
+func (item testItem) GetVersionAttributeName() string {
+	return "version"
+}
+
+func (item testItem) GetVersion() int64 {
+	return item.Version
+}
+
+func (item *testItem) SetVersion(version int64) {
+	item.Version = version
+}
+
+func newTestStore() (*memdb.Client, dynamo.Store[testItem]) {
+	client := memdb.NewClient()
+	store := memdb.NewStore[testItem](client, testTableName, memdb.Index{
+		Name:         testIndexName,
+		PartitionKey: "owner",
+		SortKey:      "skey",
+	})
+	return client, store
+}
+
+func TestPutGetDelete(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, store := newTestStore()
+
+	_, err := store.Get(ctx, "pk", "sk")
+	r.ErrorIs(err, dynamo.ErrNotFound)
+
+	item := &testItem{Pkey: "pk", Skey: "sk", Owner: "alice"}
+	r.NoError(store.Put(ctx, item))
+	r.Equal(int64(1), item.Version)
+
+	found, err := store.Get(ctx, "pk", "sk")
+	r.NoError(err)
+	r.Equal(item, found)
+
+	// stale version
+	stale := &testItem{Pkey: "pk", Skey: "sk", Version: 0}
+	r.ErrorIs(store.Put(ctx, stale), dynamo.ErrVersionConflict)
+
+	r.NoError(store.Delete(ctx, item, "pk", "sk"))
+	_, err = store.Get(ctx, "pk", "sk")
+	r.ErrorIs(err, dynamo.ErrNotFound)
+}
+
+func TestConditionalPut(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client, _ := newTestStore()
+
+	put := func(count int) error {
+		_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: strPtr(testTableName),
+			Item: map[string]types.AttributeValue{
+				"pkey":  &types.AttributeValueMemberS{Value: "pk"},
+				"skey":  &types.AttributeValueMemberS{Value: "sk"},
+				"count": &types.AttributeValueMemberN{Value: "1"},
+			},
+			ConditionExpression: strPtr("attribute_not_exists(#c) OR #c < :max"),
+			ExpressionAttributeNames: map[string]string{
+				"#c": "count",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":max": &types.AttributeValueMemberN{Value: "1"},
+			},
+		})
+		return err
+	}
+	r.NoError(put(1))
+	err := put(1)
+	var conditionErr *types.ConditionalCheckFailedException
+	r.True(errors.As(err, &conditionErr))
+}
+
+func TestUpdate(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, store := newTestStore()
+
+	r.NoError(store.Put(ctx, &testItem{Pkey: "pk", Skey: "sk", Owner: "alice"}))
+
+	updated, err := store.Update(ctx, dynamo.NewKey("pk", "sk"), dynamo.NewUpdate().
+		Set("owner", "bob").
+		Increment("count", 2).
+		ExpectVersion(1))
+	r.NoError(err)
+	r.Equal(&testItem{Pkey: "pk", Skey: "sk", Owner: "bob", Count: 2, Version: 2}, updated)
+
+	_, err = store.Update(ctx, dynamo.NewKey("pk", "sk"), dynamo.NewUpdate().
+		Increment("count", 1).
+		ExpectVersion(1))
+	r.ErrorIs(err, dynamo.ErrVersionConflict)
+
+	// creates the item if it does not exist
+	created, err := store.Update(ctx, dynamo.NewKey("pk", "other"), dynamo.NewUpdate().Increment("count", 5))
+	r.NoError(err)
+	r.Equal(5, created.Count)
+}
+
+func TestQuery(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, store := newTestStore()
+
+	for _, item := range []*testItem{
+		{Pkey: "pk", Skey: "a1", Owner: "alice", Count: 1},
+		{Pkey: "pk", Skey: "a2", Owner: "bob", Count: 2},
+		{Pkey: "pk", Skey: "b1", Owner: "alice", Count: 3},
+		{Pkey: "other", Skey: "a3", Owner: "alice", Count: 4},
+	} {
+		r.NoError(store.Put(ctx, item))
+	}
+
+	skeys := func(items []*testItem) (result []string) {
+		for _, item := range items {
+			result = append(result, item.Skey)
+		}
+		return
+	}
+
+	items, err := store.GetAll(ctx, "pk")
+	r.NoError(err)
+	r.Equal([]string{"a1", "a2", "b1"}, skeys(items))
+
+	items, err = store.Query(ctx, dynamo.NewQuery("pk").SortKey(dynamo.KeyBeginsWith("a")).Descending())
+	r.NoError(err)
+	r.Equal([]string{"a2", "a1"}, skeys(items))
+
+	items, err = store.Query(ctx, dynamo.NewQuery("pk").Filter(dynamo.GreaterThan("count", 1)))
+	r.NoError(err)
+	r.Equal([]string{"a2", "b1"}, skeys(items))
+
+	items, err = store.Query(ctx, dynamo.NewQuery("alice").Index(testIndexName, "owner", "skey").
+		SortKey(dynamo.KeyBetween("a1", "a3")))
+	r.NoError(err)
+	r.Equal([]string{"a1", "a3"}, skeys(items))
+
+	items, err = store.GetAllFromIndex(ctx, testIndexName, "owner", "alice")
+	r.NoError(err)
+	r.Equal([]string{"a1", "a3", "b1"}, skeys(items))
+}
+
+func TestPagination(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, store := newTestStore()
+
+	for _, skey := range []string{"a", "b", "c", "d", "e"} {
+		r.NoError(store.Put(ctx, &testItem{Pkey: "pk", Skey: skey, Owner: "alice"}))
+	}
+
+	for _, getPage := range []func(pageToken string) (*dynamo.Page[testItem], error){
+		func(pageToken string) (*dynamo.Page[testItem], error) {
+			return store.GetAllPage(ctx, "pk", 2, pageToken)
+		},
+		func(pageToken string) (*dynamo.Page[testItem], error) {
+			return store.GetAllFromIndexPage(ctx, testIndexName, "owner", "alice", 2, pageToken)
+		},
+		func(pageToken string) (*dynamo.Page[testItem], error) {
+			return store.ScanPage(ctx, 2, pageToken)
+		},
+	} {
+		var (
+			skeys     []string
+			pageToken string
+			pages     int
+		)
+		for {
+			page, err := getPage(pageToken)
+			r.NoError(err)
+			for _, item := range page.Items {
+				skeys = append(skeys, item.Skey)
+			}
+			pages++
+			if page.NextPageToken == "" {
+				break
+			}
+			pageToken = page.NextPageToken
+		}
+		r.Equal([]string{"a", "b", "c", "d", "e"}, skeys)
+		r.Equal(3, pages)
+	}
+
+	items, err := store.ParallelScan(ctx, 3)
+	r.NoError(err)
+	r.Len(items, 5)
+}
+
+func TestBatch(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client, store := newTestStore()
+
+	var (
+		items []*testItem
+		keys  []dynamo.Key
+	)
+	for i := 0; i < 30; i++ {
+		skey := string(rune('a' + i))
+		items = append(items, &testItem{Pkey: "pk", Skey: skey})
+		keys = append(keys, dynamo.NewKey("pk", skey))
+	}
+	r.NoError(store.BatchPut(ctx, items))
+	r.Len(client.Items(testTableName), 30)
+
+	found, err := store.BatchGet(ctx, append(keys, dynamo.NewKey("pk", "missing")))
+	r.NoError(err)
+	r.Len(found, 30)
+
+	r.NoError(store.BatchDelete(ctx, keys[:20]))
+	r.Len(client.Items(testTableName), 10)
+
+	// the client enforces the DynamoDB limits
+	requests := make([]types.WriteRequest, 26)
+	for i := range requests {
+		requests[i].DeleteRequest = &types.DeleteRequest{Key: map[string]types.AttributeValue{
+			"pkey": &types.AttributeValueMemberS{Value: "pk"},
+			"skey": &types.AttributeValueMemberS{Value: string(rune('a' + i))},
+		}}
+	}
+	_, err = client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]types.WriteRequest{testTableName: requests},
+	})
+	r.Error(err)
+}
+
+func TestTransaction(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client, store := newTestStore()
+
+	r.NoError(store.Put(ctx, &testItem{Pkey: "pk", Skey: "existing"}))
+
+	err := dynamo.NewTransaction(client).Add(
+		dynamo.TxPut(store, &testItem{Pkey: "pk", Skey: "new"}),
+		dynamo.TxPut(store, &testItem{Pkey: "pk", Skey: "existing"}),
+	).Commit(ctx)
+	var canceledErr *dynamo.TransactionCanceledError
+	r.True(errors.As(err, &canceledErr))
+	r.Equal([]int{1}, canceledErr.Failed())
+	r.Len(client.Items(testTableName), 1)
+
+	r.NoError(dynamo.NewTransaction(client).Add(
+		dynamo.TxPut(store, &testItem{Pkey: "pk", Skey: "new"}),
+		dynamo.TxDelete(store, dynamo.NewKey("pk", "existing")),
+	).Commit(ctx))
+	items := client.Items(testTableName)
+	r.Len(items, 1)
+	r.Equal(&types.AttributeValueMemberS{Value: "new"}, items[0]["skey"])
+}
+
+func strPtr(s string) *string {
+	return &s
+}
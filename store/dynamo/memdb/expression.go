@@ -0,0 +1,840 @@
+package memdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenValue
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func isNameChar(c byte, first bool) bool {
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' || c == ':' || isNameChar(c, true):
+			start := i
+			i++
+			for i < len(expr) && isNameChar(expr[i], false) {
+				i++
+			}
+			kind := tokenName
+			if c == ':' {
+				kind = tokenValue
+			}
+			if i-start == 1 && !isNameChar(c, true) {
+				return nil, fmt.Errorf("invalid expression: unexpected '%c'", c)
+			}
+			tokens = append(tokens, token{kind: kind, text: expr[start:i]})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(expr) && expr[i] >= '0' && expr[i] <= '9' {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i]})
+		case c == '<' || c == '>':
+			if i+1 < len(expr) && (expr[i+1] == '=' || (c == '<' && expr[i+1] == '>')) {
+				tokens = append(tokens, token{kind: tokenSymbol, text: expr[i : i+2]})
+				i += 2
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(c)})
+			i++
+		case strings.IndexByte("=(),.[]+-", c) >= 0:
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("invalid expression: unexpected '%c'", c)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func newParser(expr string, names map[string]string, values map[string]types.AttributeValue) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens, names: names, values: values}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	tok := p.peek()
+	return tok.kind == tokenSymbol && tok.text == symbol
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenName && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	tok := p.next()
+	if tok.kind != tokenSymbol || tok.text != symbol {
+		return p.unexpected(tok, symbol)
+	}
+	return nil
+}
+
+func (p *parser) expectEOF() error {
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return p.unexpected(tok, "end of expression")
+	}
+	return nil
+}
+
+func (p *parser) unexpected(tok token, expected string) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("invalid expression: expected %s but reached the end", expected)
+	}
+	return fmt.Errorf("invalid expression: expected %s but found '%s'", expected, tok.text)
+}
+
+func (p *parser) resolveName(tok token) (string, error) {
+	if !strings.HasPrefix(tok.text, "#") {
+		return tok.text, nil
+	}
+	name, ok := p.names[tok.text]
+	if !ok {
+		return "", fmt.Errorf("an expression attribute name used in the document path is not defined: %s", tok.text)
+	}
+	return name, nil
+}
+
+func (p *parser) parsePath() ([]pathElem, error) {
+	tok := p.next()
+	if tok.kind != tokenName {
+		return nil, p.unexpected(tok, "attribute name")
+	}
+	name, err := p.resolveName(tok)
+	if err != nil {
+		return nil, err
+	}
+	path := []pathElem{{name: name}}
+	for {
+		switch {
+		case p.isSymbol("."):
+			p.next()
+			tok := p.next()
+			if tok.kind != tokenName {
+				return nil, p.unexpected(tok, "attribute name")
+			}
+			name, err := p.resolveName(tok)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, pathElem{name: name})
+		case p.isSymbol("["):
+			p.next()
+			tok := p.next()
+			if tok.kind != tokenNumber {
+				return nil, p.unexpected(tok, "list index")
+			}
+			index, err := strconv.Atoi(tok.text)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol("]"); err != nil {
+				return nil, err
+			}
+			path = append(path, pathElem{index: index, isIndex: true})
+		default:
+			return path, nil
+		}
+	}
+}
+
+// operand is a value in an expression.
+type operand interface {
+	eval(it item) (types.AttributeValue, bool, error)
+}
+
+type pathOperand []pathElem
+
+func (o pathOperand) eval(it item) (types.AttributeValue, bool, error) {
+	value, ok := getPath(it, o)
+	return value, ok, nil
+}
+
+type valueOperand struct {
+	value types.AttributeValue
+}
+
+func (o valueOperand) eval(it item) (types.AttributeValue, bool, error) {
+	return o.value, true, nil
+}
+
+type sizeOperand []pathElem
+
+func (o sizeOperand) eval(it item) (types.AttributeValue, bool, error) {
+	value, ok := getPath(it, o)
+	if !ok {
+		return nil, false, nil
+	}
+	size, ok := valueSize(value)
+	if !ok {
+		return nil, false, fmt.Errorf("invalid operand type for size(): %s", valueType(value))
+	}
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(size)}, true, nil
+}
+
+type ifNotExistsOperand struct {
+	path     []pathElem
+	fallback operand
+}
+
+func (o ifNotExistsOperand) eval(it item) (types.AttributeValue, bool, error) {
+	if value, ok := getPath(it, o.path); ok {
+		return value, true, nil
+	}
+	return o.fallback.eval(it)
+}
+
+type listAppendOperand struct {
+	left, right operand
+}
+
+func (o listAppendOperand) eval(it item) (types.AttributeValue, bool, error) {
+	var values []types.AttributeValue
+	for _, arg := range []operand{o.left, o.right} {
+		value, ok, err := arg.eval(it)
+		if err != nil {
+			return nil, false, err
+		}
+		list, isList := value.(*types.AttributeValueMemberL)
+		if !ok || !isList {
+			return nil, false, fmt.Errorf("invalid operand for list_append(): expected a list")
+		}
+		values = append(values, list.Value...)
+	}
+	return &types.AttributeValueMemberL{Value: values}, true, nil
+}
+
+type arithmeticOperand struct {
+	operator    string
+	left, right operand
+}
+
+func (o arithmeticOperand) eval(it item) (types.AttributeValue, bool, error) {
+	var numbers []*types.AttributeValueMemberN
+	for _, arg := range []operand{o.left, o.right} {
+		value, ok, err := arg.eval(it)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			return nil, false, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+		}
+		number, isNumber := value.(*types.AttributeValueMemberN)
+		if !isNumber {
+			return nil, false, fmt.Errorf("incorrect operand type for operator %s: %s", o.operator, valueType(value))
+		}
+		numbers = append(numbers, number)
+	}
+	left, err := parseNumber(numbers[0].Value)
+	if err != nil {
+		return nil, false, err
+	}
+	right, err := parseNumber(numbers[1].Value)
+	if err != nil {
+		return nil, false, err
+	}
+	if o.operator == "-" {
+		right.Neg(right)
+	}
+	return &types.AttributeValueMemberN{Value: formatNumber(left.Add(left, right))}, true, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenValue:
+		p.next()
+		value, ok := p.values[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("an expression attribute value used in expression is not defined: %s", tok.text)
+		}
+		return valueOperand{value: value}, nil
+	case tokenName:
+		if next := p.peekAt(1); next.kind != tokenSymbol || next.text != "(" {
+			path, err := p.parsePath()
+			return pathOperand(path), err
+		}
+		p.next()
+		p.next()
+		var (
+			op  operand
+			err error
+		)
+		switch tok.text {
+		case "size":
+			var path []pathElem
+			path, err = p.parsePath()
+			op = sizeOperand(path)
+		case "if_not_exists":
+			ifNotExists := ifNotExistsOperand{}
+			if ifNotExists.path, err = p.parsePath(); err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+			ifNotExists.fallback, err = p.parseOperand()
+			op = ifNotExists
+		case "list_append":
+			listAppend := listAppendOperand{}
+			if listAppend.left, err = p.parseOperand(); err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+			listAppend.right, err = p.parseOperand()
+			op = listAppend
+		default:
+			return nil, fmt.Errorf("invalid function name: %s", tok.text)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return op, p.expectSymbol(")")
+	default:
+		return nil, p.unexpected(tok, "operand")
+	}
+}
+
+// condition is a boolean expression.
+type condition interface {
+	eval(it item) (bool, error)
+}
+
+type andCondition struct {
+	left, right condition
+}
+
+func (c andCondition) eval(it item) (bool, error) {
+	ok, err := c.left.eval(it)
+	if err != nil || !ok {
+		return false, err
+	}
+	return c.right.eval(it)
+}
+
+type orCondition struct {
+	left, right condition
+}
+
+func (c orCondition) eval(it item) (bool, error) {
+	ok, err := c.left.eval(it)
+	if err != nil || ok {
+		return ok, err
+	}
+	return c.right.eval(it)
+}
+
+type notCondition struct {
+	condition condition
+}
+
+func (c notCondition) eval(it item) (bool, error) {
+	ok, err := c.condition.eval(it)
+	return !ok, err
+}
+
+type compareCondition struct {
+	operator    string
+	left, right operand
+}
+
+func (c compareCondition) eval(it item) (bool, error) {
+	left, leftOk, err := c.left.eval(it)
+	if err != nil {
+		return false, err
+	}
+	right, rightOk, err := c.right.eval(it)
+	if err != nil {
+		return false, err
+	}
+	if !leftOk || !rightOk {
+		return c.operator == "<>", nil
+	}
+	switch c.operator {
+	case "=":
+		return equalValues(left, right), nil
+	case "<>":
+		return !equalValues(left, right), nil
+	}
+	cmp, ok := compareValues(left, right)
+	if !ok {
+		return false, nil
+	}
+	switch c.operator {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type betweenCondition struct {
+	value, lower, upper operand
+}
+
+func (c betweenCondition) eval(it item) (bool, error) {
+	lower, err := compareCondition{operator: ">=", left: c.value, right: c.lower}.eval(it)
+	if err != nil || !lower {
+		return false, err
+	}
+	return compareCondition{operator: "<=", left: c.value, right: c.upper}.eval(it)
+}
+
+type inCondition struct {
+	value operand
+	list  []operand
+}
+
+func (c inCondition) eval(it item) (bool, error) {
+	for _, elem := range c.list {
+		ok, err := compareCondition{operator: "=", left: c.value, right: elem}.eval(it)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+type functionCondition struct {
+	name string
+	path []pathElem
+	arg  operand
+}
+
+func (c functionCondition) eval(it item) (bool, error) {
+	value, exists := getPath(it, c.path)
+	switch c.name {
+	case "attribute_exists":
+		return exists, nil
+	case "attribute_not_exists":
+		return !exists, nil
+	}
+	arg, ok, err := c.arg.eval(it)
+	if err != nil || !ok || !exists {
+		return false, err
+	}
+	switch c.name {
+	case "attribute_type":
+		typ, ok := arg.(*types.AttributeValueMemberS)
+		return ok && typ.Value == valueType(value), nil
+	case "begins_with":
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			prefix, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.HasPrefix(v.Value, prefix.Value), nil
+		case *types.AttributeValueMemberB:
+			prefix, ok := arg.(*types.AttributeValueMemberB)
+			return ok && strings.HasPrefix(string(v.Value), string(prefix.Value)), nil
+		}
+		return false, nil
+	default: // contains
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			substr, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.Contains(v.Value, substr.Value), nil
+		case *types.AttributeValueMemberL:
+			return containsValue(v.Value, arg), nil
+		}
+		if elems := setElems(value); elems != nil {
+			return containsValue(elems, arg), nil
+		}
+		return false, nil
+	}
+}
+
+var conditionFunctions = map[string]bool{
+	"attribute_exists":     true,
+	"attribute_not_exists": true,
+	"attribute_type":       true,
+	"begins_with":          true,
+	"contains":             true,
+}
+
+var comparators = map[string]bool{"=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *parser) parseCondition() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{condition: cond}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (condition, error) {
+	if p.isSymbol("(") {
+		p.next()
+		cond, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expectSymbol(")")
+	}
+
+	if tok, next := p.peek(), p.peekAt(1); tok.kind == tokenName && conditionFunctions[tok.text] &&
+		next.kind == tokenSymbol && next.text == "(" {
+		p.next()
+		p.next()
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		cond := functionCondition{name: tok.text, path: path}
+		if tok.text != "attribute_exists" && tok.text != "attribute_not_exists" {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+			if cond.arg, err = p.parseOperand(); err != nil {
+				return nil, err
+			}
+		}
+		return cond, p.expectSymbol(")")
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenSymbol && comparators[tok.text]:
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareCondition{operator: tok.text, left: left, right: right}, nil
+
+	case p.isKeyword("BETWEEN"):
+		p.next()
+		lower, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("AND") {
+			return nil, p.unexpected(p.peek(), "AND")
+		}
+		p.next()
+		upper, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return betweenCondition{value: left, lower: lower, upper: upper}, nil
+
+	case p.isKeyword("IN"):
+		p.next()
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		cond := inCondition{value: left}
+		for {
+			elem, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			cond.list = append(cond.list, elem)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		return cond, p.expectSymbol(")")
+
+	default:
+		return nil, p.unexpected(tok, "comparator")
+	}
+}
+
+// parseCondition parses a condition, filter or key condition expression.
+func parseCondition(expr string, names map[string]string, values map[string]types.AttributeValue) (condition, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	return cond, p.expectEOF()
+}
+
+// parseProjection parses a projection expression.
+func parseProjection(expr string, names map[string]string) ([][]pathElem, error) {
+	p, err := newParser(expr, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths [][]pathElem
+	for {
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	return paths, p.expectEOF()
+}
+
+// Update actions
+const (
+	updateSet    = "SET"
+	updateRemove = "REMOVE"
+	updateAdd    = "ADD"
+	updateDelete = "DELETE"
+)
+
+type updateAction struct {
+	action string
+	path   []pathElem
+	value  operand
+}
+
+// parseUpdate parses an update expression.
+func parseUpdate(expr string, names map[string]string, values map[string]types.AttributeValue) ([]updateAction, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	var actions []updateAction
+	seen := make(map[string]bool)
+	for p.peek().kind != tokenEOF {
+		tok := p.next()
+		action := strings.ToUpper(tok.text)
+		if tok.kind != tokenName || (action != updateSet && action != updateRemove && action != updateAdd && action != updateDelete) {
+			return nil, p.unexpected(tok, "SET, REMOVE, ADD or DELETE")
+		}
+		if seen[action] {
+			return nil, fmt.Errorf("invalid update expression: the %s section can only be used once", action)
+		}
+		seen[action] = true
+		for {
+			path, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			a := updateAction{action: action, path: path}
+			switch action {
+			case updateSet:
+				if err := p.expectSymbol("="); err != nil {
+					return nil, err
+				}
+				if a.value, err = p.parseOperand(); err != nil {
+					return nil, err
+				}
+				if p.isSymbol("+") || p.isSymbol("-") {
+					operator := p.next().text
+					right, err := p.parseOperand()
+					if err != nil {
+						return nil, err
+					}
+					a.value = arithmeticOperand{operator: operator, left: a.value, right: right}
+				}
+			case updateAdd, updateDelete:
+				if a.value, err = p.parseOperand(); err != nil {
+					return nil, err
+				}
+			}
+			actions = append(actions, a)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("invalid update expression: empty expression")
+	}
+	return actions, nil
+}
+
+// applyUpdate applies the update actions to the item. The values are evaluated on the item
+// before the update.
+func applyUpdate(it item, actions []updateAction) (item, error) {
+	updated := copyItem(it)
+	values := make([]types.AttributeValue, len(actions))
+	for i, a := range actions {
+		if a.value == nil {
+			continue
+		}
+		value, ok, err := a.value.eval(it)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+		}
+		values[i] = copyValue(value)
+	}
+	for i, a := range actions {
+		switch a.action {
+		case updateSet:
+			if err := setPath(updated, a.path, values[i]); err != nil {
+				return nil, err
+			}
+		case updateRemove:
+			removePath(updated, a.path)
+		case updateAdd:
+			current, ok := getPath(updated, a.path)
+			if !ok {
+				if err := setPath(updated, a.path, values[i]); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			sum, err := addValues(current, values[i])
+			if err != nil {
+				return nil, err
+			}
+			if err := setPath(updated, a.path, sum); err != nil {
+				return nil, err
+			}
+		case updateDelete:
+			current, ok := getPath(updated, a.path)
+			if !ok {
+				continue
+			}
+			currentElems, deleteElems := setElems(current), setElems(values[i])
+			if currentElems == nil || deleteElems == nil || valueType(current) != valueType(values[i]) {
+				return nil, fmt.Errorf("invalid operand type for DELETE: %s", valueType(current))
+			}
+			var remaining []types.AttributeValue
+			for _, elem := range currentElems {
+				if !containsValue(deleteElems, elem) {
+					remaining = append(remaining, elem)
+				}
+			}
+			if len(remaining) == 0 {
+				removePath(updated, a.path)
+				continue
+			}
+			if err := setPath(updated, a.path, makeSet(current, remaining)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return updated, nil
+}
+
+// addValues adds numbers or makes the union of the sets for the ADD action.
+func addValues(current, value types.AttributeValue) (types.AttributeValue, error) {
+	if currentNum, ok := current.(*types.AttributeValueMemberN); ok {
+		sum, _, err := arithmeticOperand{
+			operator: "+",
+			left:     valueOperand{value: currentNum},
+			right:    valueOperand{value: value},
+		}.eval(nil)
+		return sum, err
+	}
+	currentElems, addElems := setElems(current), setElems(value)
+	if currentElems == nil || addElems == nil || valueType(current) != valueType(value) {
+		return nil, fmt.Errorf("invalid operand type for ADD: %s", valueType(current))
+	}
+	for _, elem := range addElems {
+		if !containsValue(currentElems, elem) {
+			currentElems = append(currentElems, elem)
+		}
+	}
+	return makeSet(current, currentElems), nil
+}
@@ -0,0 +1,115 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testExpressionItem() item {
+	return item{
+		"name":  &types.AttributeValueMemberS{Value: "alice"},
+		"count": &types.AttributeValueMemberN{Value: "3"},
+		"tags":  &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberN{Value: "1"},
+		}},
+		"nested": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"field": &types.AttributeValueMemberS{Value: "value"},
+		}},
+	}
+}
+
+func TestCondition(t *testing.T) {
+	values := map[string]types.AttributeValue{
+		":s":   &types.AttributeValueMemberS{Value: "alice"},
+		":n1":  &types.AttributeValueMemberN{Value: "1"},
+		":n5":  &types.AttributeValueMemberN{Value: "5"},
+		":pfx": &types.AttributeValueMemberS{Value: "al"},
+		":tag": &types.AttributeValueMemberS{Value: "b"},
+		":v":   &types.AttributeValueMemberS{Value: "value"},
+	}
+	names := map[string]string{"#n": "name"}
+
+	testCases := []struct {
+		expr     string
+		expected bool
+	}{
+		{expr: "#n = :s", expected: true},
+		{expr: "#n <> :s", expected: false},
+		{expr: "missing <> :s", expected: true},
+		{expr: "missing = :s", expected: false},
+		{expr: "count BETWEEN :n1 AND :n5", expected: true},
+		{expr: "count IN (:n1, :n5)", expected: false},
+		{expr: "begins_with(#n, :pfx)", expected: true},
+		{expr: "contains(tags, :tag)", expected: true},
+		{expr: "attribute_exists(nested.field) AND nested.field = :v", expected: true},
+		{expr: "attribute_not_exists(list[1])", expected: true},
+		{expr: "size(tags) > :n1", expected: true},
+		{expr: "NOT (count > :n1 OR #n = :s)", expected: false},
+		{expr: "count < :n1 OR (count > :n1 AND attribute_type(list, :s))", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expr, func(t *testing.T) {
+			r := require.New(t)
+
+			cond, err := parseCondition(testCase.expr, names, values)
+			r.NoError(err)
+			result, err := cond.eval(testExpressionItem())
+			r.NoError(err)
+			r.Equal(testCase.expected, result)
+		})
+	}
+}
+
+func TestConditionErrors(t *testing.T) {
+	for _, expr := range []string{
+		"#missing = :s",
+		"name = :missing",
+		"name =",
+		"name = :s extra",
+		"unknown_function(name)",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := parseCondition(expr, nil, map[string]types.AttributeValue{
+				":s": &types.AttributeValueMemberS{Value: "alice"},
+			})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	r := require.New(t)
+
+	actions, err := parseUpdate(
+		"SET count = count + :n, list = list_append(list, :l), created = if_not_exists(created, :n) "+
+			"REMOVE nested.field ADD tags :tags DELETE tags :old",
+		nil,
+		map[string]types.AttributeValue{
+			":n":    &types.AttributeValueMemberN{Value: "2"},
+			":l":    &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberN{Value: "2"}}},
+			":tags": &types.AttributeValueMemberSS{Value: []string{"c"}},
+			":old":  &types.AttributeValueMemberSS{Value: []string{"a"}},
+		},
+	)
+	r.NoError(err)
+
+	original := testExpressionItem()
+	updated, err := applyUpdate(original, actions)
+	r.NoError(err)
+
+	r.Equal(&types.AttributeValueMemberN{Value: "5"}, updated["count"])
+	r.Equal(&types.AttributeValueMemberN{Value: "2"}, updated["created"])
+	r.Len(updated["list"].(*types.AttributeValueMemberL).Value, 2)
+	r.Empty(updated["nested"].(*types.AttributeValueMemberM).Value)
+	r.ElementsMatch([]string{"b", "c"}, updated["tags"].(*types.AttributeValueMemberSS).Value)
+	r.True(equalValues(original["count"], &types.AttributeValueMemberN{Value: "3"}), "the original item must not change")
+
+	_, err = parseUpdate("SET a = :n SET b = :n", nil, map[string]types.AttributeValue{
+		":n": &types.AttributeValueMemberN{Value: "1"},
+	})
+	r.Error(err)
+}
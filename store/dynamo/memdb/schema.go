@@ -0,0 +1,324 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// keySchemaNames returns the partition and the sort key names of a key schema.
+func keySchemaNames(keySchema []types.KeySchemaElement) (partitionKey, sortKey string, err error) {
+	for _, elem := range keySchema {
+		if elem.AttributeName == nil {
+			return "", "", validationError("key schema element without attribute name")
+		}
+		switch elem.KeyType {
+		case types.KeyTypeHash:
+			partitionKey = *elem.AttributeName
+		case types.KeyTypeRange:
+			sortKey = *elem.AttributeName
+		default:
+			return "", "", validationError("invalid key type: %s", elem.KeyType)
+		}
+	}
+	if len(partitionKey) == 0 || len(keySchema) > 2 {
+		return "", "", validationError("invalid key schema")
+	}
+	return partitionKey, sortKey, nil
+}
+
+func makeKeySchema(partitionKey, sortKey string) []types.KeySchemaElement {
+	keySchema := []types.KeySchemaElement{
+		{AttributeName: strPtr(partitionKey), KeyType: types.KeyTypeHash},
+	}
+	if len(sortKey) > 0 {
+		keySchema = append(keySchema, types.KeySchemaElement{AttributeName: strPtr(sortKey), KeyType: types.KeyTypeRange})
+	}
+	return keySchema
+}
+
+// setAttributeTypes validates that the key attributes are defined and sets their types.
+func (t *table) setAttributeTypes(definitions []types.AttributeDefinition, names ...string) error {
+	defined := make(map[string]types.ScalarAttributeType)
+	for _, definition := range definitions {
+		if definition.AttributeName != nil {
+			defined[*definition.AttributeName] = definition.AttributeType
+		}
+	}
+	for _, name := range nonEmpty(names...) {
+		attributeType, ok := defined[name]
+		if !ok {
+			if _, known := t.attributeTypes[name]; known {
+				continue
+			}
+			return validationError("the key attribute %s is not defined in the attribute definitions", name)
+		}
+		if known, ok := t.attributeTypes[name]; ok && known != attributeType {
+			return validationError("conflicting attribute types for %s", name)
+		}
+		t.attributeTypes[name] = attributeType
+	}
+	return nil
+}
+
+func (t *table) hasIndex(name string) bool {
+	for _, idx := range t.def.Indexes {
+		if idx.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *table) describe() *types.TableDescription {
+	itemCount := int64(len(t.items))
+	desc := &types.TableDescription{
+		TableName:          strPtr(t.def.Name),
+		TableArn:           strPtr(fmt.Sprintf("arn:aws:dynamodb:local:000000000000:table/%s", t.def.Name)),
+		TableStatus:        types.TableStatusActive,
+		KeySchema:          makeKeySchema(t.def.PartitionKey, t.def.SortKey),
+		ItemCount:          &itemCount,
+		CreationDateTime:   &t.createdAt,
+		BillingModeSummary: &types.BillingModeSummary{BillingMode: t.billingMode},
+	}
+	if t.throughput != nil {
+		desc.ProvisionedThroughput = &types.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  t.throughput.ReadCapacityUnits,
+			WriteCapacityUnits: t.throughput.WriteCapacityUnits,
+		}
+	}
+	for _, name := range sortedNames(t.attributeTypes) {
+		desc.AttributeDefinitions = append(desc.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: strPtr(name),
+			AttributeType: t.attributeTypes[name],
+		})
+	}
+	for _, idx := range t.def.Indexes {
+		projection := &types.Projection{ProjectionType: types.ProjectionTypeAll}
+		if idx.Local {
+			desc.LocalSecondaryIndexes = append(desc.LocalSecondaryIndexes, types.LocalSecondaryIndexDescription{
+				IndexName:  strPtr(idx.Name),
+				KeySchema:  makeKeySchema(idx.PartitionKey, idx.SortKey),
+				Projection: projection,
+			})
+			continue
+		}
+		desc.GlobalSecondaryIndexes = append(desc.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   strPtr(idx.Name),
+			IndexStatus: types.IndexStatusActive,
+			KeySchema:   makeKeySchema(idx.PartitionKey, idx.SortKey),
+			Projection:  projection,
+		})
+	}
+	return desc
+}
+
+func (c *Client) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if params.TableName == nil {
+		return nil, validationError("table name is required")
+	}
+	if _, ok := c.tables[*params.TableName]; ok {
+		return nil, &types.ResourceInUseException{
+			Message: strPtr(fmt.Sprintf("table already exists: %s", *params.TableName)),
+		}
+	}
+	partitionKey, sortKey, err := keySchemaNames(params.KeySchema)
+	if err != nil {
+		return nil, err
+	}
+	def := TableDefinition{Name: *params.TableName, PartitionKey: partitionKey, SortKey: sortKey}
+	for _, gsi := range params.GlobalSecondaryIndexes {
+		idxPartitionKey, idxSortKey, err := keySchemaNames(gsi.KeySchema)
+		if err != nil {
+			return nil, err
+		}
+		def.Indexes = append(def.Indexes, Index{
+			Name: *gsi.IndexName, PartitionKey: idxPartitionKey, SortKey: idxSortKey,
+		})
+	}
+	for _, lsi := range params.LocalSecondaryIndexes {
+		idxPartitionKey, idxSortKey, err := keySchemaNames(lsi.KeySchema)
+		if err != nil {
+			return nil, err
+		}
+		if idxPartitionKey != partitionKey || len(sortKey) == 0 {
+			return nil, validationError("local secondary index %s must use the partition key of a table with a sort key", *lsi.IndexName)
+		}
+		def.Indexes = append(def.Indexes, Index{
+			Name: *lsi.IndexName, PartitionKey: idxPartitionKey, SortKey: idxSortKey, Local: true,
+		})
+	}
+
+	t := newTable(def)
+	t.attributeTypes = make(map[string]types.ScalarAttributeType)
+	names := []string{partitionKey, sortKey}
+	for _, idx := range def.Indexes {
+		names = append(names, idx.PartitionKey, idx.SortKey)
+	}
+	if err := t.setAttributeTypes(params.AttributeDefinitions, names...); err != nil {
+		return nil, err
+	}
+	if err := t.setBillingMode(params.BillingMode, params.ProvisionedThroughput); err != nil {
+		return nil, err
+	}
+	c.tables[def.Name] = t
+	return &dynamodb.CreateTableOutput{TableDescription: t.describe()}, nil
+}
+
+func (t *table) setBillingMode(billingMode types.BillingMode, throughput *types.ProvisionedThroughput) error {
+	switch billingMode {
+	case types.BillingModePayPerRequest:
+		if throughput != nil {
+			return validationError("provisioned throughput cannot be specified with the PAY_PER_REQUEST billing mode")
+		}
+		t.throughput = nil
+	case types.BillingModeProvisioned, "":
+		if throughput == nil {
+			if billingMode == "" && t.billingMode == types.BillingModePayPerRequest {
+				return nil
+			}
+			if t.throughput == nil {
+				return validationError("provisioned throughput is required with the PROVISIONED billing mode")
+			}
+			throughput = t.throughput
+		}
+		billingMode = types.BillingModeProvisioned
+		t.throughput = throughput
+	default:
+		return validationError("invalid billing mode: %s", billingMode)
+	}
+	t.billingMode = billingMode
+	return nil
+}
+
+func (c *Client) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTableOutput{Table: t.describe()}, nil
+}
+
+func (c *Client) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(params.GlobalSecondaryIndexUpdates) > 1 {
+		return nil, validationError("only one global secondary index can be created or deleted per update")
+	}
+	for _, update := range params.GlobalSecondaryIndexUpdates {
+		switch {
+		case update.Create != nil:
+			name := *update.Create.IndexName
+			if t.hasIndex(name) {
+				return nil, validationError("index already exists: %s", name)
+			}
+			partitionKey, sortKey, err := keySchemaNames(update.Create.KeySchema)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.setAttributeTypes(params.AttributeDefinitions, partitionKey, sortKey); err != nil {
+				return nil, err
+			}
+			t.def.Indexes = append(t.def.Indexes, Index{Name: name, PartitionKey: partitionKey, SortKey: sortKey})
+		case update.Delete != nil:
+			name := *update.Delete.IndexName
+			var indexes []Index
+			for _, idx := range t.def.Indexes {
+				if idx.Name != name || idx.Local {
+					indexes = append(indexes, idx)
+				}
+			}
+			if len(indexes) == len(t.def.Indexes) {
+				return nil, &types.ResourceNotFoundException{Message: strPtr(fmt.Sprintf("index not found: %s", name))}
+			}
+			t.def.Indexes = indexes
+		}
+	}
+	if len(params.BillingMode) > 0 || params.ProvisionedThroughput != nil {
+		if err := t.setBillingMode(params.BillingMode, params.ProvisionedThroughput); err != nil {
+			return nil, err
+		}
+	}
+	return &dynamodb.UpdateTableOutput{TableDescription: t.describe()}, nil
+}
+
+func (c *Client) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	desc := &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	if len(t.ttlAttribute) > 0 {
+		desc.TimeToLiveStatus = types.TimeToLiveStatusEnabled
+		desc.AttributeName = strPtr(t.ttlAttribute)
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: desc}, nil
+}
+
+// UpdateTimeToLive updates the time to live setting of the table. The expired items are not deleted.
+func (c *Client) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	spec := params.TimeToLiveSpecification
+	if spec == nil || spec.AttributeName == nil || spec.Enabled == nil {
+		return nil, validationError("time to live specification is required")
+	}
+	if *spec.Enabled {
+		if len(t.ttlAttribute) > 0 {
+			return nil, validationError("time to live is already enabled")
+		}
+		t.ttlAttribute = *spec.AttributeName
+	} else {
+		if t.ttlAttribute != *spec.AttributeName {
+			return nil, validationError("time to live is not enabled for %s", *spec.AttributeName)
+		}
+		t.ttlAttribute = ""
+	}
+	return &dynamodb.UpdateTimeToLiveOutput{TimeToLiveSpecification: spec}, nil
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
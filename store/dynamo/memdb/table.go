@@ -0,0 +1,323 @@
+package memdb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TableDefinition defines the keys and the indexes of a table.
+type TableDefinition struct {
+	Name         string
+	PartitionKey string
+	// SortKey is empty if the table has no sort key.
+	SortKey string
+	Indexes []Index
+}
+
+// Index defines a global or a local secondary index. All attributes are projected to the indexes.
+type Index struct {
+	Name         string
+	PartitionKey string
+	// SortKey is empty if the index has no sort key.
+	SortKey string
+	// Local is true for the local secondary indexes.
+	Local bool
+}
+
+type table struct {
+	def   TableDefinition
+	items map[string]item
+
+	// attributeTypes contains the types of the key attributes of the table and the indexes.
+	attributeTypes map[string]types.ScalarAttributeType
+	billingMode    types.BillingMode
+	throughput     *types.ProvisionedThroughput
+	ttlAttribute   string
+	createdAt      time.Time
+}
+
+// newTable creates a table which has string key attributes.
+func newTable(def TableDefinition) *table {
+	t := &table{
+		def:            def,
+		items:          make(map[string]item),
+		attributeTypes: make(map[string]types.ScalarAttributeType),
+		billingMode:    types.BillingModePayPerRequest,
+		createdAt:      time.Now(),
+	}
+	for _, name := range t.keyNames() {
+		t.attributeTypes[name] = types.ScalarAttributeTypeS
+	}
+	for _, idx := range def.Indexes {
+		for _, name := range nonEmpty(idx.PartitionKey, idx.SortKey) {
+			t.attributeTypes[name] = types.ScalarAttributeTypeS
+		}
+	}
+	return t
+}
+
+func (t *table) keyNames() []string {
+	return nonEmpty(t.def.PartitionKey, t.def.SortKey)
+}
+
+func nonEmpty(names ...string) []string {
+	var result []string
+	for _, name := range names {
+		if len(name) > 0 {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// itemKey validates the key attributes of an item and returns the storage key.
+func (t *table) itemKey(it item) (string, error) {
+	for _, name := range t.keyNames() {
+		value, ok := it[name]
+		if !ok {
+			return "", validationError("one of the required keys was not given a value: %s", name)
+		}
+		if attributeType, ok := t.attributeTypes[name]; ok && valueType(value) != string(attributeType) {
+			return "", validationError("type mismatch for the key attribute %s: expected %s, got %s", name, attributeType, valueType(value))
+		}
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			if len(v.Value) == 0 {
+				return "", validationError("one or more parameter values are not valid: the key attribute %s is an empty string", name)
+			}
+		case *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		default:
+			return "", validationError("invalid type for the key attribute %s: %s", name, valueType(value))
+		}
+	}
+	return keyString(it, t.keyNames()...), nil
+}
+
+// lookupKey validates a key used for reading or deleting an item and returns the storage key.
+func (t *table) lookupKey(key item) (string, error) {
+	if len(key) != len(t.keyNames()) {
+		return "", validationError("the provided key element does not match the schema")
+	}
+	return t.itemKey(key)
+}
+
+func (t *table) extractKey(it item, idx *Index) item {
+	names := t.keyNames()
+	if idx != nil {
+		names = append(names, nonEmpty(idx.PartitionKey, idx.SortKey)...)
+	}
+	key := make(item)
+	for _, name := range names {
+		if value, ok := it[name]; ok {
+			key[name] = copyValue(value)
+		}
+	}
+	return key
+}
+
+func (t *table) index(name *string) (*Index, error) {
+	if name == nil {
+		return nil, nil
+	}
+	for i := range t.def.Indexes {
+		if t.def.Indexes[i].Name == *name {
+			return &t.def.Indexes[i], nil
+		}
+	}
+	return nil, validationError("the table does not have the specified index: %s", *name)
+}
+
+// orderNames returns the attribute names which determine the order of the items.
+func (t *table) orderNames(idx *Index) []string {
+	if idx == nil {
+		return t.keyNames()
+	}
+	names := nonEmpty(idx.PartitionKey, idx.SortKey)
+	for _, name := range t.keyNames() {
+		if name != idx.PartitionKey && name != idx.SortKey {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sortedItems returns the items which have the index keys in the key order.
+func (t *table) sortedItems(idx *Index) []item {
+	orderNames := t.orderNames(idx)
+	var items []item
+	for _, it := range t.items {
+		if idx != nil && !hasAttributes(it, nonEmpty(idx.PartitionKey, idx.SortKey)...) {
+			continue
+		}
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return compareKeys(items[i], items[j], orderNames) < 0
+	})
+	return items
+}
+
+func hasAttributes(it item, names ...string) bool {
+	for _, name := range names {
+		if _, ok := it[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compareKeys(a, b item, names []string) int {
+	for _, name := range names {
+		av, bv := a[name], b[name]
+		cmp, ok := compareValues(av, bv)
+		if !ok {
+			cmp = strings.Compare(valueType(av), valueType(bv))
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+func segmentOf(it item, keyName string, totalSegments int32) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(keyString(it, keyName)))
+	return int32(h.Sum32() % uint32(totalSegments))
+}
+
+// readRequest contains the common parameters of the queries and the scans.
+type readRequest struct {
+	indexName         *string
+	keyCondition      *string
+	filter            *string
+	projection        *string
+	names             map[string]string
+	values            map[string]types.AttributeValue
+	exclusiveStartKey item
+	limit             *int32
+	descending        bool
+	segment           *int32
+	totalSegments     *int32
+	countOnly         bool
+}
+
+type readResult struct {
+	items            []item
+	count            int32
+	scannedCount     int32
+	lastEvaluatedKey item
+}
+
+func (t *table) read(req *readRequest) (*readResult, error) {
+	idx, err := t.index(req.indexName)
+	if err != nil {
+		return nil, err
+	}
+	var keyCond, filter condition
+	if req.keyCondition != nil {
+		if keyCond, err = parseCondition(*req.keyCondition, req.names, req.values); err != nil {
+			return nil, validationError("invalid KeyConditionExpression: %v", err)
+		}
+	}
+	if req.filter != nil {
+		if filter, err = parseCondition(*req.filter, req.names, req.values); err != nil {
+			return nil, validationError("invalid FilterExpression: %v", err)
+		}
+	}
+	var projection [][]pathElem
+	if req.projection != nil {
+		if projection, err = parseProjection(*req.projection, req.names); err != nil {
+			return nil, validationError("invalid ProjectionExpression: %v", err)
+		}
+	}
+	if req.limit != nil && *req.limit <= 0 {
+		return nil, validationError("limit must be greater than or equal to 1")
+	}
+	if req.segment != nil || req.totalSegments != nil {
+		if req.segment == nil || req.totalSegments == nil || *req.totalSegments <= 0 || *req.segment < 0 || *req.segment >= *req.totalSegments {
+			return nil, validationError("invalid Segment and TotalSegments")
+		}
+	}
+
+	items := t.sortedItems(idx)
+	if req.descending {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	orderNames := t.orderNames(idx)
+	result := &readResult{}
+	for i, it := range items {
+		if req.exclusiveStartKey != nil {
+			cmp := compareKeys(it, req.exclusiveStartKey, orderNames)
+			if (!req.descending && cmp <= 0) || (req.descending && cmp >= 0) {
+				continue
+			}
+		}
+		if req.segment != nil && segmentOf(it, t.def.PartitionKey, *req.totalSegments) != *req.segment {
+			continue
+		}
+		if keyCond != nil {
+			ok, err := keyCond.eval(it)
+			if err != nil {
+				return nil, validationError("invalid KeyConditionExpression: %v", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		result.scannedCount++
+		matches := true
+		if filter != nil {
+			if matches, err = filter.eval(it); err != nil {
+				return nil, validationError("invalid FilterExpression: %v", err)
+			}
+		}
+		if matches {
+			result.count++
+			if !req.countOnly {
+				if projection != nil {
+					result.items = append(result.items, projectItem(it, projection))
+				} else {
+					result.items = append(result.items, copyItem(it))
+				}
+			}
+		}
+		if req.limit != nil && result.scannedCount >= *req.limit {
+			if hasMore(items[i+1:], req, keyCond, t.def.PartitionKey) {
+				result.lastEvaluatedKey = t.extractKey(it, idx)
+			}
+			break
+		}
+	}
+	return result, nil
+}
+
+// hasMore tells if there are more items to read after reaching the limit.
+func hasMore(items []item, req *readRequest, keyCond condition, partitionKey string) bool {
+	for _, it := range items {
+		if req.segment != nil && segmentOf(it, partitionKey, *req.totalSegments) != *req.segment {
+			continue
+		}
+		if keyCond == nil {
+			return true
+		}
+		if ok, _ := keyCond.eval(it); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func tableNotFound(name string) error {
+	return &types.ResourceNotFoundException{
+		Message: strPtr(fmt.Sprintf("requested resource not found: table %s does not exist", name)),
+	}
+}
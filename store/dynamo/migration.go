@@ -0,0 +1,156 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const backfillPageSize = 100
+
+// ErrMigrationConflict is returned when another migrator applies the same migration at the same time.
+var ErrMigrationConflict = errors.New("migration conflict")
+
+// Migration is a versioned change of the data. The migrations should be idempotent since a
+// migration can run again if the migrator fails before recording it.
+type Migration struct {
+	// Version is a positive number which orders the migrations.
+	Version     int64
+	Description string
+	Migrate     func(ctx context.Context) error
+}
+
+// migrationState is the latest applied migration of a migration set.
+type migrationState struct {
+	Name           string    `dynamodbav:"name"`
+	AppliedVersion int64     `dynamodbav:"appliedVersion"`
+	Description    string    `dynamodbav:"description"`
+	AppliedAt      time.Time `dynamodbav:"appliedAt"`
+}
+
+func (migrationState) GetPartitionKeyName() string {
+	return "name"
+}
+
+func (migrationState) GetSortKeyName() string {
+	return ""
+}
+
+// Migrator runs migrations and records the applied versions in a state table.
+type Migrator struct {
+	client aws.DynamoDBClient
+	states Store[migrationState]
+}
+
+// NewMigrator creates a new migrator which records the applied versions in the given table.
+// The table is created if it does not exist.
+func NewMigrator(client aws.DynamoDBClient, stateTableName string) *Migrator {
+	return &Migrator{
+		client: client,
+		states: NewStore[migrationState](client, stateTableName),
+	}
+}
+
+// Run applies the migrations of the named migration set which have greater versions than
+// the latest applied version, in the version order. It stops at the first failing migration.
+func (m *Migrator) Run(ctx context.Context, name string, migrations ...Migration) error {
+	migrations, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+	if err := EnsureTable(ctx, m.client, NewTableSchema[migrationState](m.states.TableName())); err != nil {
+		return err
+	}
+	var appliedVersion int64
+	state, err := m.states.Get(ctx, name)
+	switch {
+	case err == nil:
+		appliedVersion = state.AppliedVersion
+	case !errors.Is(err, ErrNotFound):
+		return fmt.Errorf("failed to get the state of migrations %s: %w", name, err)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= appliedVersion {
+			continue
+		}
+		if err := migration.Migrate(ctx); err != nil {
+			return fmt.Errorf("migration %d of %s failed: %w", migration.Version, name, err)
+		}
+		err := m.states.Put(ctx, &migrationState{
+			Name:           name,
+			AppliedVersion: migration.Version,
+			Description:    migration.Description,
+			AppliedAt:      time.Now().UTC(),
+		}, ConditionExpression{
+			Expression: "attribute_not_exists(appliedVersion) OR appliedVersion = :prev",
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":prev": &types.AttributeValueMemberN{Value: strconv.FormatInt(appliedVersion, 10)},
+			},
+		})
+		if errors.Is(err, ErrConditionFailed) {
+			return fmt.Errorf("%w: migration %d of %s was applied by another migrator", ErrMigrationConflict, migration.Version, name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d of %s: %w", migration.Version, name, err)
+		}
+		appliedVersion = migration.Version
+	}
+	return nil
+}
+
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("invalid migration version: %d", migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version: %d", migration.Version)
+		}
+		if migration.Migrate == nil {
+			return nil, fmt.Errorf("migration %d has no migrate function", migration.Version)
+		}
+	}
+	return sorted, nil
+}
+
+// Backfill scans all items and puts the items which are changed by the given function. It returns
+// the number of the changed items. Versioned items are put with the version checks so the items
+// which are changed concurrently cause a version conflict.
+func Backfill[I Item](ctx context.Context, store Store[I], change func(item *I) bool) (int, error) {
+	var (
+		changed   int
+		pageToken string
+	)
+	for {
+		page, err := store.ScanPage(ctx, backfillPageSize, pageToken)
+		if err != nil {
+			return changed, err
+		}
+		for _, item := range page.Items {
+			if !change(item) {
+				continue
+			}
+			if err := store.Put(ctx, item); err != nil {
+				return changed, fmt.Errorf("failed to backfill: %w", err)
+			}
+			changed++
+		}
+		if len(page.NextPageToken) == 0 {
+			return changed, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
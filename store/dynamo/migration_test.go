@@ -0,0 +1,90 @@
+package dynamo_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMigrationsTableName = "test-migrations"
+
+func TestMigrator(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+	migrator := dynamo.NewMigrator(client, testMigrationsTableName)
+
+	var applied []int64
+	migration := func(version int64) dynamo.Migration {
+		return dynamo.Migration{
+			Version: version,
+			Migrate: func(ctx context.Context) error {
+				applied = append(applied, version)
+				return nil
+			},
+		}
+	}
+
+	r.NoError(migrator.Run(ctx, "test", migration(2), migration(1)))
+	r.Equal([]int64{1, 2}, applied)
+
+	// only the new migrations run
+	applied = nil
+	r.NoError(migrator.Run(ctx, "test", migration(1), migration(2), migration(3)))
+	r.Equal([]int64{3}, applied)
+
+	// the migration sets are independent
+	applied = nil
+	r.NoError(migrator.Run(ctx, "other", migration(1)))
+	r.Equal([]int64{1}, applied)
+
+	// a failing migration stops the run and runs again next time
+	applied = nil
+	testErr := errors.New("test error")
+	failing := dynamo.Migration{Version: 5, Migrate: func(ctx context.Context) error { return testErr }}
+	r.ErrorIs(migrator.Run(ctx, "test", migration(4), failing, migration(6)), testErr)
+	r.Equal([]int64{4}, applied)
+
+	applied = nil
+	r.NoError(migrator.Run(ctx, "test", migration(4), migration(5), migration(6)))
+	r.Equal([]int64{5, 6}, applied)
+
+	r.Error(migrator.Run(ctx, "test", migration(7), migration(7)))
+	r.Error(migrator.Run(ctx, "test", migration(0)))
+}
+
+func TestBackfill(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+	store := memdb.NewStore[testVersionedItem](client, testTableName)
+
+	for i := 0; i < 250; i++ {
+		r.NoError(store.Put(ctx, &testVersionedItem{Pkey: strconv.Itoa(i)}))
+	}
+
+	isEven := func(item *testVersionedItem) bool {
+		i, _ := strconv.Atoi(item.Pkey)
+		return i%2 == 0
+	}
+	changed, err := dynamo.Backfill(ctx, store, isEven)
+	r.NoError(err)
+	r.Equal(125, changed)
+
+	items, err := store.Scan(ctx)
+	r.NoError(err)
+	r.Len(items, 250)
+	for _, item := range items {
+		if isEven(item) {
+			r.Equal(int64(2), item.Version)
+		} else {
+			r.Equal(int64(1), item.Version)
+		}
+	}
+}
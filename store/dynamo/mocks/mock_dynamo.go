@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	types "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	dynamo "github.com/forta-network/core-go/store/dynamo"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -121,6 +122,35 @@ func (mr *MockCacheMockRecorder[I]) GetQuery(ctx, queryKey interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuery", reflect.TypeOf((*MockCache[I])(nil).GetQuery), ctx, queryKey)
 }
 
+// Invalidate mocks base method.
+func (m *MockCache[I]) Invalidate(ctx context.Context, partitionKey string, sortKey ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, partitionKey}
+	for _, a := range sortKey {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Invalidate", varargs...)
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockCacheMockRecorder[I]) Invalidate(ctx, partitionKey interface{}, sortKey ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, partitionKey}, sortKey...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockCache[I])(nil).Invalidate), varargs...)
+}
+
+// InvalidateQueries mocks base method.
+func (m *MockCache[I]) InvalidateQueries(ctx context.Context, queryKeyPrefix string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateQueries", ctx, queryKeyPrefix)
+}
+
+// InvalidateQueries indicates an expected call of InvalidateQueries.
+func (mr *MockCacheMockRecorder[I]) InvalidateQueries(ctx, queryKeyPrefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateQueries", reflect.TypeOf((*MockCache[I])(nil).InvalidateQueries), ctx, queryKeyPrefix)
+}
+
 // Put mocks base method.
 func (m *MockCache[I]) Put(ctx context.Context, item *I, partitionKey string, sortKey ...string) {
 	m.ctrl.T.Helper()
@@ -150,6 +180,46 @@ func (mr *MockCacheMockRecorder[I]) PutQuery(ctx, queryKey, items interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutQuery", reflect.TypeOf((*MockCache[I])(nil).PutQuery), ctx, queryKey, items)
 }
 
+// MockNegativeCache is a mock of NegativeCache interface.
+type MockNegativeCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockNegativeCacheMockRecorder
+}
+
+// MockNegativeCacheMockRecorder is the mock recorder for MockNegativeCache.
+type MockNegativeCacheMockRecorder struct {
+	mock *MockNegativeCache
+}
+
+// NewMockNegativeCache creates a new mock instance.
+func NewMockNegativeCache(ctrl *gomock.Controller) *MockNegativeCache {
+	mock := &MockNegativeCache{ctrl: ctrl}
+	mock.recorder = &MockNegativeCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNegativeCache) EXPECT() *MockNegativeCacheMockRecorder {
+	return m.recorder
+}
+
+// PutNotFound mocks base method.
+func (m *MockNegativeCache) PutNotFound(ctx context.Context, partitionKey string, sortKey ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, partitionKey}
+	for _, a := range sortKey {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "PutNotFound", varargs...)
+}
+
+// PutNotFound indicates an expected call of PutNotFound.
+func (mr *MockNegativeCacheMockRecorder) PutNotFound(ctx, partitionKey interface{}, sortKey ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, partitionKey}, sortKey...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutNotFound", reflect.TypeOf((*MockNegativeCache)(nil).PutNotFound), varargs...)
+}
+
 // MockStore is a mock of Store interface.
 type MockStore[I dynamo.Item] struct {
 	ctrl     *gomock.Controller
@@ -173,6 +243,64 @@ func (m *MockStore[I]) EXPECT() *MockStoreMockRecorder[I] {
 	return m.recorder
 }
 
+// BatchDelete mocks base method.
+func (m *MockStore[I]) BatchDelete(ctx context.Context, keys []dynamo.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchDelete", ctx, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchDelete indicates an expected call of BatchDelete.
+func (mr *MockStoreMockRecorder[I]) BatchDelete(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDelete", reflect.TypeOf((*MockStore[I])(nil).BatchDelete), ctx, keys)
+}
+
+// BatchGet mocks base method.
+func (m *MockStore[I]) BatchGet(ctx context.Context, keys []dynamo.Key) ([]*I, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchGet", ctx, keys)
+	ret0, _ := ret[0].([]*I)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchGet indicates an expected call of BatchGet.
+func (mr *MockStoreMockRecorder[I]) BatchGet(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGet", reflect.TypeOf((*MockStore[I])(nil).BatchGet), ctx, keys)
+}
+
+// BatchPut mocks base method.
+func (m *MockStore[I]) BatchPut(ctx context.Context, items []*I) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchPut", ctx, items)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchPut indicates an expected call of BatchPut.
+func (mr *MockStoreMockRecorder[I]) BatchPut(ctx, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchPut", reflect.TypeOf((*MockStore[I])(nil).BatchPut), ctx, items)
+}
+
+// DecodeItem mocks base method.
+func (m *MockStore[I]) DecodeItem(ctx context.Context, attributes map[string]types.AttributeValue) (*I, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecodeItem", ctx, attributes)
+	ret0, _ := ret[0].(*I)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecodeItem indicates an expected call of DecodeItem.
+func (mr *MockStoreMockRecorder[I]) DecodeItem(ctx, attributes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecodeItem", reflect.TypeOf((*MockStore[I])(nil).DecodeItem), ctx, attributes)
+}
+
 // Delete mocks base method.
 func (m *MockStore[I]) Delete(ctx context.Context, item *I, partitionKey string, sortKey ...string) error {
 	m.ctrl.T.Helper()
@@ -242,6 +370,51 @@ func (mr *MockStoreMockRecorder[I]) GetAllFromIndex(ctx, indexName, partitionKey
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFromIndex", reflect.TypeOf((*MockStore[I])(nil).GetAllFromIndex), ctx, indexName, partitionKeyName, partitionKeyVal)
 }
 
+// GetAllFromIndexPage mocks base method.
+func (m *MockStore[I]) GetAllFromIndexPage(ctx context.Context, indexName, partitionKeyName, partitionKeyVal string, pageSize int32, pageToken string) (*dynamo.Page[I], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllFromIndexPage", ctx, indexName, partitionKeyName, partitionKeyVal, pageSize, pageToken)
+	ret0, _ := ret[0].(*dynamo.Page[I])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllFromIndexPage indicates an expected call of GetAllFromIndexPage.
+func (mr *MockStoreMockRecorder[I]) GetAllFromIndexPage(ctx, indexName, partitionKeyName, partitionKeyVal, pageSize, pageToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFromIndexPage", reflect.TypeOf((*MockStore[I])(nil).GetAllFromIndexPage), ctx, indexName, partitionKeyName, partitionKeyVal, pageSize, pageToken)
+}
+
+// GetAllPage mocks base method.
+func (m *MockStore[I]) GetAllPage(ctx context.Context, partitionKey string, pageSize int32, pageToken string) (*dynamo.Page[I], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllPage", ctx, partitionKey, pageSize, pageToken)
+	ret0, _ := ret[0].(*dynamo.Page[I])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllPage indicates an expected call of GetAllPage.
+func (mr *MockStoreMockRecorder[I]) GetAllPage(ctx, partitionKey, pageSize, pageToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPage", reflect.TypeOf((*MockStore[I])(nil).GetAllPage), ctx, partitionKey, pageSize, pageToken)
+}
+
+// ParallelScan mocks base method.
+func (m *MockStore[I]) ParallelScan(ctx context.Context, segments int) ([]*I, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParallelScan", ctx, segments)
+	ret0, _ := ret[0].([]*I)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParallelScan indicates an expected call of ParallelScan.
+func (mr *MockStoreMockRecorder[I]) ParallelScan(ctx, segments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParallelScan", reflect.TypeOf((*MockStore[I])(nil).ParallelScan), ctx, segments)
+}
+
 // Put mocks base method.
 func (m *MockStore[I]) Put(ctx context.Context, item *I, conditionExpression ...dynamo.ConditionExpression) error {
 	m.ctrl.T.Helper()
@@ -261,6 +434,36 @@ func (mr *MockStoreMockRecorder[I]) Put(ctx, item interface{}, conditionExpressi
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockStore[I])(nil).Put), varargs...)
 }
 
+// Query mocks base method.
+func (m *MockStore[I]) Query(ctx context.Context, query *dynamo.Query) ([]*I, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, query)
+	ret0, _ := ret[0].([]*I)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockStoreMockRecorder[I]) Query(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockStore[I])(nil).Query), ctx, query)
+}
+
+// QueryPage mocks base method.
+func (m *MockStore[I]) QueryPage(ctx context.Context, query *dynamo.Query, pageSize int32, pageToken string) (*dynamo.Page[I], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryPage", ctx, query, pageSize, pageToken)
+	ret0, _ := ret[0].(*dynamo.Page[I])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryPage indicates an expected call of QueryPage.
+func (mr *MockStoreMockRecorder[I]) QueryPage(ctx, query, pageSize, pageToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryPage", reflect.TypeOf((*MockStore[I])(nil).QueryPage), ctx, query, pageSize, pageToken)
+}
+
 // Scan mocks base method.
 func (m *MockStore[I]) Scan(ctx context.Context) ([]*I, error) {
 	m.ctrl.T.Helper()
@@ -276,6 +479,21 @@ func (mr *MockStoreMockRecorder[I]) Scan(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockStore[I])(nil).Scan), ctx)
 }
 
+// ScanPage mocks base method.
+func (m *MockStore[I]) ScanPage(ctx context.Context, pageSize int32, pageToken string) (*dynamo.Page[I], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanPage", ctx, pageSize, pageToken)
+	ret0, _ := ret[0].(*dynamo.Page[I])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanPage indicates an expected call of ScanPage.
+func (mr *MockStoreMockRecorder[I]) ScanPage(ctx, pageSize, pageToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanPage", reflect.TypeOf((*MockStore[I])(nil).ScanPage), ctx, pageSize, pageToken)
+}
+
 // TableName mocks base method.
 func (m *MockStore[I]) TableName() string {
 	m.ctrl.T.Helper()
@@ -290,16 +508,79 @@ func (mr *MockStoreMockRecorder[I]) TableName() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableName", reflect.TypeOf((*MockStore[I])(nil).TableName))
 }
 
+// Update mocks base method.
+func (m *MockStore[I]) Update(ctx context.Context, key dynamo.Key, update *dynamo.Update, conditionExpression ...dynamo.ConditionExpression) (*I, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key, update}
+	for _, a := range conditionExpression {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Update", varargs...)
+	ret0, _ := ret[0].(*I)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockStoreMockRecorder[I]) Update(ctx, key, update interface{}, conditionExpression ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key, update}, conditionExpression...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStore[I])(nil).Update), varargs...)
+}
+
 // WithCache mocks base method.
-func (m *MockStore[I]) WithCache(cache dynamo.Cache[I]) dynamo.Store[I] {
+func (m *MockStore[I]) WithCache(cache dynamo.Cache[I], mode ...dynamo.CacheMode) dynamo.Store[I] {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithCache", cache)
+	varargs := []interface{}{cache}
+	for _, a := range mode {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithCache", varargs...)
 	ret0, _ := ret[0].(dynamo.Store[I])
 	return ret0
 }
 
 // WithCache indicates an expected call of WithCache.
-func (mr *MockStoreMockRecorder[I]) WithCache(cache interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder[I]) WithCache(cache interface{}, mode ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{cache}, mode...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithCache", reflect.TypeOf((*MockStore[I])(nil).WithCache), varargs...)
+}
+
+// MockItemDecoder is a mock of ItemDecoder interface.
+type MockItemDecoder[I dynamo.Item] struct {
+	ctrl     *gomock.Controller
+	recorder *MockItemDecoderMockRecorder[I]
+}
+
+// MockItemDecoderMockRecorder is the mock recorder for MockItemDecoder.
+type MockItemDecoderMockRecorder[I dynamo.Item] struct {
+	mock *MockItemDecoder[I]
+}
+
+// NewMockItemDecoder creates a new mock instance.
+func NewMockItemDecoder[I dynamo.Item](ctrl *gomock.Controller) *MockItemDecoder[I] {
+	mock := &MockItemDecoder[I]{ctrl: ctrl}
+	mock.recorder = &MockItemDecoderMockRecorder[I]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockItemDecoder[I]) EXPECT() *MockItemDecoderMockRecorder[I] {
+	return m.recorder
+}
+
+// DecodeItem mocks base method.
+func (m *MockItemDecoder[I]) DecodeItem(ctx context.Context, attributes map[string]types.AttributeValue) (*I, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecodeItem", ctx, attributes)
+	ret0, _ := ret[0].(*I)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecodeItem indicates an expected call of DecodeItem.
+func (mr *MockItemDecoderMockRecorder[I]) DecodeItem(ctx, attributes interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithCache", reflect.TypeOf((*MockStore[I])(nil).WithCache), cache)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecodeItem", reflect.TypeOf((*MockItemDecoder[I])(nil).DecodeItem), ctx, attributes)
 }
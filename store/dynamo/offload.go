@@ -0,0 +1,454 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/utils"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Offloading defaults
+const (
+	DefaultCompressThreshold = 32 * 1024
+	DefaultOffloadThreshold  = 128 * 1024
+	// maxItemSize leaves room under the 400 KB item size limit.
+	maxItemSize = 350 * 1024
+	// discardTimeout limits deleting the objects of a failed write.
+	discardTimeout = time.Second * 30
+)
+
+// Offloaded attributes are replaced by maps with one of these keys. The object keys are only
+// used if they have the object prefix of the item so that the user maps which look like the
+// offloaded attributes cannot point to the objects of the other items.
+const (
+	compressedAttributeKey = "@gzip"
+	offloadedAttributeKey  = "@s3"
+)
+
+// OffloadConfig configures offloading the large attributes.
+type OffloadConfig struct {
+	S3       aws.S3
+	Uploader aws.S3Uploader
+	Bucket   string
+	// KeyPrefix is prepended to the object keys.
+	KeyPrefix string
+	// CompressThreshold is the attribute size above which the attributes are compressed.
+	CompressThreshold int
+	// OffloadThreshold is the compressed attribute size above which the attributes are written to S3.
+	OffloadThreshold int
+}
+
+type offloader struct {
+	config    OffloadConfig
+	tableName string
+}
+
+// NewOffloadingStore creates a store which compresses the large attributes and writes them to S3
+// if they are still too large. The items are restored when they are read. Put deletes the objects
+// which are replaced, and Delete and BatchDelete delete all objects of the items. The compressed and
+// the offloaded attributes cannot be used in the conditions, the filters and the updates. The
+// transactions write the items without offloading.
+func NewOffloadingStore[I Item](client aws.DynamoDBClient, tableName string, config OffloadConfig) Store[I] {
+	return &store[I]{
+		client:    client,
+		tableName: tableName,
+		offloader: newOffloader(tableName, config),
+	}
+}
+
+func newOffloader(tableName string, config OffloadConfig) *offloader {
+	if config.CompressThreshold == 0 {
+		config.CompressThreshold = DefaultCompressThreshold
+	}
+	if config.OffloadThreshold == 0 {
+		config.OffloadThreshold = DefaultOffloadThreshold
+	}
+	return &offloader{config: config, tableName: tableName}
+}
+
+// objectPrefix returns the prefix of the objects of the item with the given key.
+func (o *offloader) objectPrefix(key map[string]types.AttributeValue, keyNames ...string) string {
+	h := sha256.New()
+	for _, name := range keyNames {
+		if av, ok := key[name]; ok {
+			h.Write([]byte(formatAttributeValue(av)))
+		}
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s%s/%s/", o.config.KeyPrefix, o.tableName, hex.EncodeToString(h.Sum(nil))[:32])
+}
+
+// offload compresses and uploads the large attributes of the item.
+func (o *offloader) offload(ctx context.Context, item map[string]types.AttributeValue, keyNames ...string) error {
+	isKey := make(map[string]bool)
+	for _, name := range keyNames {
+		isKey[name] = true
+	}
+	prefix := o.objectPrefix(item, keyNames...)
+
+	compressed := make(map[string][]byte)
+	for _, name := range sortedKeys(item) {
+		if isKey[name] {
+			continue
+		}
+		encoded, err := encodeAttributeValue(item[name])
+		if err != nil {
+			return err
+		}
+		if len(encoded) <= o.config.CompressThreshold {
+			continue
+		}
+		data, err := utils.GzipEncode(encoded)
+		if err != nil {
+			return err
+		}
+		if len(data) > o.config.OffloadThreshold {
+			if err := o.upload(ctx, item, prefix, name, data); err != nil {
+				return err
+			}
+			continue
+		}
+		compressed[name] = data
+		item[name] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			compressedAttributeKey: &types.AttributeValueMemberB{Value: data},
+		}}
+	}
+
+	// offload the largest compressed attributes until the item fits
+	for itemSize(item) > maxItemSize && len(compressed) > 0 {
+		var largest string
+		for name, data := range compressed {
+			if len(data) > len(compressed[largest]) || (len(data) == len(compressed[largest]) && name < largest) {
+				largest = name
+			}
+		}
+		if err := o.upload(ctx, item, prefix, largest, compressed[largest]); err != nil {
+			return err
+		}
+		delete(compressed, largest)
+	}
+	return nil
+}
+
+func (o *offloader) upload(ctx context.Context, item map[string]types.AttributeValue, prefix, name string, data []byte) error {
+	hash := sha256.Sum256(data)
+	key := fmt.Sprintf("%s%s/%s", prefix, hex.EncodeToString([]byte(name)), hex.EncodeToString(hash[:]))
+	_, err := o.config.Uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: &o.config.Bucket,
+		Key:    &key,
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to offload attribute %s: %w", name, err)
+	}
+	item[name] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		offloadedAttributeKey: &types.AttributeValueMemberS{Value: key},
+	}}
+	return nil
+}
+
+// restore replaces the compressed and the offloaded attributes with the original values.
+func (o *offloader) restore(ctx context.Context, item map[string]types.AttributeValue, keyNames ...string) error {
+	prefix := o.objectPrefix(item, keyNames...)
+	for name, av := range item {
+		var data []byte
+		switch v := pointerValue(av, prefix).(type) {
+		case *types.AttributeValueMemberB:
+			data = v.Value
+		case *types.AttributeValueMemberS:
+			res, err := o.config.S3.GetObject(ctx, &s3.GetObjectInput{
+				Bucket: &o.config.Bucket,
+				Key:    &v.Value,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get offloaded attribute %s: %w", name, err)
+			}
+			data, err = io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				return fmt.Errorf("failed to read offloaded attribute %s: %w", name, err)
+			}
+		default:
+			continue
+		}
+		decompressed, err := utils.GzipDecode(data)
+		if err != nil {
+			return fmt.Errorf("failed to decompress attribute %s: %w", name, err)
+		}
+		if item[name], err = decodeAttributeValue(decompressed); err != nil {
+			return fmt.Errorf("failed to decode attribute %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// pointerValue returns the compressed data or the object key if the attribute is compressed or
+// offloaded. The object key must have the object prefix of the item.
+func pointerValue(av types.AttributeValue, prefix string) types.AttributeValue {
+	m, ok := av.(*types.AttributeValueMemberM)
+	if !ok || len(m.Value) != 1 {
+		return nil
+	}
+	if data, ok := m.Value[compressedAttributeKey].(*types.AttributeValueMemberB); ok {
+		return data
+	}
+	if key, ok := m.Value[offloadedAttributeKey].(*types.AttributeValueMemberS); ok && strings.HasPrefix(key.Value, prefix) {
+		return key
+	}
+	return nil
+}
+
+// objectKeys returns the keys of the offloaded attributes of the item.
+func (o *offloader) objectKeys(item map[string]types.AttributeValue, keyNames ...string) map[string]bool {
+	prefix := o.objectPrefix(item, keyNames...)
+	keys := make(map[string]bool)
+	for _, av := range item {
+		if key, ok := pointerValue(av, prefix).(*types.AttributeValueMemberS); ok {
+			keys[key.Value] = true
+		}
+	}
+	return keys
+}
+
+// cleanUp deletes the objects of the old item which are not used by the new item.
+func (o *offloader) cleanUp(ctx context.Context, oldItem, newItem map[string]types.AttributeValue, keyNames ...string) error {
+	newKeys := o.objectKeys(newItem, keyNames...)
+	for _, key := range sortedObjectKeys(o.objectKeys(oldItem, keyNames...)) {
+		if !newKeys[key] {
+			if err := o.deleteObject(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteObjects deletes all objects of the item with the given key, including the orphaned ones.
+func (o *offloader) deleteObjects(ctx context.Context, key map[string]types.AttributeValue, keyNames ...string) error {
+	prefix := o.objectPrefix(key, keyNames...)
+	var continuationToken *string
+	for {
+		res, err := o.config.S3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            &o.config.Bucket,
+			Prefix:            &prefix,
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list offloaded objects: %w", err)
+		}
+		for _, object := range res.Contents {
+			if err := o.deleteObject(ctx, *object.Key); err != nil {
+				return err
+			}
+		}
+		if res.NextContinuationToken == nil {
+			return nil
+		}
+		continuationToken = res.NextContinuationToken
+	}
+}
+
+func (o *offloader) deleteObject(ctx context.Context, key string) error {
+	_, err := o.config.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &o.config.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete offloaded object %s: %w", key, err)
+	}
+	return nil
+}
+
+// itemSize estimates the size of the item the way DynamoDB calculates it.
+func itemSize(item map[string]types.AttributeValue) int {
+	var size int
+	for name, av := range item {
+		size += len(name) + attributeSize(av)
+	}
+	return size
+}
+
+func attributeSize(av types.AttributeValue) int {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return len(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberSS:
+		var size int
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		var size int
+		for _, n := range v.Value {
+			size += len(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		var size int
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, elem := range v.Value {
+			size += 1 + attributeSize(elem)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for name, elem := range v.Value {
+			size += 1 + len(name) + attributeSize(elem)
+		}
+		return size
+	default:
+		return 1
+	}
+}
+
+// encodedAttributeValue is the JSON representation of an attribute value.
+type encodedAttributeValue struct {
+	S    *string                           `json:"S,omitempty"`
+	N    *string                           `json:"N,omitempty"`
+	B    []byte                            `json:"B,omitempty"`
+	BOOL *bool                             `json:"BOOL,omitempty"`
+	NULL bool                              `json:"NULL,omitempty"`
+	SS   []string                          `json:"SS,omitempty"`
+	NS   []string                          `json:"NS,omitempty"`
+	BS   [][]byte                          `json:"BS,omitempty"`
+	L    []*encodedAttributeValue          `json:"L,omitempty"`
+	M    map[string]*encodedAttributeValue `json:"M,omitempty"`
+	// empty lists and maps are not omitted
+	EmptyL bool `json:"EL,omitempty"`
+	EmptyM bool `json:"EM,omitempty"`
+}
+
+func encodeAttributeValue(av types.AttributeValue) ([]byte, error) {
+	encoded, err := toEncodedAttributeValue(av)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+func toEncodedAttributeValue(av types.AttributeValue) (*encodedAttributeValue, error) {
+	encoded := &encodedAttributeValue{}
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		encoded.S = &v.Value
+	case *types.AttributeValueMemberN:
+		encoded.N = &v.Value
+	case *types.AttributeValueMemberB:
+		encoded.B = v.Value
+	case *types.AttributeValueMemberBOOL:
+		encoded.BOOL = &v.Value
+	case *types.AttributeValueMemberNULL:
+		encoded.NULL = true
+	case *types.AttributeValueMemberSS:
+		encoded.SS = v.Value
+	case *types.AttributeValueMemberNS:
+		encoded.NS = v.Value
+	case *types.AttributeValueMemberBS:
+		encoded.BS = v.Value
+	case *types.AttributeValueMemberL:
+		encoded.EmptyL = len(v.Value) == 0
+		for _, elem := range v.Value {
+			encodedElem, err := toEncodedAttributeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			encoded.L = append(encoded.L, encodedElem)
+		}
+	case *types.AttributeValueMemberM:
+		encoded.EmptyM = len(v.Value) == 0
+		encoded.M = make(map[string]*encodedAttributeValue, len(v.Value))
+		for name, elem := range v.Value {
+			encodedElem, err := toEncodedAttributeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			encoded.M[name] = encodedElem
+		}
+	default:
+		return nil, fmt.Errorf("unsupported attribute value type %T", av)
+	}
+	return encoded, nil
+}
+
+func decodeAttributeValue(data []byte) (types.AttributeValue, error) {
+	var encoded encodedAttributeValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	return fromEncodedAttributeValue(&encoded)
+}
+
+func fromEncodedAttributeValue(encoded *encodedAttributeValue) (types.AttributeValue, error) {
+	switch {
+	case encoded == nil:
+		return nil, errors.New("empty attribute value")
+	case encoded.S != nil:
+		return &types.AttributeValueMemberS{Value: *encoded.S}, nil
+	case encoded.N != nil:
+		return &types.AttributeValueMemberN{Value: *encoded.N}, nil
+	case encoded.B != nil:
+		return &types.AttributeValueMemberB{Value: encoded.B}, nil
+	case encoded.BOOL != nil:
+		return &types.AttributeValueMemberBOOL{Value: *encoded.BOOL}, nil
+	case encoded.NULL:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case encoded.SS != nil:
+		return &types.AttributeValueMemberSS{Value: encoded.SS}, nil
+	case encoded.NS != nil:
+		return &types.AttributeValueMemberNS{Value: encoded.NS}, nil
+	case encoded.BS != nil:
+		return &types.AttributeValueMemberBS{Value: encoded.BS}, nil
+	case encoded.L != nil || encoded.EmptyL:
+		list := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, len(encoded.L))}
+		for i, elem := range encoded.L {
+			var err error
+			if list.Value[i], err = fromEncodedAttributeValue(elem); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case encoded.M != nil || encoded.EmptyM:
+		m := &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue, len(encoded.M))}
+		for _, name := range sortedKeys(encoded.M) {
+			var err error
+			if m.Value[name], err = fromEncodedAttributeValue(encoded.M[name]); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	default:
+		return nil, errors.New("invalid attribute value")
+	}
+}
+
+func sortedObjectKeys(keys map[string]bool) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
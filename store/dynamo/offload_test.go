@@ -0,0 +1,354 @@
+package dynamo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testBucket = "test-bucket"
+
+type testLargeItem struct {
+	Pkey     string            `dynamodbav:"pkey"`
+	Skey     string            `dynamodbav:"skey"`
+	Small    string            `dynamodbav:"small"`
+	Text     string            `dynamodbav:"text"`
+	Random   []byte            `dynamodbav:"random"`
+	Manifest map[string]string `dynamodbav:"manifest"`
+}
+
+func (testLargeItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (testLargeItem) GetSortKeyName() string {
+	return "skey"
+}
+
+// testBucketObjects fakes a bucket with the S3 mocks.
+type testBucketObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newTestBucket(ctrl *gomock.Controller) (*testBucketObjects, *mock_aws.MockS3, *mock_aws.MockS3Uploader) {
+	bucket := &testBucketObjects{objects: make(map[string][]byte)}
+	s3Client := mock_aws.NewMockS3(ctrl)
+	uploader := mock_aws.NewMockS3Uploader(ctrl)
+
+	uploader.EXPECT().Upload(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.PutObjectInput, _ ...func(*manager.Uploader)) (*manager.UploadOutput, error) {
+			data, err := io.ReadAll(input.Body)
+			if err != nil {
+				return nil, err
+			}
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			bucket.objects[*input.Key] = data
+			return &manager.UploadOutput{Key: input.Key}, nil
+		}).AnyTimes()
+	s3Client.EXPECT().GetObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			data, ok := bucket.objects[*input.Key]
+			if !ok {
+				return nil, &s3types.NoSuchKey{}
+			}
+			return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+		}).AnyTimes()
+	s3Client.EXPECT().ListObjectsV2(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			res := &s3.ListObjectsV2Output{}
+			for _, key := range bucket.keys() {
+				if strings.HasPrefix(key, *input.Prefix) {
+					key := key
+					res.Contents = append(res.Contents, s3types.Object{Key: &key})
+				}
+			}
+			return res, nil
+		}).AnyTimes()
+	s3Client.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			delete(bucket.objects, *input.Key)
+			return &s3.DeleteObjectOutput{}, nil
+		}).AnyTimes()
+	return bucket, s3Client, uploader
+}
+
+func (bucket *testBucketObjects) keys() []string {
+	var keys []string
+	for key := range bucket.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (bucket *testBucketObjects) count() int {
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	return len(bucket.objects)
+}
+
+func randomBytes(size int) []byte {
+	b := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(b)
+	return b
+}
+
+func newTestOffloadingStore(t *testing.T) (*memdb.Client, *testBucketObjects, dynamo.Store[testLargeItem]) {
+	ctrl := gomock.NewController(t)
+	bucket, s3Client, uploader := newTestBucket(ctrl)
+	client := memdb.NewClient()
+	memdb.NewStore[testLargeItem](client, testTableName)
+	store := dynamo.NewOffloadingStore[testLargeItem](client, testTableName, dynamo.OffloadConfig{
+		S3:        s3Client,
+		Uploader:  uploader,
+		Bucket:    testBucket,
+		KeyPrefix: "offloaded/",
+	})
+	return client, bucket, store
+}
+
+func TestOffloadingStore(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client, bucket, store := newTestOffloadingStore(t)
+
+	item := &testLargeItem{
+		Pkey:     testPartitionKeyVal,
+		Skey:     testSortKeyVal,
+		Small:    "small",
+		Text:     strings.Repeat("compressible ", 50000),
+		Random:   randomBytes(200 * 1024),
+		Manifest: map[string]string{"name": "bot"},
+	}
+	r.NoError(store.Put(ctx, item))
+
+	// the text is compressed and the random bytes are offloaded
+	stored := client.Items(testTableName)[0]
+	r.Equal(&types.AttributeValueMemberS{Value: "small"}, stored["small"])
+	r.Contains(stored["text"].(*types.AttributeValueMemberM).Value, "@gzip")
+	r.Contains(stored["random"].(*types.AttributeValueMemberM).Value, "@s3")
+	r.Equal(1, bucket.count())
+	r.True(strings.HasPrefix(bucket.keys()[0], "offloaded/"+testTableName+"/"))
+
+	found, err := store.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(item, found)
+
+	items, err := store.GetAll(ctx, testPartitionKeyVal)
+	r.NoError(err)
+	r.Equal([]*testLargeItem{item}, items)
+
+	items, err = store.BatchGet(ctx, []dynamo.Key{dynamo.NewKey(testPartitionKeyVal, testSortKeyVal)})
+	r.NoError(err)
+	r.Equal([]*testLargeItem{item}, items)
+
+	// the replaced object is deleted
+	item.Random = randomBytes(300 * 1024)
+	r.NoError(store.Put(ctx, item))
+	r.Equal(1, bucket.count())
+	found, err = store.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(item, found)
+
+	// small items are not changed
+	item.Random = nil
+	item.Text = "text"
+	r.NoError(store.Put(ctx, item))
+	r.Equal(0, bucket.count())
+	r.Equal(&types.AttributeValueMemberS{Value: "text"}, client.Items(testTableName)[0]["text"])
+
+	// the objects are deleted with the items
+	item.Random = randomBytes(200 * 1024)
+	r.NoError(store.Put(ctx, item))
+	r.NoError(store.BatchPut(ctx, []*testLargeItem{{Pkey: "other", Skey: "other", Random: randomBytes(250 * 1024)}}))
+	r.Equal(2, bucket.count())
+	r.NoError(store.Delete(ctx, item, testPartitionKeyVal, testSortKeyVal))
+	r.Equal(1, bucket.count())
+	r.NoError(store.BatchDelete(ctx, []dynamo.Key{dynamo.NewKey("other", "other")}))
+	r.Equal(0, bucket.count())
+}
+
+func TestOffloadingStore_UserPointers(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, bucket, store := newTestOffloadingStore(t)
+
+	r.NoError(store.Put(ctx, &testLargeItem{Pkey: "other", Skey: "other", Random: randomBytes(200 * 1024)}))
+	r.Equal(1, bucket.count())
+	otherKey := bucket.keys()[0]
+
+	// the user maps which look like offloaded attributes do not point to the objects of other items
+	item := &testLargeItem{
+		Pkey:     testPartitionKeyVal,
+		Skey:     testSortKeyVal,
+		Manifest: map[string]string{"@s3": otherKey},
+	}
+	r.NoError(store.Put(ctx, item))
+	found, err := store.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(item, found)
+
+	item.Manifest = nil
+	r.NoError(store.Put(ctx, item))
+	r.Equal([]string{otherKey}, bucket.keys())
+}
+
+func TestOffloadingStore_FailedPut(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, bucket, store := newTestOffloadingStore(t)
+
+	item := &testLargeItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal, Random: randomBytes(200 * 1024)}
+	r.NoError(store.Put(ctx, item))
+	keys := bucket.keys()
+
+	// the objects of the failed write are deleted and the objects of the stored item are kept
+	notExists := dynamo.ConditionExpression{Expression: "attribute_not_exists(pkey)"}
+	r.ErrorIs(store.Put(ctx, item, notExists), dynamo.ErrConditionFailed)
+	r.Equal(keys, bucket.keys())
+	item.Text = hex.EncodeToString(randomBytes(300 * 1024))
+	r.ErrorIs(store.Put(ctx, item, notExists), dynamo.ErrConditionFailed)
+	r.Equal(keys, bucket.keys())
+}
+
+// failingBatchClient fails the batch writes.
+type failingBatchClient struct {
+	*memdb.Client
+}
+
+func (c *failingBatchClient) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return nil, errors.New("failed")
+}
+
+// failingUploader fails the uploads after the given number of uploads.
+type failingUploader struct {
+	*mock_aws.MockS3Uploader
+	uploads int
+}
+
+func (u *failingUploader) Upload(ctx context.Context, input *s3.PutObjectInput, opts ...func(*manager.Uploader)) (*manager.UploadOutput, error) {
+	if u.uploads == 0 {
+		return nil, errors.New("upload failed")
+	}
+	u.uploads--
+	return u.MockS3Uploader.Upload(ctx, input, opts...)
+}
+
+func TestOffloadingStore_BatchPut(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	bucket, s3Client, uploader := newTestBucket(ctrl)
+	client := memdb.NewClient()
+	memdb.NewStore[testLargeItem](client, testTableName)
+	offloadConfig := dynamo.OffloadConfig{S3: s3Client, Uploader: uploader, Bucket: testBucket}
+	store := dynamo.NewOffloadingStore[testLargeItem](client, testTableName, offloadConfig)
+
+	items := []*testLargeItem{
+		{Pkey: "1", Skey: "1", Random: randomBytes(200 * 1024)},
+		{Pkey: "2", Skey: "2", Random: randomBytes(250 * 1024)},
+	}
+	r.NoError(store.BatchPut(ctx, items))
+	r.Equal(2, bucket.count())
+
+	// the objects of the replaced items are deleted
+	items[0].Random = randomBytes(300 * 1024)
+	items[1].Random = nil
+	r.NoError(store.BatchPut(ctx, items))
+	r.Equal(1, bucket.count())
+	found, err := store.Get(ctx, "1", "1")
+	r.NoError(err)
+	r.Equal(items[0], found)
+	keys := bucket.keys()
+
+	// the objects of the failed write are deleted and the objects of the stored items are kept
+	failingStore := dynamo.NewOffloadingStore[testLargeItem](&failingBatchClient{Client: client}, testTableName, offloadConfig)
+	items[1].Random = randomBytes(200 * 1024)
+	items = append(items, &testLargeItem{Pkey: "3", Skey: "3", Random: randomBytes(250 * 1024)})
+	r.Error(failingStore.BatchPut(ctx, items))
+	r.Equal(keys, bucket.keys())
+
+	// and the objects which are uploaded before an item fails to be marshaled
+	offloadConfig.Uploader = &failingUploader{MockS3Uploader: uploader, uploads: 2}
+	failingStore = dynamo.NewOffloadingStore[testLargeItem](client, testTableName, offloadConfig)
+	r.Error(failingStore.BatchPut(ctx, items))
+	r.Equal(keys, bucket.keys())
+}
+
+func TestOffloadingStore_ItemSize(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client, bucket, store := newTestOffloadingStore(t)
+
+	// each compressed attribute is below the offload threshold but the item is too large
+	item := &testLargeItem{
+		Pkey:     testPartitionKeyVal,
+		Skey:     testSortKeyVal,
+		Small:    hex.EncodeToString(randomBytes(110 * 1024)),
+		Text:     hex.EncodeToString(randomBytes(100 * 1024)),
+		Random:   randomBytes(100 * 1024),
+		Manifest: map[string]string{"a": hex.EncodeToString(randomBytes(50 * 1024))},
+	}
+	r.NoError(store.Put(ctx, item))
+	r.Equal(1, bucket.count())
+	stored := client.Items(testTableName)[0]
+	var offloaded int
+	for _, name := range []string{"small", "text", "random", "manifest"} {
+		if _, ok := stored[name].(*types.AttributeValueMemberM).Value["@s3"]; ok {
+			offloaded++
+		} else {
+			r.Contains(stored[name].(*types.AttributeValueMemberM).Value, "@gzip")
+		}
+	}
+	r.Equal(1, offloaded)
+
+	found, err := store.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(item, found)
+}
+
+func TestOffloadingStore_Errors(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	client := memdb.NewClient()
+	memdb.NewStore[testLargeItem](client, testTableName)
+	uploader := mock_aws.NewMockS3Uploader(ctrl)
+	store := dynamo.NewOffloadingStore[testLargeItem](client, testTableName, dynamo.OffloadConfig{
+		Uploader: uploader,
+		Bucket:   testBucket,
+	})
+
+	uploadErr := errors.New("upload failed")
+	uploader.EXPECT().Upload(gomock.Any(), gomock.Any()).Return(nil, uploadErr)
+	err := store.Put(ctx, &testLargeItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal, Random: randomBytes(200 * 1024)})
+	r.ErrorIs(err, uploadErr)
+	r.Empty(client.Items(testTableName))
+}
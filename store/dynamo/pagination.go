@@ -0,0 +1,84 @@
+package dynamo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Pagination errors
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+// Page is a single page of items from a paginated request.
+type Page[I Item] struct {
+	Items []*I
+	// NextPageToken is an opaque token to request the next page with. It is empty
+	// if there are no more pages.
+	NextPageToken string
+}
+
+// pageTokenAttribute is the serializable form of a key attribute value.
+type pageTokenAttribute struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+	B []byte  `json:"B,omitempty"`
+}
+
+// encodePageToken serializes the last evaluated key to an opaque token.
+func encodePageToken(lastEvaluatedKey map[string]types.AttributeValue) (string, error) {
+	if len(lastEvaluatedKey) == 0 {
+		return "", nil
+	}
+	attrs := make(map[string]pageTokenAttribute, len(lastEvaluatedKey))
+	for name, value := range lastEvaluatedKey {
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			attrs[name] = pageTokenAttribute{S: &v.Value}
+		case *types.AttributeValueMemberN:
+			attrs[name] = pageTokenAttribute{N: &v.Value}
+		case *types.AttributeValueMemberB:
+			attrs[name] = pageTokenAttribute{B: v.Value}
+		default:
+			return "", fmt.Errorf("unsupported key attribute type %T in last evaluated key", value)
+		}
+	}
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodePageToken deserializes the token to an exclusive start key.
+func decodePageToken(pageToken string) (map[string]types.AttributeValue, error) {
+	if len(pageToken) == 0 {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var attrs map[string]pageTokenAttribute
+	if err := json.Unmarshal(b, &attrs); err != nil || len(attrs) == 0 {
+		return nil, ErrInvalidPageToken
+	}
+	key := make(map[string]types.AttributeValue, len(attrs))
+	for name, attr := range attrs {
+		switch {
+		case attr.S != nil:
+			key[name] = &types.AttributeValueMemberS{Value: *attr.S}
+		case attr.N != nil:
+			key[name] = &types.AttributeValueMemberN{Value: *attr.N}
+		case attr.B != nil:
+			key[name] = &types.AttributeValueMemberB{Value: attr.B}
+		default:
+			return nil, ErrInvalidPageToken
+		}
+	}
+	return key, nil
+}
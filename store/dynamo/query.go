@@ -0,0 +1,211 @@
+package dynamo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Query builds a query for the items with the same partition key.
+type Query struct {
+	partitionKeyVal  string
+	indexName        string
+	partitionKeyName string
+	sortKeyName      string
+	sortKeyCondition *KeyCondition
+	filter           *Condition
+	projection       []string
+	descending       bool
+	limit            int32
+	consistentRead   bool
+}
+
+// NewQuery creates a new query for the items with given partition key value.
+func NewQuery(partitionKeyVal string) *Query {
+	return &Query{partitionKeyVal: partitionKeyVal}
+}
+
+// Index queries the index instead of the table. The sort key name is empty if the index
+// does not have a sort key.
+func (q *Query) Index(indexName, partitionKeyName, sortKeyName string) *Query {
+	q.indexName = indexName
+	q.partitionKeyName = partitionKeyName
+	q.sortKeyName = sortKeyName
+	return q
+}
+
+// SortKey adds a condition on the sort key.
+func (q *Query) SortKey(condition KeyCondition) *Query {
+	q.sortKeyCondition = &condition
+	return q
+}
+
+// Filter filters the items after they are read. Multiple filters are combined with AND.
+func (q *Query) Filter(conditions ...Condition) *Query {
+	if len(conditions) == 0 {
+		return q
+	}
+	if q.filter != nil {
+		conditions = append([]Condition{*q.filter}, conditions...)
+	}
+	filter := conditions[0]
+	if len(conditions) > 1 {
+		filter = And(conditions...)
+	}
+	q.filter = &filter
+	return q
+}
+
+// Project reads only the given attributes.
+func (q *Query) Project(paths ...string) *Query {
+	q.projection = append(q.projection, paths...)
+	return q
+}
+
+// Descending returns the items in descending sort key order.
+func (q *Query) Descending() *Query {
+	q.descending = true
+	return q
+}
+
+// Limit sets the maximum number of items to return.
+func (q *Query) Limit(limit int32) *Query {
+	q.limit = limit
+	return q
+}
+
+// ConsistentRead makes the query use strongly consistent reads. Such queries are not cached.
+func (q *Query) ConsistentRead() *Query {
+	q.consistentRead = true
+	return q
+}
+
+func (q *Query) makeInput(tableName string, item Item) (*dynamodb.QueryInput, error) {
+	partitionKeyName, sortKeyName := item.GetPartitionKeyName(), item.GetSortKeyName()
+	input := &dynamodb.QueryInput{
+		TableName:        &tableName,
+		ScanIndexForward: boolPtr(!q.descending),
+	}
+	if len(q.indexName) > 0 {
+		input.IndexName = &q.indexName
+		partitionKeyName, sortKeyName = q.partitionKeyName, q.sortKeyName
+	}
+	if q.consistentRead {
+		input.ConsistentRead = &q.consistentRead
+	}
+
+	ea := newExpressionAttributes("q")
+	pkVal, err := ea.value(q.partitionKeyVal)
+	if err != nil {
+		return nil, err
+	}
+	keyCond := fmt.Sprintf("%s = %s", ea.name(partitionKeyName), pkVal)
+	if q.sortKeyCondition != nil {
+		if len(sortKeyName) == 0 {
+			return nil, fmt.Errorf("sort key condition is used without a sort key")
+		}
+		sortKeyCond, err := q.sortKeyCondition.build(ea, ea.name(sortKeyName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build sort key condition: %v", err)
+		}
+		keyCond += " AND " + sortKeyCond
+	}
+	input.KeyConditionExpression = &keyCond
+
+	if q.filter != nil {
+		filter, err := q.filter.build(ea)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build filter: %v", err)
+		}
+		input.FilterExpression = &filter
+	}
+	if len(q.projection) > 0 {
+		paths := make([]string, len(q.projection))
+		for i, path := range q.projection {
+			paths[i] = ea.path(path)
+		}
+		projection := strings.Join(paths, ", ")
+		input.ProjectionExpression = &projection
+	}
+
+	input.ExpressionAttributeNames = ea.namesOrNil()
+	input.ExpressionAttributeValues = ea.valuesOrNil()
+	return input, nil
+}
+
+// makeQueryInputCacheKey makes a deterministic cache key from the query input.
+func makeQueryInputCacheKey(input *dynamodb.QueryInput) string {
+	parts := []string{
+		strValue(input.TableName),
+		strValue(input.IndexName),
+		strValue(input.KeyConditionExpression),
+		strValue(input.FilterExpression),
+		strValue(input.ProjectionExpression),
+		fmt.Sprint(*input.ScanIndexForward),
+	}
+	for _, placeholder := range sortedKeys(input.ExpressionAttributeNames) {
+		parts = append(parts, placeholder+"="+input.ExpressionAttributeNames[placeholder])
+	}
+	for _, placeholder := range sortedKeys(input.ExpressionAttributeValues) {
+		parts = append(parts, placeholder+"="+formatAttributeValue(input.ExpressionAttributeValues[placeholder]))
+	}
+	return makeQueryCacheKey(parts...)
+}
+
+func formatAttributeValue(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return fmt.Sprintf("S:%q", v.Value)
+	case *types.AttributeValueMemberN:
+		return "N:" + v.Value
+	case *types.AttributeValueMemberB:
+		return fmt.Sprintf("B:%x", v.Value)
+	case *types.AttributeValueMemberBOOL:
+		return fmt.Sprintf("BOOL:%t", v.Value)
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return fmt.Sprintf("SS:%q", v.Value)
+	case *types.AttributeValueMemberNS:
+		return fmt.Sprintf("NS:%v", v.Value)
+	case *types.AttributeValueMemberBS:
+		return fmt.Sprintf("BS:%x", v.Value)
+	case *types.AttributeValueMemberL:
+		values := make([]string, len(v.Value))
+		for i, value := range v.Value {
+			values[i] = formatAttributeValue(value)
+		}
+		return "L:[" + strings.Join(values, ",") + "]"
+	case *types.AttributeValueMemberM:
+		var values []string
+		for _, name := range sortedKeys(v.Value) {
+			values = append(values, fmt.Sprintf("%q:%s", name, formatAttributeValue(v.Value[name])))
+		}
+		return "M:{" + strings.Join(values, ",") + "}"
+	default:
+		return fmt.Sprintf("%T", av)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func strValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
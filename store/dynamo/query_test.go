@@ -0,0 +1,132 @@
+package dynamo_test
+
+import (
+	"context"
+	"testing"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/cache"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			r.Nil(input.IndexName)
+			r.Equal("#q0 = :q0 AND begins_with(#q1, :q1)", *input.KeyConditionExpression)
+			r.Equal("(#q2 = :q2) AND ((#q3.#q4 > :q3) OR (attribute_not_exists(#q5)))", *input.FilterExpression)
+			r.Equal("#q0, #q1, #q3.#q4", *input.ProjectionExpression)
+			r.Equal(map[string]string{
+				"#q0": "pkey", "#q1": "skey", "#q2": "status", "#q3": "stats", "#q4": "count", "#q5": "deleted",
+			}, input.ExpressionAttributeNames)
+			r.Equal(&types.AttributeValueMemberS{Value: testPartitionKeyVal}, input.ExpressionAttributeValues[":q0"])
+			r.Equal(&types.AttributeValueMemberN{Value: "10"}, input.ExpressionAttributeValues[":q3"])
+			r.False(*input.ScanIndexForward)
+			r.True(*input.ConsistentRead)
+			return &dynamodb.QueryOutput{
+				Items: []map[string]types.AttributeValue{testFoundItem},
+			}, nil
+		})
+
+	// an empty filter is ignored
+	items, err := store.Query(context.Background(), dynamo.NewQuery(testPartitionKeyVal).
+		SortKey(dynamo.KeyBeginsWith("skey")).
+		Filter().
+		Filter(dynamo.Equal("status", "active")).
+		Filter(dynamo.Or(dynamo.GreaterThan("stats.count", 10), dynamo.AttributeNotExists("deleted"))).
+		Project("pkey", "skey", "stats.count").
+		Descending().
+		ConsistentRead(),
+	)
+	r.NoError(err)
+	r.Len(items, 1)
+}
+
+func TestQuery_Index(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			r.Equal(testIndexName, *input.IndexName)
+			r.Equal("#q0 = :q0 AND #q1 BETWEEN :q1 AND :q2", *input.KeyConditionExpression)
+			r.Equal(map[string]string{"#q0": "owner", "#q1": "createdAt"}, input.ExpressionAttributeNames)
+			return &dynamodb.QueryOutput{}, nil
+		})
+
+	_, err := store.Query(context.Background(), dynamo.NewQuery("0x1").
+		Index(testIndexName, "owner", "createdAt").
+		SortKey(dynamo.KeyBetween(1, 2)),
+	)
+	r.NoError(err)
+
+	// no sort key on the index
+	_, err = store.Query(context.Background(), dynamo.NewQuery("0x1").
+		Index(testIndexName, "owner", "").
+		SortKey(dynamo.KeyEqual(1)),
+	)
+	r.Error(err)
+}
+
+func TestQuery_Limit(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	var limits []int32
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+			limits = append(limits, *input.Limit)
+			return &dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{testFoundItem, testFoundItem},
+				LastEvaluatedKey: testBothKeys,
+			}, nil
+		}).Times(2)
+
+	items, err := store.Query(context.Background(), dynamo.NewQuery(testPartitionKeyVal).Limit(3))
+	r.NoError(err)
+	r.Len(items, 4)
+	r.Equal([]int32{3, 1}, limits)
+}
+
+func TestQuery_CacheLayer(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName).WithCache(cache.NewTemp[testItem]())
+
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).
+		Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{testFoundItem},
+		}, nil).Times(3)
+
+	makeQuery := func(prefix string) *dynamo.Query {
+		return dynamo.NewQuery(testPartitionKeyVal).SortKey(dynamo.KeyBeginsWith(prefix))
+	}
+
+	// the same query is served from the cache
+	for i := 0; i < 2; i++ {
+		items, err := store.Query(context.Background(), makeQuery("a"))
+		r.NoError(err)
+		r.Len(items, 1)
+	}
+	// a different query
+	_, err := store.Query(context.Background(), makeQuery("b"))
+	r.NoError(err)
+	// consistent reads are not cached
+	_, err = store.Query(context.Background(), makeQuery("a").ConsistentRead())
+	r.NoError(err)
+}
@@ -0,0 +1,466 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	tableWaitInterval = time.Second
+	tableWaitTimeout  = time.Minute * 10
+)
+
+// ErrSchemaMismatch is returned when an existing table cannot be changed to match the schema.
+var ErrSchemaMismatch = errors.New("table schema mismatch")
+
+// KeyAttribute is a key attribute of a table or an index.
+type KeyAttribute struct {
+	Name string
+	Type types.ScalarAttributeType
+}
+
+// StringKey creates a string key attribute.
+func StringKey(name string) KeyAttribute {
+	return KeyAttribute{Name: name, Type: types.ScalarAttributeTypeS}
+}
+
+// NumberKey creates a number key attribute.
+func NumberKey(name string) KeyAttribute {
+	return KeyAttribute{Name: name, Type: types.ScalarAttributeTypeN}
+}
+
+// BinaryKey creates a binary key attribute.
+func BinaryKey(name string) KeyAttribute {
+	return KeyAttribute{Name: name, Type: types.ScalarAttributeTypeB}
+}
+
+// IndexSchema is the schema of a secondary index. All attributes are projected to the index.
+type IndexSchema struct {
+	Name         string
+	PartitionKey KeyAttribute
+	// SortKey is nil if the index has no sort key.
+	SortKey *KeyAttribute
+}
+
+// TableSchema declares a table.
+type TableSchema struct {
+	TableName    string
+	PartitionKey KeyAttribute
+	// SortKey is nil if the table has no sort key.
+	SortKey       *KeyAttribute
+	GlobalIndexes []IndexSchema
+	// LocalIndexes can only be created together with the table.
+	LocalIndexes []IndexSchema
+	// TTLAttribute enables the time to live for the attribute if it is not empty.
+	TTLAttribute string
+	// BillingMode is PAY_PER_REQUEST unless the provisioned capacities are set.
+	BillingMode   types.BillingMode
+	ReadCapacity  int64
+	WriteCapacity int64
+}
+
+// SchemaDefiner is implemented by the items which declare the indexes and
+// the settings of their tables.
+type SchemaDefiner interface {
+	DefineSchema(schema *TableSchema)
+}
+
+// NewTableSchema creates the schema of a table which stores the given items. The keys of the table
+// are string attributes with the key names of the item. If the item implements SchemaDefiner, it can
+// change the schema further.
+func NewTableSchema[I Item](tableName string) *TableSchema {
+	var item I
+	schema := &TableSchema{
+		TableName:    tableName,
+		PartitionKey: StringKey(item.GetPartitionKeyName()),
+		BillingMode:  types.BillingModePayPerRequest,
+	}
+	if sortKeyName := item.GetSortKeyName(); len(sortKeyName) > 0 {
+		sortKey := StringKey(sortKeyName)
+		schema.SortKey = &sortKey
+	}
+	if definer, ok := any(item).(SchemaDefiner); ok {
+		definer.DefineSchema(schema)
+	} else if definer, ok := any(&item).(SchemaDefiner); ok {
+		definer.DefineSchema(schema)
+	}
+	return schema
+}
+
+// GlobalIndex adds a global secondary index.
+func (schema *TableSchema) GlobalIndex(name string, partitionKey KeyAttribute, sortKey ...KeyAttribute) *TableSchema {
+	schema.GlobalIndexes = append(schema.GlobalIndexes, makeIndexSchema(name, partitionKey, sortKey))
+	return schema
+}
+
+// LocalIndex adds a local secondary index.
+func (schema *TableSchema) LocalIndex(name string, sortKey KeyAttribute) *TableSchema {
+	schema.LocalIndexes = append(schema.LocalIndexes, makeIndexSchema(name, schema.PartitionKey, []KeyAttribute{sortKey}))
+	return schema
+}
+
+// TTL enables the time to live for the attribute.
+func (schema *TableSchema) TTL(attributeName string) *TableSchema {
+	schema.TTLAttribute = attributeName
+	return schema
+}
+
+// Provisioned switches to the provisioned billing mode with the given capacities.
+func (schema *TableSchema) Provisioned(readCapacity, writeCapacity int64) *TableSchema {
+	schema.BillingMode = types.BillingModeProvisioned
+	schema.ReadCapacity = readCapacity
+	schema.WriteCapacity = writeCapacity
+	return schema
+}
+
+func makeIndexSchema(name string, partitionKey KeyAttribute, sortKey []KeyAttribute) IndexSchema {
+	index := IndexSchema{Name: name, PartitionKey: partitionKey}
+	if len(sortKey) > 0 {
+		index.SortKey = &sortKey[0]
+	}
+	return index
+}
+
+func makeKeySchema(partitionKey KeyAttribute, sortKey *KeyAttribute) []types.KeySchemaElement {
+	keySchema := []types.KeySchemaElement{
+		{AttributeName: &partitionKey.Name, KeyType: types.KeyTypeHash},
+	}
+	if sortKey != nil {
+		keySchema = append(keySchema, types.KeySchemaElement{AttributeName: &sortKey.Name, KeyType: types.KeyTypeRange})
+	}
+	return keySchema
+}
+
+// keyAttributes returns the key attributes of the table and the given indexes.
+func (schema *TableSchema) keyAttributes(indexes ...IndexSchema) []KeyAttribute {
+	attributes := []KeyAttribute{schema.PartitionKey}
+	if schema.SortKey != nil {
+		attributes = append(attributes, *schema.SortKey)
+	}
+	for _, index := range indexes {
+		attributes = append(attributes, index.PartitionKey)
+		if index.SortKey != nil {
+			attributes = append(attributes, *index.SortKey)
+		}
+	}
+	return attributes
+}
+
+func (schema *TableSchema) indexes() []IndexSchema {
+	var indexes []IndexSchema
+	indexes = append(indexes, schema.GlobalIndexes...)
+	return append(indexes, schema.LocalIndexes...)
+}
+
+func makeAttributeDefinitions(attributes []KeyAttribute) []types.AttributeDefinition {
+	var definitions []types.AttributeDefinition
+	seen := make(map[string]bool)
+	for _, attribute := range attributes {
+		if seen[attribute.Name] {
+			continue
+		}
+		seen[attribute.Name] = true
+		definitions = append(definitions, types.AttributeDefinition{
+			AttributeName: strPtr(attribute.Name),
+			AttributeType: attribute.Type,
+		})
+	}
+	return definitions
+}
+
+func (schema *TableSchema) throughput() *types.ProvisionedThroughput {
+	if schema.BillingMode != types.BillingModeProvisioned {
+		return nil
+	}
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  &schema.ReadCapacity,
+		WriteCapacityUnits: &schema.WriteCapacity,
+	}
+}
+
+func (schema *TableSchema) billingMode() types.BillingMode {
+	if len(schema.BillingMode) == 0 {
+		return types.BillingModePayPerRequest
+	}
+	return schema.BillingMode
+}
+
+// EnsureTable creates the table if it does not exist or updates it to match the schema, and then
+// waits until the table and the indexes are active. The missing global secondary indexes, the billing
+// mode and the time to live are updated. The indexes which are not in the schema are not deleted.
+// ErrSchemaMismatch is returned if the keys or the local secondary indexes do not match the schema.
+func EnsureTable(ctx context.Context, client aws.DynamoDBClient, schema *TableSchema) error {
+	ctx, cancel := context.WithTimeout(ctx, tableWaitTimeout)
+	defer cancel()
+
+	res, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &schema.TableName})
+	var notFoundErr *types.ResourceNotFoundException
+	switch {
+	case errors.As(err, &notFoundErr):
+		err = createTable(ctx, client, schema)
+	case err != nil:
+		return fmt.Errorf("failed to describe table %s: %w", schema.TableName, err)
+	default:
+		err = updateActiveTable(ctx, client, schema, res.Table)
+	}
+	if err != nil {
+		return err
+	}
+	return ensureTTL(ctx, client, schema)
+}
+
+// updateActiveTable waits until the table is active and updates it. The table is diffed again if
+// another update is started meanwhile, e.g. by another replica which ensures the same table.
+func updateActiveTable(ctx context.Context, client aws.DynamoDBClient, schema *TableSchema, table *types.TableDescription) error {
+	for {
+		if !isTableActive(table) {
+			var err error
+			if table, err = waitForTable(ctx, client, schema.TableName); err != nil {
+				return err
+			}
+		}
+		err := updateTable(ctx, client, schema, table)
+		var inUseErr *types.ResourceInUseException
+		if !errors.As(err, &inUseErr) {
+			return err
+		}
+		table = nil
+	}
+}
+
+func createTable(ctx context.Context, client aws.DynamoDBClient, schema *TableSchema) error {
+	input := &dynamodb.CreateTableInput{
+		TableName:             &schema.TableName,
+		KeySchema:             makeKeySchema(schema.PartitionKey, schema.SortKey),
+		AttributeDefinitions:  makeAttributeDefinitions(schema.keyAttributes(schema.indexes()...)),
+		BillingMode:           schema.billingMode(),
+		ProvisionedThroughput: schema.throughput(),
+	}
+	for _, index := range schema.GlobalIndexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, types.GlobalSecondaryIndex{
+			IndexName:             strPtr(index.Name),
+			KeySchema:             makeKeySchema(index.PartitionKey, index.SortKey),
+			Projection:            &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			ProvisionedThroughput: schema.throughput(),
+		})
+	}
+	for _, index := range schema.LocalIndexes {
+		input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, types.LocalSecondaryIndex{
+			IndexName:  strPtr(index.Name),
+			KeySchema:  makeKeySchema(index.PartitionKey, index.SortKey),
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		})
+	}
+	var inUseErr *types.ResourceInUseException
+	if _, err := client.CreateTable(ctx, input); err != nil && !errors.As(err, &inUseErr) {
+		return fmt.Errorf("failed to create table %s: %w", schema.TableName, err)
+	}
+	_, err := waitForTable(ctx, client, schema.TableName)
+	return err
+}
+
+func updateTable(ctx context.Context, client aws.DynamoDBClient, schema *TableSchema, table *types.TableDescription) error {
+	if err := checkTableKeys(schema, table); err != nil {
+		return err
+	}
+	existingIndexes := make(map[string][]types.KeySchemaElement)
+	for _, index := range table.GlobalSecondaryIndexes {
+		existingIndexes[*index.IndexName] = index.KeySchema
+	}
+	for _, index := range table.LocalSecondaryIndexes {
+		existingIndexes[*index.IndexName] = index.KeySchema
+	}
+	for _, index := range schema.LocalIndexes {
+		keySchema, ok := existingIndexes[index.Name]
+		if !ok {
+			return fmt.Errorf("%w: local secondary index %s cannot be added to table %s", ErrSchemaMismatch, index.Name, schema.TableName)
+		}
+		if !equalKeySchemas(keySchema, makeKeySchema(index.PartitionKey, index.SortKey)) {
+			return fmt.Errorf("%w: different keys for index %s of table %s", ErrSchemaMismatch, index.Name, schema.TableName)
+		}
+	}
+
+	var err error
+	if describedBillingMode(table) != schema.billingMode() || (schema.throughput() != nil && !equalThroughput(table.ProvisionedThroughput, schema)) {
+		_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:             &schema.TableName,
+			BillingMode:           schema.billingMode(),
+			ProvisionedThroughput: schema.throughput(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update the billing mode of table %s: %w", schema.TableName, err)
+		}
+		if _, err = waitForTable(ctx, client, schema.TableName); err != nil {
+			return err
+		}
+	}
+
+	// only one global secondary index can be created per update
+	for _, index := range schema.GlobalIndexes {
+		keySchema, ok := existingIndexes[index.Name]
+		if ok {
+			if !equalKeySchemas(keySchema, makeKeySchema(index.PartitionKey, index.SortKey)) {
+				return fmt.Errorf("%w: different keys for index %s of table %s", ErrSchemaMismatch, index.Name, schema.TableName)
+			}
+			continue
+		}
+		_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:            &schema.TableName,
+			AttributeDefinitions: makeAttributeDefinitions(schema.keyAttributes(index)),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+				{
+					Create: &types.CreateGlobalSecondaryIndexAction{
+						IndexName:             strPtr(index.Name),
+						KeySchema:             makeKeySchema(index.PartitionKey, index.SortKey),
+						Projection:            &types.Projection{ProjectionType: types.ProjectionTypeAll},
+						ProvisionedThroughput: schema.throughput(),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create index %s of table %s: %w", index.Name, schema.TableName, err)
+		}
+		if _, err = waitForTable(ctx, client, schema.TableName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describedBillingMode returns the billing mode of the table. The summary may be missing for the
+// tables which are created with the provisioned capacities, and on DynamoDB Local.
+func describedBillingMode(table *types.TableDescription) types.BillingMode {
+	if table.BillingModeSummary != nil && len(table.BillingModeSummary.BillingMode) > 0 {
+		return table.BillingModeSummary.BillingMode
+	}
+	if throughput := table.ProvisionedThroughput; throughput != nil &&
+		throughput.ReadCapacityUnits != nil && *throughput.ReadCapacityUnits > 0 {
+		return types.BillingModeProvisioned
+	}
+	return types.BillingModePayPerRequest
+}
+
+func checkTableKeys(schema *TableSchema, table *types.TableDescription) error {
+	if !equalKeySchemas(table.KeySchema, makeKeySchema(schema.PartitionKey, schema.SortKey)) {
+		return fmt.Errorf("%w: different keys for table %s", ErrSchemaMismatch, schema.TableName)
+	}
+	attributeTypes := make(map[string]types.ScalarAttributeType)
+	for _, definition := range table.AttributeDefinitions {
+		attributeTypes[*definition.AttributeName] = definition.AttributeType
+	}
+	for _, attribute := range schema.keyAttributes(schema.indexes()...) {
+		if attributeType, ok := attributeTypes[attribute.Name]; ok && attributeType != attribute.Type {
+			return fmt.Errorf(
+				"%w: attribute %s of table %s has type %s instead of %s",
+				ErrSchemaMismatch, attribute.Name, schema.TableName, attributeType, attribute.Type,
+			)
+		}
+	}
+	return nil
+}
+
+func equalKeySchemas(a, b []types.KeySchemaElement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strValue(a[i].AttributeName) != strValue(b[i].AttributeName) || a[i].KeyType != b[i].KeyType {
+			return false
+		}
+	}
+	return true
+}
+
+func equalThroughput(throughput *types.ProvisionedThroughputDescription, schema *TableSchema) bool {
+	if throughput == nil || throughput.ReadCapacityUnits == nil || throughput.WriteCapacityUnits == nil {
+		return false
+	}
+	return *throughput.ReadCapacityUnits == schema.ReadCapacity && *throughput.WriteCapacityUnits == schema.WriteCapacity
+}
+
+func ensureTTL(ctx context.Context, client aws.DynamoDBClient, schema *TableSchema) error {
+	if len(schema.TTLAttribute) == 0 {
+		return nil
+	}
+	res, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: &schema.TableName})
+	if err != nil {
+		return fmt.Errorf("failed to describe the time to live of table %s: %w", schema.TableName, err)
+	}
+	if desc := res.TimeToLiveDescription; desc != nil {
+		switch desc.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			if strValue(desc.AttributeName) == schema.TTLAttribute {
+				return nil
+			}
+			return fmt.Errorf(
+				"%w: time to live of table %s is enabled for %s", ErrSchemaMismatch, schema.TableName, strValue(desc.AttributeName),
+			)
+		}
+	}
+	enabled := true
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: &schema.TableName,
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: &schema.TTLAttribute,
+			Enabled:       &enabled,
+		},
+	})
+	var inUseErr *types.ResourceInUseException
+	if errors.As(err, &inUseErr) {
+		// the table or its time to live is being updated, e.g. by another replica
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for table %s: %w", schema.TableName, ctx.Err())
+		case <-time.After(tableWaitInterval):
+		}
+		if _, err := waitForTable(ctx, client, schema.TableName); err != nil {
+			return err
+		}
+		return ensureTTL(ctx, client, schema)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enable the time to live of table %s: %w", schema.TableName, err)
+	}
+	return nil
+}
+
+// waitForTable waits until the table and its global secondary indexes are active.
+func waitForTable(ctx context.Context, client aws.DynamoDBClient, tableName string) (*types.TableDescription, error) {
+	ticker := time.NewTicker(tableWaitInterval)
+	defer ticker.Stop()
+	for {
+		res, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &tableName})
+		var notFoundErr *types.ResourceNotFoundException
+		if err != nil && !errors.As(err, &notFoundErr) {
+			return nil, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+		}
+		if err == nil && isTableActive(res.Table) {
+			return res.Table, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for table %s: %w", tableName, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func isTableActive(table *types.TableDescription) bool {
+	if table == nil || table.TableStatus != types.TableStatusActive {
+		return false
+	}
+	for _, index := range table.GlobalSecondaryIndexes {
+		if index.IndexStatus != types.IndexStatusActive {
+			return false
+		}
+	}
+	return true
+}
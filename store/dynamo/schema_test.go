@@ -0,0 +1,206 @@
+package dynamo_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+type testSchemaItem struct {
+	Pkey      string `dynamodbav:"pkey"`
+	Skey      string `dynamodbav:"skey"`
+	Owner     string `dynamodbav:"owner"`
+	CreatedAt int64  `dynamodbav:"createdAt"`
+	ExpiresAt int64  `dynamodbav:"expiresAt"`
+}
+
+func (testSchemaItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (testSchemaItem) GetSortKeyName() string {
+	return "skey"
+}
+
+func (testSchemaItem) DefineSchema(schema *dynamo.TableSchema) {
+	schema.
+		GlobalIndex("owner-index", dynamo.StringKey("owner"), dynamo.NumberKey("createdAt")).
+		LocalIndex("created-index", dynamo.NumberKey("createdAt")).
+		TTL("expiresAt")
+}
+
+func describeTestTable(t *testing.T, client *memdb.Client) *types.TableDescription {
+	res, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String(testTableName)})
+	require.NoError(t, err)
+	return res.Table
+}
+
+func TestEnsureTable_Create(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+
+	schema := dynamo.NewTableSchema[testSchemaItem](testTableName)
+	r.NoError(dynamo.EnsureTable(ctx, client, schema))
+
+	table := describeTestTable(t, client)
+	r.Equal(types.TableStatusActive, table.TableStatus)
+	r.Equal(types.BillingModePayPerRequest, table.BillingModeSummary.BillingMode)
+	r.Len(table.KeySchema, 2)
+	r.Len(table.GlobalSecondaryIndexes, 1)
+	r.Equal("owner-index", *table.GlobalSecondaryIndexes[0].IndexName)
+	r.Len(table.LocalSecondaryIndexes, 1)
+	r.Equal("created-index", *table.LocalSecondaryIndexes[0].IndexName)
+
+	ttl, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(testTableName)})
+	r.NoError(err)
+	r.Equal(types.TimeToLiveStatusEnabled, ttl.TimeToLiveDescription.TimeToLiveStatus)
+	r.Equal("expiresAt", *ttl.TimeToLiveDescription.AttributeName)
+
+	// ensuring again does not change anything
+	r.NoError(dynamo.EnsureTable(ctx, client, schema))
+	r.Equal(table, describeTestTable(t, client))
+
+	// the indexes are usable
+	store := dynamo.NewStore[testSchemaItem](client, testTableName)
+	r.NoError(store.Put(ctx, &testSchemaItem{Pkey: "pk", Skey: "sk", Owner: "alice", CreatedAt: 1}))
+	items, err := store.Query(ctx, dynamo.NewQuery("alice").Index("owner-index", "owner", "createdAt").
+		SortKey(dynamo.KeyGreaterThan(0)))
+	r.NoError(err)
+	r.Len(items, 1)
+}
+
+func TestEnsureTable_Update(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := memdb.NewClient()
+
+	r.NoError(dynamo.EnsureTable(ctx, client, dynamo.NewTableSchema[testItem](testTableName)))
+
+	schema := dynamo.NewTableSchema[testItem](testTableName).
+		GlobalIndex(testIndexName, dynamo.StringKey("owner")).
+		GlobalIndex("other-index", dynamo.StringKey("other"), dynamo.StringKey("skey")).
+		TTL("expiresAt").
+		Provisioned(5, 10)
+	r.NoError(dynamo.EnsureTable(ctx, client, schema))
+
+	table := describeTestTable(t, client)
+	r.Len(table.GlobalSecondaryIndexes, 2)
+	r.Equal(types.BillingModeProvisioned, table.BillingModeSummary.BillingMode)
+	r.Equal(int64(5), *table.ProvisionedThroughput.ReadCapacityUnits)
+	r.Equal(int64(10), *table.ProvisionedThroughput.WriteCapacityUnits)
+
+	ttl, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(testTableName)})
+	r.NoError(err)
+	r.Equal("expiresAt", *ttl.TimeToLiveDescription.AttributeName)
+
+	// the indexes which are not in the schema are kept
+	r.NoError(dynamo.EnsureTable(ctx, client, dynamo.NewTableSchema[testItem](testTableName)))
+	r.Len(describeTestTable(t, client).GlobalSecondaryIndexes, 2)
+}
+
+func TestEnsureTable_Mismatch(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name   string
+		schema *dynamo.TableSchema
+	}{
+		{
+			name:   "different partition key",
+			schema: dynamo.NewTableSchema[testOtherItem](testTableName),
+		},
+		{
+			name:   "different key type",
+			schema: &dynamo.TableSchema{TableName: testTableName, PartitionKey: dynamo.NumberKey("pkey"), SortKey: &[]dynamo.KeyAttribute{dynamo.StringKey("skey")}[0]},
+		},
+		{
+			name:   "new local index",
+			schema: dynamo.NewTableSchema[testItem](testTableName).LocalIndex("local-index", dynamo.StringKey("other")),
+		},
+		{
+			name:   "different index keys",
+			schema: dynamo.NewTableSchema[testItem](testTableName).GlobalIndex(testIndexName, dynamo.StringKey("other")),
+		},
+		{
+			name:   "different ttl attribute",
+			schema: dynamo.NewTableSchema[testItem](testTableName).TTL("other"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := require.New(t)
+			client := memdb.NewClient()
+			r.NoError(dynamo.EnsureTable(ctx, client, dynamo.NewTableSchema[testItem](testTableName).
+				GlobalIndex(testIndexName, dynamo.StringKey("owner")).
+				TTL("expiresAt")))
+
+			r.ErrorIs(dynamo.EnsureTable(ctx, client, testCase.schema), dynamo.ErrSchemaMismatch)
+		})
+	}
+}
+
+// updatingClient reports the table as updating until it is described a number of times and
+// rejects the updates meanwhile, as DynamoDB does while another replica updates the table.
+type updatingClient struct {
+	*memdb.Client
+	mu       sync.Mutex
+	updating int
+}
+
+func (c *updatingClient) isUpdating() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.updating > 0
+}
+
+func (c *updatingClient) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	res, err := c.Client.DescribeTable(ctx, input, optFns...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil && c.updating > 0 {
+		c.updating--
+		table := *res.Table
+		table.TableStatus = types.TableStatusUpdating
+		res.Table = &table
+	}
+	return res, err
+}
+
+func (c *updatingClient) UpdateTable(ctx context.Context, input *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	if c.isUpdating() {
+		return nil, &types.ResourceInUseException{}
+	}
+	return c.Client.UpdateTable(ctx, input, optFns...)
+}
+
+func (c *updatingClient) UpdateTimeToLive(ctx context.Context, input *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	if c.isUpdating() {
+		return nil, &types.ResourceInUseException{}
+	}
+	return c.Client.UpdateTimeToLive(ctx, input, optFns...)
+}
+
+func TestEnsureTable_Updating(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	client := &updatingClient{Client: memdb.NewClient()}
+	r.NoError(dynamo.EnsureTable(ctx, client, dynamo.NewTableSchema[testItem](testTableName)))
+
+	// the table is updated after it becomes active
+	client.updating = 2
+	schema := dynamo.NewTableSchema[testItem](testTableName).
+		GlobalIndex(testIndexName, dynamo.StringKey("owner")).
+		TTL("expiresAt")
+	r.NoError(dynamo.EnsureTable(ctx, client, schema))
+	r.Len(describeTestTable(t, client.Client).GlobalSecondaryIndexes, 1)
+}
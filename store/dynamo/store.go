@@ -4,13 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"maps"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/forta-network/core-go/aws"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 // Attribute keys
@@ -18,6 +25,9 @@ const (
 	AttributePartitionKey = ":partitionkeyval"
 )
 
+// coalescedGetTimeout limits the reads which are shared by the concurrent Get calls.
+const coalescedGetTimeout = time.Minute
+
 // Item represents the minimum interface that needs to be implemented
 // by the items that are managed by the store.
 type Item interface {
@@ -36,19 +46,60 @@ type Cache[I Item] interface {
 	Put(ctx context.Context, item *I, partitionKey string, sortKey ...string)
 	GetQuery(ctx context.Context, queryKey string) ([]*I, bool)
 	PutQuery(ctx context.Context, queryKey string, items []*I)
+	// Invalidate removes the cached item.
+	Invalidate(ctx context.Context, partitionKey string, sortKey ...string)
+	// InvalidateQueries removes the cached query results which have the given key prefix.
+	InvalidateQueries(ctx context.Context, queryKeyPrefix string)
+}
+
+// NegativeCache is implemented by the caches which can cache the missing items. Such caches
+// return a nil item and true from Get for the items which are known to be missing.
+type NegativeCache interface {
+	PutNotFound(ctx context.Context, partitionKey string, sortKey ...string)
 }
 
+// CacheMode determines how the cached stores update the cache after writes.
+type CacheMode int
+
+// Cache modes
+const (
+	// CacheWriteInvalidate removes the written items from the cache.
+	CacheWriteInvalidate CacheMode = iota
+	// CacheWriteThrough puts the written items to the cache.
+	CacheWriteThrough
+)
+
 // Store is a generic interface for storing data.
 type Store[I Item] interface {
 	TableName() string
-	WithCache(cache Cache[I]) Store[I]
+	WithCache(cache Cache[I], mode ...CacheMode) Store[I]
 
 	Scan(ctx context.Context) ([]*I, error)
+	ScanPage(ctx context.Context, pageSize int32, pageToken string) (*Page[I], error)
+	ParallelScan(ctx context.Context, segments int) ([]*I, error)
 	Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, error)
 	GetAll(ctx context.Context, partitionKey string) ([]*I, error)
+	GetAllPage(ctx context.Context, partitionKey string, pageSize int32, pageToken string) (*Page[I], error)
 	GetAllFromIndex(ctx context.Context, indexName, partitionKeyName, partitionKeyVal string) ([]*I, error)
+	GetAllFromIndexPage(
+		ctx context.Context, indexName, partitionKeyName, partitionKeyVal string, pageSize int32, pageToken string,
+	) (*Page[I], error)
+	Query(ctx context.Context, query *Query) ([]*I, error)
+	QueryPage(ctx context.Context, query *Query, pageSize int32, pageToken string) (*Page[I], error)
+	BatchGet(ctx context.Context, keys []Key) ([]*I, error)
 	Put(ctx context.Context, item *I, conditionExpression ...ConditionExpression) error
+	BatchPut(ctx context.Context, items []*I) error
+	Update(ctx context.Context, key Key, update *Update, conditionExpression ...ConditionExpression) (*I, error)
 	Delete(ctx context.Context, item *I, partitionKey string, sortKey ...string) error
+	BatchDelete(ctx context.Context, keys []Key) error
+	// DecodeItem decodes the item attributes which are not read by the store, e.g. a stream image.
+	DecodeItem(ctx context.Context, attributes map[string]types.AttributeValue) (*I, error)
+}
+
+// ItemDecoder decodes the items which are read outside of the store, e.g. the images in the
+// table stream. The stores restore the offloaded and decrypt the encrypted attributes.
+type ItemDecoder[I Item] interface {
+	DecodeItem(ctx context.Context, attributes map[string]types.AttributeValue) (*I, error)
 }
 
 // Store errors
@@ -60,6 +111,10 @@ type store[I Item] struct {
 	client    aws.DynamoDBClient
 	tableName string
 	item      I
+	// offloader is nil if offloading is disabled.
+	offloader *offloader
+	// encryptor is nil if encryption is disabled.
+	encryptor *encryptor
 }
 
 // NewStore creates a new store.
@@ -74,29 +129,176 @@ func (s *store[I]) TableName() string {
 	return s.tableName
 }
 
-func (s *store[I]) WithCache(cache Cache[I]) Store[I] {
-	return &cachedStore[I]{cache: cache, Store: s}
+// DecodeItem restores and unmarshals a copy of the item attributes.
+func (s *store[I]) DecodeItem(ctx context.Context, attributes map[string]types.AttributeValue) (*I, error) {
+	attributes = maps.Clone(attributes)
+	if err := s.restore(ctx, attributes); err != nil {
+		return nil, err
+	}
+	var item I
+	if err := attributevalue.UnmarshalMap(attributes, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *store[I]) WithCache(cache Cache[I], mode ...CacheMode) Store[I] {
+	return newCachedStore[I](s, cache, mode...)
 }
 
 func (s *store[I]) Scan(ctx context.Context) ([]*I, error) {
-	res, err := s.client.Scan(ctx, &dynamodb.ScanInput{
+	return s.scanAll(ctx, &dynamodb.ScanInput{
 		TableName: &s.tableName,
 		Select:    types.SelectAllAttributes,
 	})
+}
+
+func (s *store[I]) ScanPage(ctx context.Context, pageSize int32, pageToken string) (*Page[I], error) {
+	startKey, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.ScanInput{
+		TableName:         &s.tableName,
+		Select:            types.SelectAllAttributes,
+		ExclusiveStartKey: startKey,
+	}
+	if pageSize > 0 {
+		input.Limit = &pageSize
+	}
+	res, err := s.client.Scan(ctx, input)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.restore(ctx, res.Items...); err != nil {
+		return nil, err
+	}
+	return makePage[I](res.Items, res.LastEvaluatedKey)
+}
+
+// ParallelScan scans the table by splitting it to given number of segments and
+// scanning the segments concurrently.
+func (s *store[I]) ParallelScan(ctx context.Context, segments int) ([]*I, error) {
+	if segments <= 1 {
+		return s.Scan(ctx)
+	}
+	totalSegments := int32(segments)
+	results := make([][]*I, segments)
+	g, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < segments; i++ {
+		segment := int32(i)
+		g.Go(func() error {
+			items, err := s.scanAll(ctx, &dynamodb.ScanInput{
+				TableName:     &s.tableName,
+				Select:        types.SelectAllAttributes,
+				Segment:       &segment,
+				TotalSegments: &totalSegments,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to scan segment %d: %v", segment, err)
+			}
+			results[segment] = items
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	var items []*I
+	for _, segmentItems := range results {
+		items = append(items, segmentItems...)
+	}
+	return items, nil
+}
+
+// scanAll follows the last evaluated keys until all items are scanned.
+func (s *store[I]) scanAll(ctx context.Context, input *dynamodb.ScanInput) ([]*I, error) {
 	var items []*I
-	for _, resIt := range res.Items {
-		var item I
-		if err := attributevalue.UnmarshalMap(resIt, &item); err != nil {
+	for {
+		res, err := s.client.Scan(ctx, input)
+		if err != nil {
 			return nil, err
 		}
-		items = append(items, &item)
+		if err := s.restore(ctx, res.Items...); err != nil {
+			return nil, err
+		}
+		pageItems, err := unmarshalItems[I](res.Items)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+		if len(res.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+}
+
+// marshalItem marshals the item, encrypts the encrypted attributes if encryption is enabled
+// and offloads the large attributes if offloading is enabled.
+func (s *store[I]) marshalItem(ctx context.Context, item *I) (map[string]types.AttributeValue, error) {
+	marshaled, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+	if s.encryptor != nil {
+		if err := s.encryptor.encrypt(ctx, marshaled); err != nil {
+			return nil, err
+		}
+	}
+	if s.offloader != nil {
+		if err := s.offloader.offload(ctx, marshaled, s.item.GetPartitionKeyName(), s.item.GetSortKeyName()); err != nil {
+			return nil, err
+		}
+	}
+	return marshaled, nil
+}
+
+// restore restores the offloaded attributes and decrypts the encrypted attributes of the items.
+func (s *store[I]) restore(ctx context.Context, items ...map[string]types.AttributeValue) error {
+	for _, item := range items {
+		if s.offloader != nil {
+			if err := s.offloader.restore(ctx, item, s.item.GetPartitionKeyName(), s.item.GetSortKeyName()); err != nil {
+				return err
+			}
+		}
+		if s.encryptor != nil {
+			if err := s.encryptor.decrypt(ctx, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteObjects deletes the offloaded objects of the item with the given key if offloading is enabled.
+func (s *store[I]) deleteObjects(ctx context.Context, key map[string]types.AttributeValue) error {
+	if s.offloader == nil {
+		return nil
+	}
+	return s.offloader.deleteObjects(ctx, key, s.item.GetPartitionKeyName(), s.item.GetSortKeyName())
+}
+
+func unmarshalItems[I Item](resItems []map[string]types.AttributeValue) ([]*I, error) {
+	var items []*I
+	if err := attributevalue.UnmarshalListOfMaps(resItems, &items); err != nil {
+		return nil, err
 	}
 	return items, nil
 }
 
+func makePage[I Item](resItems []map[string]types.AttributeValue, lastEvaluatedKey map[string]types.AttributeValue) (*Page[I], error) {
+	items, err := unmarshalItems[I](resItems)
+	if err != nil {
+		return nil, err
+	}
+	nextPageToken, err := encodePageToken(lastEvaluatedKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Page[I]{Items: items, NextPageToken: nextPageToken}, nil
+}
+
 func (s *store[I]) Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, error) {
 	if len(partitionKey) == 0 {
 		return nil, errors.New("empty partition key provided")
@@ -113,6 +315,9 @@ func (s *store[I]) Get(ctx context.Context, partitionKey string, sortKey ...stri
 	if err != nil {
 		return nil, err
 	}
+	if err := s.restore(ctx, res.Item); err != nil {
+		return nil, err
+	}
 	if err := attributevalue.UnmarshalMap(res.Item, &item); err != nil {
 		return nil, err
 	}
@@ -130,97 +335,474 @@ func makePrimaryKey[I Item](item *I, partitionKey string, sortKey ...string) map
 	return primaryKey
 }
 
+// Put puts the item. If the item is Versioned, the version is incremented and the
+// write is conditioned on the previous version.
 func (s *store[I]) Put(ctx context.Context, item *I, conditionExpression ...ConditionExpression) error {
-	marshaled, err := attributevalue.MarshalMap(item)
+	vc, restoreVersion := prepareVersionedPut(item)
+	err := s.put(ctx, item, vc, conditionExpression...)
+	if err != nil {
+		restoreVersion()
+	}
+	return err
+}
+
+func (s *store[I]) put(ctx context.Context, item *I, vc *versionCheck, conditionExpression ...ConditionExpression) error {
+	marshaled, err := s.marshalItem(ctx, item)
+	if err != nil {
+		return err
+	}
+	cond, err := makeWriteCondition(vc, conditionExpression...)
 	if err != nil {
 		return err
 	}
 	op := &dynamodb.PutItemInput{
-		Item:      marshaled,
-		TableName: &s.tableName,
+		Item:                      marshaled,
+		TableName:                 &s.tableName,
+		ConditionExpression:       cond.expression,
+		ExpressionAttributeNames:  cond.names,
+		ExpressionAttributeValues: cond.values,
 	}
-	if conditionExpression != nil {
-		op.ConditionExpression = &conditionExpression[0].Expression
-		op.ExpressionAttributeValues = conditionExpression[0].ExpressionAttributeValues
+	if vc != nil {
+		op.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
 	}
-	_, err = s.client.PutItem(ctx, op)
-	return err
+	if s.offloader != nil {
+		op.ReturnValues = types.ReturnValueAllOld
+	}
+	res, err := s.client.PutItem(ctx, op)
+	if err != nil {
+		if s.offloader != nil {
+			s.discardObjects(ctx, marshaled)
+		}
+		return toConditionError(err, vc)
+	}
+	if s.offloader != nil {
+		return s.offloader.cleanUp(ctx, res.Attributes, marshaled, s.item.GetPartitionKeyName(), s.item.GetSortKeyName())
+	}
+	return nil
+}
+
+// discardObjects deletes the objects which were uploaded for an item that failed to be written,
+// except the ones which are used by the stored item. The objects are deleted with the item later
+// if this fails.
+func (s *store[I]) discardObjects(ctx context.Context, marshaled map[string]types.AttributeValue) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), discardTimeout)
+	defer cancel()
+	key := make(map[string]types.AttributeValue, 2)
+	for _, name := range []string{s.item.GetPartitionKeyName(), s.item.GetSortKeyName()} {
+		if av, ok := marshaled[name]; ok {
+			key[name] = av
+		}
+	}
+	consistentRead := true
+	res, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &s.tableName,
+		Key:            key,
+		ConsistentRead: &consistentRead,
+	})
+	if err == nil {
+		err = s.offloader.cleanUp(ctx, marshaled, res.Item, s.item.GetPartitionKeyName(), s.item.GetSortKeyName())
+	}
+	if err != nil {
+		log.WithError(err).WithField("table", s.tableName).Warn("failed to discard offloaded objects of failed write")
+	}
+}
+
+// Update applies the update to the item with given key and returns the updated item.
+// If the item is Versioned, the version is incremented.
+func (s *store[I]) Update(ctx context.Context, key Key, update *Update, conditionExpression ...ConditionExpression) (*I, error) {
+	if s.encryptor != nil {
+		if err := checkEncryptedUpdate[I](update); err != nil {
+			return nil, err
+		}
+	}
+	update, vc := prepareVersionedUpdate[I](update)
+	updateExpr, err := update.Build()
+	if err != nil {
+		return nil, err
+	}
+	cond, err := makeWriteCondition(vc, conditionExpression...)
+	if err != nil {
+		return nil, err
+	}
+	op := &dynamodb.UpdateItemInput{
+		TableName:                 &s.tableName,
+		Key:                       makeItemKey[I](key),
+		UpdateExpression:          &updateExpr.Expression,
+		ConditionExpression:       cond.expression,
+		ExpressionAttributeNames:  mergeExpressionNames(updateExpr.ExpressionAttributeNames, cond.names),
+		ExpressionAttributeValues: mergeExpressionValues(updateExpr.ExpressionAttributeValues, cond.values),
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+	if vc != nil {
+		op.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+	res, err := s.client.UpdateItem(ctx, op)
+	if err != nil {
+		return nil, toConditionError(err, vc)
+	}
+	if err := s.restore(ctx, res.Attributes); err != nil {
+		return nil, err
+	}
+	var item I
+	if err := attributevalue.UnmarshalMap(res.Attributes, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
 }
 
 func (s *store[I]) Delete(ctx context.Context, item *I, partitionKey string, sortKey ...string) error {
+	key := makePrimaryKey(item, partitionKey, sortKey...)
 	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &s.tableName,
-		Key:       makePrimaryKey(item, partitionKey, sortKey...),
+		Key:       key,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	return s.deleteObjects(ctx, key)
 }
 
 func (s *store[I]) GetAll(ctx context.Context, partitionKeyVal string) ([]*I, error) {
-	keyCond := fmt.Sprintf("%s = %s", s.item.GetPartitionKeyName(), AttributePartitionKey)
-	res, err := s.client.Query(ctx, &dynamodb.QueryInput{
+	return s.queryAll(ctx, s.makeQueryInput(nil, s.item.GetPartitionKeyName(), partitionKeyVal), 0)
+}
+
+func (s *store[I]) GetAllPage(ctx context.Context, partitionKeyVal string, pageSize int32, pageToken string) (*Page[I], error) {
+	return s.queryPage(ctx, s.makeQueryInput(nil, s.item.GetPartitionKeyName(), partitionKeyVal), pageSize, pageToken)
+}
+
+func (s *store[I]) GetAllFromIndex(ctx context.Context, indexName, partitionKeyName, partitionKeyVal string) ([]*I, error) {
+	return s.queryAll(ctx, s.makeQueryInput(&indexName, partitionKeyName, partitionKeyVal), 0)
+}
+
+func (s *store[I]) GetAllFromIndexPage(
+	ctx context.Context, indexName, partitionKeyName, partitionKeyVal string, pageSize int32, pageToken string,
+) (*Page[I], error) {
+	return s.queryPage(ctx, s.makeQueryInput(&indexName, partitionKeyName, partitionKeyVal), pageSize, pageToken)
+}
+
+func (s *store[I]) makeQueryInput(indexName *string, partitionKeyName, partitionKeyVal string) *dynamodb.QueryInput {
+	keyCond := fmt.Sprintf("%s = %s", partitionKeyName, AttributePartitionKey)
+	return &dynamodb.QueryInput{
 		TableName:              &s.tableName,
+		IndexName:              indexName,
 		KeyConditionExpression: &keyCond,
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			AttributePartitionKey: &types.AttributeValueMemberS{Value: partitionKeyVal},
 		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get all with same partition key: %v", err)
 	}
-	var items []*I
-	if err := attributevalue.UnmarshalListOfMaps(res.Items, &items); err != nil {
+}
+
+func (s *store[I]) Query(ctx context.Context, query *Query) ([]*I, error) {
+	input, err := query.makeInput(s.tableName, s.item)
+	if err != nil {
 		return nil, err
 	}
-	return items, nil
+	return s.queryAll(ctx, input, query.limit)
 }
 
-func (s *store[I]) GetAllFromIndex(ctx context.Context, indexName, partitionKeyName, partitionKeyVal string) ([]*I, error) {
-	keyCond := fmt.Sprintf("%s = %s", partitionKeyName, AttributePartitionKey)
-	res, err := s.client.Query(ctx, &dynamodb.QueryInput{
-		TableName:              &s.tableName,
-		IndexName:              &indexName,
-		KeyConditionExpression: &keyCond,
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			AttributePartitionKey: &types.AttributeValueMemberS{Value: partitionKeyVal},
-		},
-	})
+func (s *store[I]) QueryPage(ctx context.Context, query *Query, pageSize int32, pageToken string) (*Page[I], error) {
+	input, err := query.makeInput(s.tableName, s.item)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all with same partition key: %v", err)
+		return nil, err
 	}
+	return s.queryPage(ctx, input, pageSize, pageToken)
+}
+
+// queryAll follows the last evaluated keys until all items are queried or the limit is reached.
+func (s *store[I]) queryAll(ctx context.Context, input *dynamodb.QueryInput, limit int32) ([]*I, error) {
 	var items []*I
-	if err := attributevalue.UnmarshalListOfMaps(res.Items, &items); err != nil {
+	for {
+		if limit > 0 {
+			remaining := limit - int32(len(items))
+			input.Limit = &remaining
+		}
+		res, err := s.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get all with same partition key: %v", err)
+		}
+		if err := s.restore(ctx, res.Items...); err != nil {
+			return nil, err
+		}
+		pageItems, err := unmarshalItems[I](res.Items)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+		if len(res.LastEvaluatedKey) == 0 || (limit > 0 && int32(len(items)) >= limit) {
+			return items, nil
+		}
+		input.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+}
+
+func (s *store[I]) queryPage(ctx context.Context, input *dynamodb.QueryInput, pageSize int32, pageToken string) (*Page[I], error) {
+	startKey, err := decodePageToken(pageToken)
+	if err != nil {
 		return nil, err
 	}
-	return items, nil
+	input.ExclusiveStartKey = startKey
+	if pageSize > 0 {
+		input.Limit = &pageSize
+	}
+	res, err := s.client.Query(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page with same partition key: %v", err)
+	}
+	if err := s.restore(ctx, res.Items...); err != nil {
+		return nil, err
+	}
+	return makePage[I](res.Items, res.LastEvaluatedKey)
 }
 
+// cacheStripes is the number of the stripes which the cached keys share.
+const cacheStripes = 256
+
+// cacheStripe serializes the cache writes of the keys which share it. Its generation is bumped
+// by every write so that the reads which overlap with a write do not cache the old items.
+type cacheStripe struct {
+	mu         sync.Mutex
+	generation uint64
+}
+
+// cachedStore caches the items and the query results. The writes invalidate all cached
+// query results of the table since any of them can be affected by a write.
 type cachedStore[I Item] struct {
 	cache Cache[I]
+	mode  CacheMode
 	Store[I]
+	gets    singleflight.Group
+	stripes [cacheStripes]cacheStripe
+}
+
+func newCachedStore[I Item](store Store[I], cache Cache[I], mode ...CacheMode) *cachedStore[I] {
+	cs := &cachedStore[I]{cache: cache, Store: store}
+	if mode != nil {
+		cs.mode = mode[0]
+	}
+	return cs
 }
 
+// Get coalesces the concurrent cache misses for the same key into one read. The read is detached
+// from the contexts of the callers so that a canceled caller does not fail the others.
 func (s *cachedStore[I]) Get(ctx context.Context, partitionKey string, sortKey ...string) (*I, error) {
-	if s.cache != nil {
-		it, ok := s.cache.Get(ctx, partitionKey, sortKey...)
-		if ok {
-			return it, nil
+	if s.cache == nil {
+		return s.Store.Get(ctx, partitionKey, sortKey...)
+	}
+
+	it, ok := s.cache.Get(ctx, partitionKey, sortKey...)
+	if ok {
+		if it == nil {
+			return nil, ErrNotFound
+		}
+		return it, nil
+	}
+
+	key := NewKey(partitionKey, sortKey...)
+	generation := s.generation(key)
+	// the reads which start after a write do not join the reads before it
+	flightKey := fmt.Sprintf("%s\x00%s\x00%d", key.PartitionKey, key.SortKey, generation)
+	results := s.gets.DoChan(flightKey, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), coalescedGetTimeout)
+		defer cancel()
+		it, err := s.Store.Get(ctx, partitionKey, sortKey...)
+		if errors.Is(err, ErrNotFound) {
+			s.fill(ctx, key, generation, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.fill(ctx, key, generation, it)
+		return it, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-results:
+		if res.Err != nil {
+			return nil, res.Err
 		}
+		return res.Val.(*I), nil
 	}
+}
 
-	it, err := s.Store.Get(ctx, partitionKey, sortKey...)
+// BatchGet serves the cached items locally and gets only the missing ones from the store.
+func (s *cachedStore[I]) BatchGet(ctx context.Context, keys []Key) ([]*I, error) {
+	if s.cache == nil {
+		return s.Store.BatchGet(ctx, keys)
+	}
+	var (
+		items  []*I
+		misses []Key
+	)
+	for _, key := range uniqueKeys(keys) {
+		it, ok := s.cache.Get(ctx, key.PartitionKey, key.SortKeys()...)
+		if ok {
+			if it != nil {
+				items = append(items, it)
+			}
+			continue
+		}
+		misses = append(misses, key)
+	}
+	if len(misses) == 0 {
+		return items, nil
+	}
+	generations := make(map[Key]uint64, len(misses))
+	for _, key := range misses {
+		generations[key] = s.generation(key)
+	}
+	its, err := s.Store.BatchGet(ctx, misses)
 	if err != nil {
 		return nil, err
 	}
+	found := make(map[Key]bool, len(its))
+	for _, it := range its {
+		key, err := getItemKey(it)
+		if err != nil {
+			return nil, err
+		}
+		found[key] = true
+		s.fill(ctx, key, generations[key], it)
+	}
+	for _, key := range misses {
+		if !found[key] {
+			s.fill(ctx, key, generations[key], nil)
+		}
+	}
+	return append(items, its...), nil
+}
+
+func (s *cachedStore[I]) stripe(key Key) *cacheStripe {
+	h := fnv.New32a()
+	h.Write([]byte(key.PartitionKey))
+	h.Write([]byte{0})
+	h.Write([]byte(key.SortKey))
+	return &s.stripes[h.Sum32()%cacheStripes]
+}
 
+// generation returns the write generation of the key before it is read.
+func (s *cachedStore[I]) generation(key Key) uint64 {
+	stripe := s.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	return stripe.generation
+}
+
+// fill caches the read item, or that it is not found if the item is nil. Nothing is cached if
+// the key was written after the generation was returned, since the read item can be older.
+func (s *cachedStore[I]) fill(ctx context.Context, key Key, generation uint64, it *I) {
+	stripe := s.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	if stripe.generation != generation {
+		return
+	}
+	if it != nil {
+		s.cache.Put(ctx, it, key.PartitionKey, key.SortKeys()...)
+		return
+	}
+	if negativeCache, ok := s.cache.(NegativeCache); ok {
+		negativeCache.PutNotFound(ctx, key.PartitionKey, key.SortKeys()...)
+	}
+}
+
+func (s *cachedStore[I]) Put(ctx context.Context, item *I, conditionExpression ...ConditionExpression) error {
+	err := s.Store.Put(ctx, item, conditionExpression...)
+	s.afterWrite(ctx, item, err)
+	s.invalidateQueries(ctx)
+	return err
+}
+
+func (s *cachedStore[I]) BatchPut(ctx context.Context, items []*I) error {
+	err := s.Store.BatchPut(ctx, items)
+	for _, item := range items {
+		s.afterWrite(ctx, item, err)
+	}
+	s.invalidateQueries(ctx)
+	return err
+}
+
+// Update caches the updated item in the write-through mode.
+func (s *cachedStore[I]) Update(ctx context.Context, key Key, update *Update, conditionExpression ...ConditionExpression) (*I, error) {
+	it, err := s.Store.Update(ctx, key, update, conditionExpression...)
+	if err != nil {
+		s.invalidate(ctx, key)
+	} else {
+		s.afterWrite(ctx, it, nil)
+	}
+	s.invalidateQueries(ctx)
+	return it, err
+}
+
+func (s *cachedStore[I]) Delete(ctx context.Context, item *I, partitionKey string, sortKey ...string) error {
+	err := s.Store.Delete(ctx, item, partitionKey, sortKey...)
+	s.invalidate(ctx, NewKey(partitionKey, sortKey...))
+	s.invalidateQueries(ctx)
+	return err
+}
+
+func (s *cachedStore[I]) BatchDelete(ctx context.Context, keys []Key) error {
+	err := s.Store.BatchDelete(ctx, keys)
+	for _, key := range keys {
+		s.invalidate(ctx, key)
+	}
+	s.invalidateQueries(ctx)
+	return err
+}
+
+// afterWrite caches a copy of the written item in the write-through mode and invalidates it otherwise.
+// The item is always invalidated after failed writes since it is not known what is stored anymore.
+func (s *cachedStore[I]) afterWrite(ctx context.Context, item *I, writeErr error) {
+	if s.cache == nil {
+		return
+	}
+	key, err := getItemKey(item)
+	if err != nil {
+		// the store can not write such items either
+		return
+	}
+	if writeErr != nil || s.mode != CacheWriteThrough {
+		s.invalidate(ctx, key)
+		return
+	}
+	it := *item
+	s.written(ctx, key, &it)
+}
+
+func (s *cachedStore[I]) invalidate(ctx context.Context, key Key) {
 	if s.cache != nil {
-		s.cache.Put(ctx, it, partitionKey, sortKey...)
+		s.written(ctx, key, nil)
 	}
+}
 
-	return it, nil
+// written bumps the generation of the written key and caches the item, or invalidates the key
+// if the item is nil.
+func (s *cachedStore[I]) written(ctx context.Context, key Key, it *I) {
+	stripe := s.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	stripe.generation++
+	if it != nil {
+		s.cache.Put(ctx, it, key.PartitionKey, key.SortKeys()...)
+		return
+	}
+	s.cache.Invalidate(ctx, key.PartitionKey, key.SortKeys()...)
 }
 
-func (s *cachedStore[I]) Put(ctx context.Context, item *I, conditionExpression ...ConditionExpression) error {
-	return s.Store.Put(ctx, item, conditionExpression...)
+// invalidateCommitted invalidates the key which is written in a transaction and the queries in
+// this and the underlying cached stores.
+func (s *cachedStore[I]) invalidateCommitted(ctx context.Context, key Key) {
+	s.invalidate(ctx, key)
+	s.invalidateQueries(ctx)
+	if invalidator, ok := s.Store.(txInvalidator); ok {
+		invalidator.invalidateCommitted(ctx, key)
+	}
+}
+
+func (s *cachedStore[I]) invalidateQueries(ctx context.Context) {
+	if s.cache != nil {
+		s.cache.InvalidateQueries(ctx, makeQueryCacheKey(s.TableName(), ""))
+	}
 }
 
 func (s *cachedStore[I]) GetAll(ctx context.Context, partitionKey string) ([]*I, error) {
@@ -246,7 +828,7 @@ func (s *cachedStore[I]) GetAll(ctx context.Context, partitionKey string) ([]*I,
 }
 
 func (s *cachedStore[I]) GetAllFromIndex(ctx context.Context, indexName, partitionKeyName, partitionKeyVal string) ([]*I, error) {
-	cacheKey := makeQueryCacheKey(indexName, partitionKeyName, partitionKeyVal)
+	cacheKey := makeQueryCacheKey(s.TableName(), indexName, partitionKeyName, partitionKeyVal)
 
 	if s.cache != nil {
 		its, ok := s.cache.GetQuery(ctx, cacheKey)
@@ -267,17 +849,43 @@ func (s *cachedStore[I]) GetAllFromIndex(ctx context.Context, indexName, partiti
 	return its, nil
 }
 
-func makeQueryCacheKey(values ...string) string {
-	return strings.Join(values, "@@")
+// Query caches the query results with a key built from the query. The consistent read
+// queries are not cached.
+func (s *cachedStore[I]) Query(ctx context.Context, query *Query) ([]*I, error) {
+	if s.cache == nil || query.consistentRead {
+		return s.Store.Query(ctx, query)
+	}
+	var item I
+	input, err := query.makeInput(s.TableName(), item)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := makeQueryInputCacheKey(input)
+	if query.limit > 0 {
+		cacheKey = makeQueryCacheKey(cacheKey, fmt.Sprint(query.limit))
+	}
+	its, ok := s.cache.GetQuery(ctx, cacheKey)
+	if ok {
+		return its, nil
+	}
+	its, err = s.Store.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.PutQuery(ctx, cacheKey, its)
+	return its, nil
 }
 
-func (s *cachedStore[I]) Delete(ctx context.Context, item *I, partitionKey string, sortKey ...string) error {
-	return s.Store.Delete(ctx, item, partitionKey, sortKey...)
+// QueryCacheKeySeparator separates the parts of the query cache keys. The first part is the
+// table name so that the caches can index the queries by the table.
+const QueryCacheKeySeparator = "@@"
+
+// makeQueryCacheKey makes a query cache key. The first value should be the table name
+// so that the table queries can be invalidated together.
+func makeQueryCacheKey(values ...string) string {
+	return strings.Join(values, QueryCacheKeySeparator)
 }
 
-func (s *cachedStore[I]) WithCache(cache Cache[I]) Store[I] {
-	return &cachedStore[I]{
-		cache: cache,
-		Store: s, // this allows layering cached stores
-	}
+func (s *cachedStore[I]) WithCache(cache Cache[I], mode ...CacheMode) Store[I] {
+	return newCachedStore[I](s, cache, mode...) // this allows layering cached stores
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -342,3 +343,332 @@ func TestGetAllFromIndex(t *testing.T) {
 	r.NoError(err)
 	r.Len(retItems, 1)
 }
+
+func TestScan_MultiplePages(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	gomock.InOrder(
+		client.EXPECT().Scan(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+				r.Nil(input.ExclusiveStartKey)
+				return &dynamodb.ScanOutput{
+					Items:            []map[string]types.AttributeValue{testFoundItem},
+					LastEvaluatedKey: testBothKeys,
+				}, nil
+			}),
+		client.EXPECT().Scan(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+				r.Equal(testBothKeys, input.ExclusiveStartKey)
+				return &dynamodb.ScanOutput{
+					Items: []map[string]types.AttributeValue{testFoundItem},
+				}, nil
+			}),
+	)
+
+	items, err := store.Scan(context.Background())
+	r.NoError(err)
+	r.Len(items, 2)
+}
+
+func TestScanPage(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	client.EXPECT().Scan(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			r.Nil(input.ExclusiveStartKey)
+			r.Equal(int32(1), *input.Limit)
+			return &dynamodb.ScanOutput{
+				Items:            []map[string]types.AttributeValue{testFoundItem},
+				LastEvaluatedKey: testBothKeys,
+			}, nil
+		})
+	page, err := store.ScanPage(context.Background(), 1, "")
+	r.NoError(err)
+	r.Len(page.Items, 1)
+	r.NotEmpty(page.NextPageToken)
+
+	client.EXPECT().Scan(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			r.Equal(testBothKeys, input.ExclusiveStartKey)
+			return &dynamodb.ScanOutput{}, nil
+		})
+	page, err = store.ScanPage(context.Background(), 1, page.NextPageToken)
+	r.NoError(err)
+	r.Empty(page.Items)
+	r.Empty(page.NextPageToken)
+
+	_, err = store.ScanPage(context.Background(), 1, "not a token")
+	r.ErrorIs(err, dynamo.ErrInvalidPageToken)
+}
+
+func TestParallelScan(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	client.EXPECT().Scan(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			if *input.TotalSegments != 3 {
+				return nil, errors.New("unexpected total segments")
+			}
+			return &dynamodb.ScanOutput{
+				Items: []map[string]types.AttributeValue{testFoundItem},
+			}, nil
+		}).Times(3)
+
+	items, err := store.ParallelScan(context.Background(), 3)
+	r.NoError(err)
+	r.Len(items, 3)
+}
+
+func TestGetAllPage(t *testing.T) {
+	r := require.New(t)
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName)
+
+	gomock.InOrder(
+		client.EXPECT().Query(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+				r.Nil(input.ExclusiveStartKey)
+				r.Equal(int32(10), *input.Limit)
+				return &dynamodb.QueryOutput{
+					Items:            []map[string]types.AttributeValue{testFoundItem},
+					LastEvaluatedKey: testBothKeys,
+				}, nil
+			}),
+		client.EXPECT().Query(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+				r.Equal(testBothKeys, input.ExclusiveStartKey)
+				r.Equal(testIndexName, *input.IndexName)
+				return &dynamodb.QueryOutput{
+					Items: []map[string]types.AttributeValue{testFoundItem},
+				}, nil
+			}),
+	)
+
+	page, err := testItemStore.GetAllPage(context.Background(), testPartitionKeyVal, 10, "")
+	r.NoError(err)
+	r.Len(page.Items, 1)
+	r.NotEmpty(page.NextPageToken)
+
+	// tokens only contain the key so they work across the requests
+	page, err = testItemStore.GetAllFromIndexPage(
+		context.Background(), testIndexName, "pkey", testPartitionKeyVal, 10, page.NextPageToken,
+	)
+	r.NoError(err)
+	r.Len(page.Items, 1)
+	r.Empty(page.NextPageToken)
+}
+
+func TestCacheConsistency_WriteInvalidate(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewInMemoryWithTTL[testItem](time.Minute, time.Minute))
+	ctx := context.Background()
+
+	// the item and the query results are cached after the first reads
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).
+		Return(&dynamodb.GetItemOutput{Item: testFoundItem}, nil).Times(2)
+	client.EXPECT().Query(gomock.Any(), gomock.Any()).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{testFoundItem}}, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+		r.NoError(err)
+		_, err = testItemStore.GetAll(ctx, testPartitionKeyVal)
+		r.NoError(err)
+	}
+
+	// both are read again after the put
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil)
+	r.NoError(testItemStore.Put(ctx, &testItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal}))
+	_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	_, err = testItemStore.GetAll(ctx, testPartitionKeyVal)
+	r.NoError(err)
+
+	// the deleted item is not served from the cache
+	client.EXPECT().DeleteItem(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+	r.NoError(testItemStore.Delete(ctx, &testItem{}, testPartitionKeyVal, testSortKeyVal))
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	_, err = testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.ErrorIs(err, dynamo.ErrNotFound)
+}
+
+func TestCacheConsistency_WriteThrough(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewInMemoryWithTTL[testItem](time.Minute, time.Minute), dynamo.CacheWriteThrough)
+	ctx := context.Background()
+
+	// the written item is served from the cache without any reads
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil)
+	item := &testItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal}
+	r.NoError(testItemStore.Put(ctx, item))
+	retItem, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(item, retItem)
+	r.NotSame(item, retItem)
+
+	// the updated item replaces the cached one
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).
+		Return(&dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+			"pkey": &types.AttributeValueMemberS{Value: testPartitionKeyVal},
+			"skey": &types.AttributeValueMemberS{Value: testSortKeyVal},
+		}}, nil)
+	updated, err := testItemStore.Update(ctx, dynamo.NewKey(testPartitionKeyVal, testSortKeyVal), dynamo.NewUpdate().Set("foo", "bar"))
+	r.NoError(err)
+	retItem, err = testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+	r.Equal(updated, retItem)
+
+	// failed writes invalidate the cached item
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
+	r.Error(testItemStore.Put(ctx, item))
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{Item: testFoundItem}, nil)
+	_, err = testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+}
+
+func TestGet_NegativeCaching(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewLRU[testItem](cache.LRUConfig{Size: 10, NegativeTTL: time.Minute}))
+	ctx := context.Background()
+
+	// only the first lookups hit the table
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+	client.EXPECT().BatchGetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.BatchGetItemOutput{}, nil)
+	for i := 0; i < 2; i++ {
+		_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+		r.ErrorIs(err, dynamo.ErrNotFound)
+		items, err := testItemStore.BatchGet(ctx, []dynamo.Key{dynamo.NewKey("other", testSortKeyVal)})
+		r.NoError(err)
+		r.Empty(items)
+	}
+
+	// writing the item replaces the negative entry
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil)
+	r.NoError(testItemStore.Put(ctx, &testItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal}))
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{Item: testFoundItem}, nil)
+	_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.NoError(err)
+}
+
+func TestGet_Coalescing(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewLRU[testItem](cache.LRUConfig{Size: 10}))
+
+	release := make(chan struct{})
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			<-release
+			return &dynamodb.GetItemOutput{Item: testFoundItem}, nil
+		})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testItemStore.Get(context.Background(), testPartitionKeyVal, testSortKeyVal)
+			r.NoError(err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+}
+
+func TestGet_CacheFillAfterWrite(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewLRU[testItem](cache.LRUConfig{Size: 10}))
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	gomock.InOrder(
+		client.EXPECT().GetItem(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+				close(started)
+				<-release
+				return &dynamodb.GetItemOutput{Item: testFoundItem}, nil
+			}),
+		client.EXPECT().GetItem(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil),
+	)
+	client.EXPECT().DeleteItem(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+	// the item is deleted while it is read
+	read := make(chan error)
+	go func() {
+		_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+		read <- err
+	}()
+	<-started
+	r.NoError(testItemStore.Delete(ctx, &testItem{}, testPartitionKeyVal, testSortKeyVal))
+	close(release)
+	r.NoError(<-read)
+
+	// the old item is not cached
+	_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.ErrorIs(err, dynamo.ErrNotFound)
+}
+
+func TestGet_CoalescingCanceled(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testItemStore := dynamo.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewLRU[testItem](cache.LRUConfig{Size: 10}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client.EXPECT().GetItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			close(started)
+			select {
+			case <-release:
+				return &dynamodb.GetItemOutput{Item: testFoundItem}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		})
+
+	// the first caller gives up while the read is in progress
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := testItemStore.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+		canceled <- err
+	}()
+	<-started
+	waited := make(chan error)
+	go func() {
+		_, err := testItemStore.Get(context.Background(), testPartitionKeyVal, testSortKeyVal)
+		waited <- err
+	}()
+	cancel()
+	r.ErrorIs(<-canceled, context.Canceled)
+
+	// the other caller still gets the item
+	close(release)
+	r.NoError(<-waited)
+}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forta-network/core-go/store/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dynamotypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Change event names
+const (
+	EventInsert = types.OperationTypeInsert
+	EventModify = types.OperationTypeModify
+	EventRemove = types.OperationTypeRemove
+)
+
+// Change is a change of an item. The images are nil if the stream view type does not contain them.
+type Change[I dynamo.Item] struct {
+	EventID        string
+	EventName      types.OperationType
+	ShardID        string
+	SequenceNumber string
+	// CreatedAt is the approximate time of the change.
+	CreatedAt time.Time
+	// Keys contains only the key attributes of the item.
+	Keys *I
+	// OldItem is also nil if its offloaded attributes are already deleted by the new write.
+	OldItem *I
+	NewItem *I
+}
+
+// unmarshaler decodes the images of the tables which do not offload or encrypt the attributes.
+type unmarshaler[I dynamo.Item] struct{}
+
+func (unmarshaler[I]) DecodeItem(ctx context.Context, attributes map[string]dynamotypes.AttributeValue) (*I, error) {
+	var item I
+	if err := attributevalue.UnmarshalMap(attributes, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func makeChange[I dynamo.Item](ctx context.Context, decoder dynamo.ItemDecoder[I], shardID string, record types.Record) (*Change[I], error) {
+	change := &Change[I]{
+		EventID:   strValue(record.EventID),
+		EventName: record.EventName,
+		ShardID:   shardID,
+	}
+	if record.Dynamodb == nil {
+		return change, nil
+	}
+	change.SequenceNumber = strValue(record.Dynamodb.SequenceNumber)
+	if record.Dynamodb.ApproximateCreationDateTime != nil {
+		change.CreatedAt = *record.Dynamodb.ApproximateCreationDateTime
+	}
+	var err error
+	if change.Keys, err = decodeImage(ctx, unmarshaler[I]{}, record.Dynamodb.Keys); err != nil {
+		return nil, err
+	}
+	change.OldItem, err = decodeImage(ctx, decoder, record.Dynamodb.OldImage)
+	var noSuchKeyErr *s3types.NoSuchKey
+	if errors.As(err, &noSuchKeyErr) {
+		log.WithError(err).WithField("shard", shardID).WithField("event", change.EventID).
+			Warn("offloaded attributes of old image are deleted")
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if change.NewItem, err = decodeImage(ctx, decoder, record.Dynamodb.NewImage); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func decodeImage[I dynamo.Item](ctx context.Context, decoder dynamo.ItemDecoder[I], image map[string]types.AttributeValue) (*I, error) {
+	if image == nil {
+		return nil, nil
+	}
+	converted, err := attributevalue.FromDynamoDBStreamsMap(image)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.DecodeItem(ctx, converted)
+}
+
+func strValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
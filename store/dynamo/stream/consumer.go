@@ -0,0 +1,231 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/internal/shards"
+	"github.com/forta-network/core-go/store/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Defaults
+const (
+	DefaultPollInterval         = time.Second
+	DefaultShardRefreshInterval = time.Second * 30
+)
+
+// Handler handles the changes which are read from a shard. The changes of an item are delivered
+// in order. If the handler returns an error, the same changes are delivered again after the poll
+// interval so the handlers should be idempotent.
+type Handler[I dynamo.Item] func(ctx context.Context, changes []*Change[I]) error
+
+// Config configures a consumer.
+type Config struct {
+	// StreamARN is the stream to consume. The latest stream of the table is consumed if it is empty.
+	StreamARN string
+	TableName string
+	// CheckpointTableName is the table which stores the progress. It is created if it does not exist.
+	CheckpointTableName string
+	// ConsumerName identifies the consumer in the checkpoints. Only one instance of a consumer
+	// should run at a time. The lock package can be used for electing one.
+	ConsumerName string
+	// StartFromLatest skips the existing records of the shards which have no checkpoints. The child
+	// shards of the consumed shards are still consumed from their oldest records.
+	StartFromLatest bool
+	// BatchSize is the maximum number of records per handler call. It is 1000 if zero.
+	BatchSize int32
+	// PollInterval is the wait time after reading no records or failing.
+	PollInterval time.Duration
+	// ShardRefreshInterval is how often the new shards are discovered.
+	ShardRefreshInterval time.Duration
+}
+
+// Consumer consumes a DynamoDB stream and delivers the changes with at-least-once semantics.
+// The child shards are consumed after their parents are finished.
+type Consumer[I dynamo.Item] struct {
+	streams aws.DynamoDBStreamsClient
+	client  aws.DynamoDBClient
+	config  Config
+	handler Handler[I]
+	decoder dynamo.ItemDecoder[I]
+}
+
+// NewConsumer creates a new consumer. The images are decoded with the given decoder, which should
+// be the store of the table if it offloads or encrypts the attributes. Otherwise, they are only
+// unmarshaled.
+func NewConsumer[I dynamo.Item](
+	streams aws.DynamoDBStreamsClient, client aws.DynamoDBClient, config Config, handler Handler[I],
+	decoder ...dynamo.ItemDecoder[I],
+) (*Consumer[I], error) {
+	if len(config.StreamARN) == 0 && len(config.TableName) == 0 {
+		return nil, errors.New("stream ARN or table name is required")
+	}
+	if len(config.CheckpointTableName) == 0 || len(config.ConsumerName) == 0 {
+		return nil, errors.New("checkpoint table name and consumer name are required")
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.ShardRefreshInterval == 0 {
+		config.ShardRefreshInterval = DefaultShardRefreshInterval
+	}
+	c := &Consumer[I]{
+		streams: streams,
+		client:  client,
+		config:  config,
+		handler: handler,
+		decoder: unmarshaler[I]{},
+	}
+	if decoder != nil {
+		c.decoder = decoder[0]
+	}
+	return c, nil
+}
+
+// CheckpointTableSchema returns the schema of the checkpoint table.
+func CheckpointTableSchema(tableName string) *dynamo.TableSchema {
+	return shards.CheckpointTableSchema(tableName)
+}
+
+// Run consumes the stream until the context is canceled or a record cannot be converted to a change.
+// It returns nil when the context is canceled.
+func (c *Consumer[I]) Run(ctx context.Context) error {
+	if err := dynamo.EnsureTable(ctx, c.client, CheckpointTableSchema(c.config.CheckpointTableName)); err != nil {
+		return err
+	}
+	streamARN, err := c.streamARN(ctx)
+	if err != nil {
+		return err
+	}
+	return shards.NewConsumer[*Change[I]](c.client, &shardStream[I]{
+		streams:   c.streams,
+		streamARN: streamARN,
+		batchSize: c.config.BatchSize,
+		decoder:   c.decoder,
+	}, shards.Config{
+		StreamName:           streamARN,
+		CheckpointTableName:  c.config.CheckpointTableName,
+		ConsumerName:         c.config.ConsumerName,
+		StartFromLatest:      c.config.StartFromLatest,
+		PollInterval:         c.config.PollInterval,
+		ShardRefreshInterval: c.config.ShardRefreshInterval,
+	}, shards.Handler[*Change[I]](c.handler)).Run(ctx)
+}
+
+func (c *Consumer[I]) streamARN(ctx context.Context) (string, error) {
+	if len(c.config.StreamARN) > 0 {
+		return c.config.StreamARN, nil
+	}
+	res, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &c.config.TableName})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table %s: %w", c.config.TableName, err)
+	}
+	if res.Table.LatestStreamArn == nil {
+		return "", fmt.Errorf("table %s has no stream", c.config.TableName)
+	}
+	return *res.Table.LatestStreamArn, nil
+}
+
+// shardStream reads the shards of a DynamoDB stream for the shard consumer.
+type shardStream[I dynamo.Item] struct {
+	streams   aws.DynamoDBStreamsClient
+	streamARN string
+	batchSize int32
+	decoder   dynamo.ItemDecoder[I]
+}
+
+func (s *shardStream[I]) ListShards(ctx context.Context) ([]shards.Shard, error) {
+	var (
+		shardList    []shards.Shard
+		startShardID *string
+	)
+	for {
+		res, err := s.streams.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn:             &s.streamARN,
+			ExclusiveStartShardId: startShardID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, shard := range res.StreamDescription.Shards {
+			shardList = append(shardList, makeShard(shard))
+		}
+		startShardID = res.StreamDescription.LastEvaluatedShardId
+		if startShardID == nil {
+			return shardList, nil
+		}
+	}
+}
+
+func makeShard(shard types.Shard) shards.Shard {
+	converted := shards.Shard{
+		ID:     *shard.ShardId,
+		Closed: shard.SequenceNumberRange != nil && shard.SequenceNumberRange.EndingSequenceNumber != nil,
+	}
+	if shard.ParentShardId != nil {
+		converted.ParentIDs = []string{*shard.ParentShardId}
+	}
+	return converted
+}
+
+// ShardIterator returns an iterator after the sequence number. If the sequence number is empty
+// or trimmed, the iterator starts from the oldest or the latest record.
+func (s *shardStream[I]) ShardIterator(ctx context.Context, shardID, sequenceNumber string, latest bool) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         &s.streamARN,
+		ShardId:           &shardID,
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	}
+	switch {
+	case len(sequenceNumber) > 0:
+		input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = &sequenceNumber
+	case latest:
+		input.ShardIteratorType = types.ShardIteratorTypeLatest
+	}
+	res, err := s.streams.GetShardIterator(ctx, input)
+	var trimmedErr *types.TrimmedDataAccessException
+	if errors.As(err, &trimmedErr) && len(sequenceNumber) > 0 {
+		log.WithField("stream", s.streamARN).WithField("shard", shardID).Warn("checkpoint is trimmed, some changes are lost")
+		return s.ShardIterator(ctx, shardID, "", false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.ShardIterator, nil
+}
+
+func (s *shardStream[I]) GetRecords(ctx context.Context, shardID string, iterator *string) (*shards.Records[*Change[I]], error) {
+	input := &dynamodbstreams.GetRecordsInput{ShardIterator: iterator}
+	if s.batchSize > 0 {
+		input.Limit = &s.batchSize
+	}
+	res, err := s.streams.GetRecords(ctx, input)
+	var expiredErr *types.ExpiredIteratorException
+	if errors.As(err, &expiredErr) {
+		return nil, fmt.Errorf("%w: %v", shards.ErrExpiredIterator, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := &shards.Records[*Change[I]]{NextIterator: res.NextShardIterator}
+	for _, record := range res.Records {
+		change, err := makeChange(ctx, s.decoder, shardID, record)
+		if err != nil {
+			return nil, &shards.RecordError{
+				Err: fmt.Errorf("failed to convert record %s of shard %s: %w", strValue(record.EventID), shardID, err),
+			}
+		}
+		records.Records = append(records.Records, change)
+		records.SequenceNumber = change.SequenceNumber
+	}
+	return records, nil
+}
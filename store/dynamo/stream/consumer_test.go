@@ -0,0 +1,350 @@
+package stream_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/internal/shards/shardstest"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+	"github.com/forta-network/core-go/store/dynamo/stream"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamotypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testStreamARN           = "arn:aws:dynamodb:us-east-1:000000000000:table/test-table/stream/1"
+	testCheckpointTableName = "test-checkpoints"
+	testConsumerName        = "test-consumer"
+)
+
+type testItem struct {
+	Pkey  string `dynamodbav:"pkey"`
+	Value string `dynamodbav:"value"`
+}
+
+func (testItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (testItem) GetSortKeyName() string {
+	return ""
+}
+
+func testRecord(eventName types.OperationType, sequenceNumber, pkey string, oldValue, newValue string) types.Record {
+	keys := map[string]types.AttributeValue{
+		"pkey": &types.AttributeValueMemberS{Value: pkey},
+	}
+	image := func(value string) map[string]types.AttributeValue {
+		if len(value) == 0 {
+			return nil
+		}
+		return map[string]types.AttributeValue{
+			"pkey":  &types.AttributeValueMemberS{Value: pkey},
+			"value": &types.AttributeValueMemberS{Value: value},
+		}
+	}
+	return types.Record{
+		EventID:   aws.String("event-" + sequenceNumber),
+		EventName: eventName,
+		Dynamodb: &types.StreamRecord{
+			SequenceNumber: aws.String(sequenceNumber),
+			Keys:           keys,
+			OldImage:       image(oldValue),
+			NewImage:       image(newValue),
+		},
+	}
+}
+
+func testShard(id string, parentID string, closed bool) types.Shard {
+	shard := types.Shard{
+		ShardId:             aws.String(id),
+		SequenceNumberRange: &types.SequenceNumberRange{StartingSequenceNumber: aws.String("0")},
+	}
+	if len(parentID) > 0 {
+		shard.ParentShardId = aws.String(parentID)
+	}
+	if closed {
+		shard.SequenceNumberRange.EndingSequenceNumber = aws.String("999")
+	}
+	return shard
+}
+
+// expectStream sets up a stream which has a closed parent shard and an open child shard.
+func expectStream(streams *mock_aws.MockDynamoDBStreamsClient, records map[string][]types.Record) {
+	streams.EXPECT().DescribeStream(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *dynamodbstreams.DescribeStreamInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+			// paginated
+			if input.ExclusiveStartShardId == nil {
+				return &dynamodbstreams.DescribeStreamOutput{StreamDescription: &types.StreamDescription{
+					Shards:               []types.Shard{testShard("child", "parent", false)},
+					LastEvaluatedShardId: aws.String("child"),
+				}}, nil
+			}
+			return &dynamodbstreams.DescribeStreamOutput{StreamDescription: &types.StreamDescription{
+				Shards: []types.Shard{testShard("parent", "trimmed", true)},
+			}}, nil
+		}).AnyTimes()
+
+	streams.EXPECT().GetShardIterator(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *dynamodbstreams.GetShardIteratorInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+			iterator := *input.ShardId + "/" + string(input.ShardIteratorType)
+			if input.SequenceNumber != nil {
+				iterator += "/" + *input.SequenceNumber
+			}
+			return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(iterator)}, nil
+		}).AnyTimes()
+
+	streams.EXPECT().GetRecords(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *dynamodbstreams.GetRecordsInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+			iterator := *input.ShardIterator
+			if iterator == "parent/TRIM_HORIZON" {
+				return &dynamodbstreams.GetRecordsOutput{Records: records[iterator]}, nil
+			}
+			// the open child shard has no more records after the first read
+			return &dynamodbstreams.GetRecordsOutput{
+				Records:           records[iterator],
+				NextShardIterator: aws.String("child/empty"),
+			}, nil
+		}).AnyTimes()
+}
+
+func newTestConsumer(t *testing.T, streams *mock_aws.MockDynamoDBStreamsClient, client *memdb.Client, handler *shardstest.Handler[*stream.Change[testItem]]) *stream.Consumer[testItem] {
+	consumer, err := stream.NewConsumer[testItem](streams, client, stream.Config{
+		StreamARN:            testStreamARN,
+		CheckpointTableName:  testCheckpointTableName,
+		ConsumerName:         testConsumerName,
+		PollInterval:         time.Millisecond,
+		ShardRefreshInterval: time.Millisecond * 10,
+	}, handler.Handle)
+	require.NoError(t, err)
+	return consumer
+}
+
+func TestConsumer(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	streams := mock_aws.NewMockDynamoDBStreamsClient(ctrl)
+	client := memdb.NewClient()
+
+	expectStream(streams, map[string][]types.Record{
+		"parent/TRIM_HORIZON": {
+			testRecord(stream.EventInsert, "1", "a", "", "v1"),
+			testRecord(stream.EventModify, "2", "a", "v1", "v2"),
+		},
+		"child/TRIM_HORIZON": {
+			testRecord(stream.EventRemove, "3", "a", "v2", ""),
+		},
+		"child/AFTER_SEQUENCE_NUMBER/3": {
+			testRecord(stream.EventInsert, "4", "b", "", "v1"),
+		},
+	})
+
+	// the first delivery fails and it is retried
+	handler := shardstest.NewHandler[*stream.Change[testItem]](1)
+	cancel, errCh := shardstest.Run(newTestConsumer(t, streams, client, handler))
+	<-handler.Handled
+	<-handler.Handled
+	cancel()
+	r.NoError(<-errCh)
+
+	r.Len(handler.Records, 3)
+	r.Equal(stream.EventInsert, handler.Records[0].EventName)
+	r.Nil(handler.Records[0].OldItem)
+	r.Equal(&testItem{Pkey: "a", Value: "v1"}, handler.Records[0].NewItem)
+	r.Equal(&testItem{Pkey: "a", Value: "v1"}, handler.Records[1].OldItem)
+	r.Equal(&testItem{Pkey: "a", Value: "v2"}, handler.Records[1].NewItem)
+	r.Equal("parent", handler.Records[1].ShardID)
+	r.Equal(stream.EventRemove, handler.Records[2].EventName)
+	r.Equal(&testItem{Pkey: "a"}, handler.Records[2].Keys)
+	r.Nil(handler.Records[2].NewItem)
+	r.Equal("child", handler.Records[2].ShardID)
+
+	// continues from the checkpoints
+	handler = shardstest.NewHandler[*stream.Change[testItem]](0)
+	cancel, errCh = shardstest.Run(newTestConsumer(t, streams, client, handler))
+	<-handler.Handled
+	cancel()
+	r.NoError(<-errCh)
+
+	r.Len(handler.Records, 1)
+	r.Equal(&testItem{Pkey: "b", Value: "v1"}, handler.Records[0].NewItem)
+}
+
+func TestConsumer_NoStream(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	client := memdb.NewClient()
+	memdb.NewStore[testItem](client, "test-table")
+
+	_, err := stream.NewConsumer[testItem](mock_aws.NewMockDynamoDBStreamsClient(ctrl), client, stream.Config{}, nil)
+	r.Error(err)
+
+	consumer, err := stream.NewConsumer[testItem](mock_aws.NewMockDynamoDBStreamsClient(ctrl), client, stream.Config{
+		TableName:           "test-table",
+		CheckpointTableName: testCheckpointTableName,
+		ConsumerName:        testConsumerName,
+	}, nil)
+	r.NoError(err)
+	r.Error(consumer.Run(context.Background()))
+
+	// the checkpoint table is created
+	_, err = client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String(testCheckpointTableName)})
+	r.NoError(err)
+}
+
+func TestConsumer_StartFromLatest(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	streams := mock_aws.NewMockDynamoDBStreamsClient(ctrl)
+	client := memdb.NewClient()
+
+	// the old shard is closed and the child shard is created after the parent shard is started
+	streams.EXPECT().DescribeStream(gomock.Any(), gomock.Any()).Return(&dynamodbstreams.DescribeStreamOutput{
+		StreamDescription: &types.StreamDescription{Shards: []types.Shard{
+			testShard("old", "", true),
+			testShard("parent", "old", false),
+			testShard("child", "parent", false),
+		}},
+	}, nil).AnyTimes()
+	var (
+		mu        sync.Mutex
+		iterators []string
+	)
+	streams.EXPECT().GetShardIterator(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *dynamodbstreams.GetShardIteratorInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+			iterator := *input.ShardId + "/" + string(input.ShardIteratorType)
+			mu.Lock()
+			iterators = append(iterators, iterator)
+			mu.Unlock()
+			return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(iterator)}, nil
+		}).AnyTimes()
+	streams.EXPECT().GetRecords(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *dynamodbstreams.GetRecordsInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+			switch *input.ShardIterator {
+			case "parent/LATEST":
+				// the parent shard is closed after a record
+				return &dynamodbstreams.GetRecordsOutput{Records: []types.Record{testRecord(stream.EventInsert, "1", "a", "", "v1")}}, nil
+			case "child/TRIM_HORIZON":
+				return &dynamodbstreams.GetRecordsOutput{
+					Records:           []types.Record{testRecord(stream.EventModify, "2", "a", "v1", "v2")},
+					NextShardIterator: aws.String("child/empty"),
+				}, nil
+			}
+			return &dynamodbstreams.GetRecordsOutput{NextShardIterator: input.ShardIterator}, nil
+		}).AnyTimes()
+
+	handler := shardstest.NewHandler[*stream.Change[testItem]](0)
+	consumer, err := stream.NewConsumer[testItem](streams, client, stream.Config{
+		StreamARN:            testStreamARN,
+		CheckpointTableName:  testCheckpointTableName,
+		ConsumerName:         testConsumerName,
+		StartFromLatest:      true,
+		PollInterval:         time.Millisecond,
+		ShardRefreshInterval: time.Millisecond * 10,
+	}, handler.Handle)
+	r.NoError(err)
+	cancel, errCh := shardstest.Run(consumer)
+	<-handler.Handled
+	<-handler.Handled
+	cancel()
+	r.NoError(<-errCh)
+
+	// the closed shard is skipped and the child of the consumed shard is consumed from the oldest record
+	r.Len(handler.Records, 2)
+	r.Equal("parent", handler.Records[0].ShardID)
+	r.Equal("child", handler.Records[1].ShardID)
+	mu.Lock()
+	defer mu.Unlock()
+	r.Equal([]string{"parent/LATEST", "child/TRIM_HORIZON"}, iterators)
+}
+
+type testSecretItem struct {
+	Pkey   string `dynamodbav:"pkey"`
+	Secret string `dynamodbav:"secret" encrypted:"true"`
+}
+
+func (testSecretItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (testSecretItem) GetSortKeyName() string {
+	return ""
+}
+
+// toStreamImage converts the stored item to a stream image.
+func toStreamImage(item map[string]dynamotypes.AttributeValue) map[string]types.AttributeValue {
+	image := make(map[string]types.AttributeValue)
+	for name, av := range item {
+		switch v := av.(type) {
+		case *dynamotypes.AttributeValueMemberS:
+			image[name] = &types.AttributeValueMemberS{Value: v.Value}
+		case *dynamotypes.AttributeValueMemberB:
+			image[name] = &types.AttributeValueMemberB{Value: v.Value}
+		case *dynamotypes.AttributeValueMemberM:
+			image[name] = &types.AttributeValueMemberM{Value: toStreamImage(v.Value)}
+		}
+	}
+	return image
+}
+
+func TestConsumer_EncryptedStore(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	streams := mock_aws.NewMockDynamoDBStreamsClient(ctrl)
+	client := memdb.NewClient()
+
+	memdb.NewStore[testSecretItem](client, "test-table")
+	provider, err := dynamo.NewLocalKeyProvider("key1", map[string][]byte{"key1": bytes.Repeat([]byte{1}, 32)})
+	r.NoError(err)
+	store := dynamo.NewEncryptedStore[testSecretItem](client, "test-table", dynamo.EncryptionConfig{KeyProvider: provider})
+	r.NoError(store.Put(ctx, &testSecretItem{Pkey: "a", Secret: "s1"}))
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key:       map[string]dynamotypes.AttributeValue{"pkey": &dynamotypes.AttributeValueMemberS{Value: "a"}},
+	})
+	r.NoError(err)
+
+	record := testRecord(stream.EventInsert, "1", "a", "", "")
+	record.Dynamodb.NewImage = toStreamImage(res.Item)
+	expectStream(streams, map[string][]types.Record{
+		"parent/TRIM_HORIZON": {record},
+	})
+	newConsumer := func(checkpointTableName string, handler *shardstest.Handler[*stream.Change[testSecretItem]], decoder ...dynamo.ItemDecoder[testSecretItem]) *stream.Consumer[testSecretItem] {
+		consumer, err := stream.NewConsumer[testSecretItem](streams, client, stream.Config{
+			StreamARN:            testStreamARN,
+			CheckpointTableName:  checkpointTableName,
+			ConsumerName:         testConsumerName,
+			PollInterval:         time.Millisecond,
+			ShardRefreshInterval: time.Millisecond * 10,
+		}, handler.Handle, decoder...)
+		r.NoError(err)
+		return consumer
+	}
+
+	// the encrypted attributes cannot be unmarshaled without the store
+	handler := shardstest.NewHandler[*stream.Change[testSecretItem]](0)
+	_, errCh := shardstest.Run(newConsumer("checkpoints-1", handler))
+	r.Error(<-errCh)
+	r.Empty(handler.Records)
+
+	// the store decrypts them
+	handler = shardstest.NewHandler[*stream.Change[testSecretItem]](0)
+	cancel, errCh := shardstest.Run(newConsumer("checkpoints-2", handler, store))
+	<-handler.Handled
+	cancel()
+	r.NoError(<-errCh)
+	r.Len(handler.Records, 1)
+	r.Equal(&testSecretItem{Pkey: "a", Secret: "s1"}, handler.Records[0].NewItem)
+}
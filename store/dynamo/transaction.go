@@ -0,0 +1,259 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/core-go/aws"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB limit for the number of operations per transaction
+const transactionLimit = 100
+
+// Transaction cancellation reason codes
+const (
+	CancellationReasonNone                = "None"
+	CancellationReasonConditionalCheck    = "ConditionalCheckFailed"
+	CancellationReasonTransactionConflict = "TransactionConflict"
+)
+
+// TxOp is a write operation in a transaction.
+type TxOp struct {
+	item types.TransactWriteItem
+	err  error
+	// committed is called after the transaction is committed.
+	committed func(ctx context.Context)
+}
+
+// txInvalidator is implemented by the cached stores which invalidate the keys written in
+// the transactions.
+type txInvalidator interface {
+	invalidateCommitted(ctx context.Context, key Key)
+}
+
+// onCommit adds a function which is called after the transaction is committed.
+func (op TxOp) onCommit(fn func(ctx context.Context)) TxOp {
+	prev := op.committed
+	op.committed = func(ctx context.Context) {
+		if prev != nil {
+			prev(ctx)
+		}
+		fn(ctx)
+	}
+	return op
+}
+
+// invalidatesOnCommit invalidates the written key in the caches of the store after the
+// transaction is committed.
+func invalidatesOnCommit[I Item](op TxOp, store Store[I], key Key) TxOp {
+	invalidator, ok := store.(txInvalidator)
+	if !ok || op.err != nil {
+		return op
+	}
+	return op.onCommit(func(ctx context.Context) {
+		invalidator.invalidateCommitted(ctx, key)
+	})
+}
+
+// TxPut creates an operation which puts the item to the store. If the item is Versioned,
+// the version is incremented and the operation is conditioned on the previous version.
+// The version of the item is incremented only after the transaction is committed, so the
+// operation can be created again if the transaction fails.
+func TxPut[I Item](store Store[I], item *I, conditionExpression ...ConditionExpression) TxOp {
+	if names := encryptedAttributes[I](); len(names) > 0 {
+		return TxOp{err: fmt.Errorf("%w %s cannot be written in a transaction", ErrEncryptedAttribute, names[0])}
+	}
+	vc, restoreVersion := prepareVersionedPut(item)
+	marshaled, err := attributevalue.MarshalMap(item)
+	restoreVersion()
+	if err != nil {
+		return TxOp{err: err}
+	}
+	cond, err := makeWriteCondition(vc, conditionExpression...)
+	if err != nil {
+		return TxOp{err: err}
+	}
+	op := TxOp{item: types.TransactWriteItem{Put: &types.Put{
+		TableName:                 strPtr(store.TableName()),
+		Item:                      marshaled,
+		ConditionExpression:       cond.expression,
+		ExpressionAttributeNames:  cond.names,
+		ExpressionAttributeValues: cond.values,
+	}}}
+	if vc != nil {
+		op = op.onCommit(func(ctx context.Context) {
+			any(item).(Versioned).SetVersion(vc.prevVersion + 1)
+		})
+	}
+	if key, err := getItemKey(item); err == nil {
+		op = invalidatesOnCommit(op, store, key)
+	}
+	return op
+}
+
+// TxUpdate creates an operation which updates the item with given key in the store.
+// If the item is Versioned, the version is incremented.
+func TxUpdate[I Item](store Store[I], key Key, update *Update, conditionExpression ...ConditionExpression) TxOp {
+	if err := checkEncryptedUpdate[I](update); err != nil {
+		return TxOp{err: err}
+	}
+	update, vc := prepareVersionedUpdate[I](update)
+	updateExpr, err := update.Build()
+	if err != nil {
+		return TxOp{err: err}
+	}
+	cond, err := makeWriteCondition(vc, conditionExpression...)
+	if err != nil {
+		return TxOp{err: err}
+	}
+	op := TxOp{item: types.TransactWriteItem{Update: &types.Update{
+		TableName:                 strPtr(store.TableName()),
+		Key:                       makeItemKey[I](key),
+		UpdateExpression:          &updateExpr.Expression,
+		ConditionExpression:       cond.expression,
+		ExpressionAttributeNames:  mergeExpressionNames(updateExpr.ExpressionAttributeNames, cond.names),
+		ExpressionAttributeValues: mergeExpressionValues(updateExpr.ExpressionAttributeValues, cond.values),
+	}}}
+	return invalidatesOnCommit(op, store, key)
+}
+
+// TxDelete creates an operation which deletes the item with given key from the store.
+func TxDelete[I Item](store Store[I], key Key, conditionExpression ...ConditionExpression) TxOp {
+	op := &types.Delete{
+		TableName: strPtr(store.TableName()),
+		Key:       makeItemKey[I](key),
+	}
+	if conditionExpression != nil {
+		op.ConditionExpression = &conditionExpression[0].Expression
+		op.ExpressionAttributeValues = conditionExpression[0].ExpressionAttributeValues
+	}
+	return invalidatesOnCommit(TxOp{item: types.TransactWriteItem{Delete: op}}, store, key)
+}
+
+// TxConditionCheck creates an operation which only checks the condition on the item with given key
+// without modifying it.
+func TxConditionCheck[I Item](store Store[I], key Key, conditionExpression ConditionExpression) TxOp {
+	return TxOp{item: types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+		TableName:                 strPtr(store.TableName()),
+		Key:                       makeItemKey[I](key),
+		ConditionExpression:       &conditionExpression.Expression,
+		ExpressionAttributeValues: conditionExpression.ExpressionAttributeValues,
+	}}}
+}
+
+// Transaction collects write operations from one or more stores and commits them atomically.
+// The written items and the query results of the cached stores are invalidated after the commit.
+type Transaction struct {
+	client aws.DynamoDBClient
+	ops    []TxOp
+}
+
+// NewTransaction creates a new transaction.
+func NewTransaction(client aws.DynamoDBClient) *Transaction {
+	return &Transaction{client: client}
+}
+
+// Add adds operations to the transaction.
+func (tx *Transaction) Add(ops ...TxOp) *Transaction {
+	tx.ops = append(tx.ops, ops...)
+	return tx
+}
+
+// Commit commits all operations. If the transaction is canceled, the returned error is
+// a *TransactionCanceledError which contains the reasons in the order of the operations.
+func (tx *Transaction) Commit(ctx context.Context) error {
+	if len(tx.ops) == 0 {
+		return nil
+	}
+	if len(tx.ops) > transactionLimit {
+		return fmt.Errorf("too many transaction operations: %d > %d", len(tx.ops), transactionLimit)
+	}
+	items := make([]types.TransactWriteItem, len(tx.ops))
+	for i, op := range tx.ops {
+		if op.err != nil {
+			return fmt.Errorf("invalid transaction operation %d: %w", i, op.err)
+		}
+		items[i] = op.item
+	}
+	_, err := tx.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	var canceledErr *types.TransactionCanceledException
+	if errors.As(err, &canceledErr) {
+		return newTransactionCanceledError(canceledErr)
+	}
+	if err != nil {
+		return err
+	}
+	for _, op := range tx.ops {
+		if op.committed != nil {
+			op.committed(ctx)
+		}
+	}
+	return nil
+}
+
+// CancellationReason is the reason why an operation caused the transaction to be canceled.
+type CancellationReason struct {
+	Code    string
+	Message string
+}
+
+// TransactionCanceledError is returned when a transaction is canceled.
+type TransactionCanceledError struct {
+	// Reasons has one entry per operation. The operations which did not
+	// cause the cancellation have the code "None".
+	Reasons []CancellationReason
+	err     error
+}
+
+func newTransactionCanceledError(err *types.TransactionCanceledException) *TransactionCanceledError {
+	canceledErr := &TransactionCanceledError{
+		Reasons: make([]CancellationReason, len(err.CancellationReasons)),
+		err:     err,
+	}
+	for i, reason := range err.CancellationReasons {
+		if reason.Code != nil {
+			canceledErr.Reasons[i].Code = *reason.Code
+		}
+		if reason.Message != nil {
+			canceledErr.Reasons[i].Message = *reason.Message
+		}
+	}
+	return canceledErr
+}
+
+// Failed returns the indexes of the operations which caused the cancellation.
+func (e *TransactionCanceledError) Failed() []int {
+	var failed []int
+	for i, reason := range e.Reasons {
+		if len(reason.Code) > 0 && reason.Code != CancellationReasonNone {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+// Error implements the error interface.
+func (e *TransactionCanceledError) Error() string {
+	var reasons []string
+	for _, i := range e.Failed() {
+		reasons = append(reasons, fmt.Sprintf("op %d: %s", i, e.Reasons[i].Code))
+	}
+	return fmt.Sprintf("transaction canceled (%s)", strings.Join(reasons, ", "))
+}
+
+// Unwrap returns the original error.
+func (e *TransactionCanceledError) Unwrap() error {
+	return e.err
+}
+
+func strPtr(s string) *string {
+	return &s
+}
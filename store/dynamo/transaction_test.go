@@ -0,0 +1,164 @@
+package dynamo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/cache"
+	"github.com/forta-network/core-go/store/dynamo/memdb"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testOtherItem struct {
+	ID string `dynamodbav:"id"`
+}
+
+func (item testOtherItem) GetPartitionKeyName() string {
+	return "id"
+}
+
+func (item testOtherItem) GetSortKeyName() string {
+	return ""
+}
+
+func TestTransaction(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+	otherStore := dynamo.NewStore[testOtherItem](client, "other-table")
+
+	cond := dynamo.ConditionExpression{
+		Expression: "attribute_exists(pkey)",
+	}
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+			items := input.TransactItems
+			r.Len(items, 4)
+
+			r.Equal(testTableName, *items[0].Put.TableName)
+			r.Equal(testBothKeys, items[0].Put.Item)
+			r.Equal(cond.Expression, *items[0].Put.ConditionExpression)
+
+			r.Equal("other-table", *items[1].Update.TableName)
+			r.Equal(map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: "1"},
+			}, items[1].Update.Key)
+			r.Equal("SET #u0 = :u0", *items[1].Update.UpdateExpression)
+			r.Equal(map[string]string{"#u0": "name"}, items[1].Update.ExpressionAttributeNames)
+			r.Len(items[1].Update.ExpressionAttributeValues, 1)
+
+			r.Equal(testBothKeys, items[2].Delete.Key)
+			r.Equal(cond.Expression, *items[3].ConditionCheck.ConditionExpression)
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		})
+
+	err := dynamo.NewTransaction(client).
+		Add(
+			dynamo.TxPut(store, &testItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal}, cond),
+			dynamo.TxUpdate(otherStore, dynamo.NewKey("1"), dynamo.NewUpdate().Set("name", "x")),
+			dynamo.TxDelete(store, dynamo.NewKey(testPartitionKeyVal, testSortKeyVal)),
+		).
+		Add(dynamo.TxConditionCheck(otherStore, dynamo.NewKey("2"), cond)).
+		Commit(context.Background())
+	r.NoError(err)
+}
+
+func TestTransaction_Canceled(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testItem](client, testTableName)
+
+	client.EXPECT().TransactWriteItems(gomock.Any(), gomock.Any()).
+		Return(nil, &types.TransactionCanceledException{
+			Message: aws.String("Transaction cancelled"),
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String(dynamo.CancellationReasonNone)},
+				{Code: aws.String(dynamo.CancellationReasonConditionalCheck), Message: aws.String("The conditional request failed")},
+			},
+		})
+
+	err := dynamo.NewTransaction(client).
+		Add(
+			dynamo.TxDelete(store, dynamo.NewKey("1", "1")),
+			dynamo.TxDelete(store, dynamo.NewKey("2", "2")),
+		).
+		Commit(context.Background())
+
+	var canceledErr *dynamo.TransactionCanceledError
+	r.True(errors.As(err, &canceledErr))
+	r.Equal([]int{1}, canceledErr.Failed())
+	r.Equal(dynamo.CancellationReasonConditionalCheck, canceledErr.Reasons[1].Code)
+
+	var awsErr *types.TransactionCanceledException
+	r.True(errors.As(err, &awsErr))
+}
+
+func TestTransaction_RetriedVersionedPut(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+
+	client := memdb.NewClient()
+	store := memdb.NewStore[testVersionedItem](client, testTableName)
+
+	item := &testVersionedItem{Pkey: "1"}
+	r.NoError(store.Put(ctx, item))
+	r.NoError(store.Put(ctx, &testVersionedItem{Pkey: "2"}))
+
+	// the stale item causes a version conflict and cancels the transaction
+	err := dynamo.NewTransaction(client).
+		Add(
+			dynamo.TxPut(store, item),
+			dynamo.TxPut(store, &testVersionedItem{Pkey: "2"}),
+		).
+		Commit(ctx)
+	var canceledErr *dynamo.TransactionCanceledError
+	r.True(errors.As(err, &canceledErr))
+	r.Equal([]int{1}, canceledErr.Failed())
+	r.Equal(int64(1), item.Version)
+
+	// the operation is created again
+	r.NoError(dynamo.NewTransaction(client).Add(dynamo.TxPut(store, item)).Commit(ctx))
+	r.Equal(int64(2), item.Version)
+
+	stored, err := store.Get(ctx, "1")
+	r.NoError(err)
+	r.Equal(int64(2), stored.Version)
+}
+
+func TestTransaction_CacheInvalidation(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+
+	client := memdb.NewClient()
+	store := memdb.NewStore[testItem](client, testTableName).
+		WithCache(cache.NewInMemoryWithTTL[testItem](time.Minute, time.Minute), dynamo.CacheWriteThrough)
+
+	item := &testItem{Pkey: testPartitionKeyVal, Skey: testSortKeyVal}
+	r.NoError(store.Put(ctx, item))
+	items, err := store.GetAll(ctx, testPartitionKeyVal)
+	r.NoError(err)
+	r.Len(items, 1)
+
+	err = dynamo.NewTransaction(client).
+		Add(dynamo.TxDelete(store, dynamo.NewKey(testPartitionKeyVal, testSortKeyVal))).
+		Commit(ctx)
+	r.NoError(err)
+
+	// neither the item nor the query result is served from the cache
+	_, err = store.Get(ctx, testPartitionKeyVal, testSortKeyVal)
+	r.ErrorIs(err, dynamo.ErrNotFound)
+	items, err = store.GetAll(ctx, testPartitionKeyVal)
+	r.NoError(err)
+	r.Empty(items)
+}
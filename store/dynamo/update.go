@@ -0,0 +1,173 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UpdateExpression is a built update expression.
+type UpdateExpression struct {
+	Expression                string
+	ExpressionAttributeNames  map[string]string
+	ExpressionAttributeValues map[string]types.AttributeValue
+}
+
+// Update builds an update expression. The attribute names and values are always
+// replaced with placeholders so reserved words and special characters are safe to use.
+type Update struct {
+	actions         []updateAction
+	expectedVersion *int64
+}
+
+type updateAction struct {
+	clause string
+	path   string
+	build  func(ea *expressionAttributes) (string, error)
+}
+
+// Update clauses
+const (
+	updateClauseSet    = "SET"
+	updateClauseRemove = "REMOVE"
+	updateClauseAdd    = "ADD"
+)
+
+// NewUpdate creates a new update.
+func NewUpdate() *Update {
+	return &Update{}
+}
+
+func (u *Update) addAction(clause, path string, build func(ea *expressionAttributes) (string, error)) *Update {
+	u.actions = append(u.actions, updateAction{clause: clause, path: path, build: build})
+	return u
+}
+
+// Set sets the attribute at given path to the value.
+func (u *Update) Set(path string, value interface{}) *Update {
+	return u.addAction(updateClauseSet, path, func(ea *expressionAttributes) (string, error) {
+		v, err := ea.value(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s = %s", ea.path(path), v), nil
+	})
+}
+
+// SetIfNotExists sets the attribute at given path to the value only if the attribute does not exist.
+func (u *Update) SetIfNotExists(path string, value interface{}) *Update {
+	return u.addAction(updateClauseSet, path, func(ea *expressionAttributes) (string, error) {
+		v, err := ea.value(value)
+		if err != nil {
+			return "", err
+		}
+		p := ea.path(path)
+		return fmt.Sprintf("%s = if_not_exists(%s, %s)", p, p, v), nil
+	})
+}
+
+// Increment adds the delta to the number attribute at given path. A missing attribute is treated as zero.
+func (u *Update) Increment(path string, delta int64) *Update {
+	return u.addAction(updateClauseSet, path, func(ea *expressionAttributes) (string, error) {
+		zero, err := ea.value(0)
+		if err != nil {
+			return "", err
+		}
+		v, err := ea.value(delta)
+		if err != nil {
+			return "", err
+		}
+		p := ea.path(path)
+		return fmt.Sprintf("%s = if_not_exists(%s, %s) + %s", p, p, zero, v), nil
+	})
+}
+
+// AppendToList appends the values to the list attribute at given path. A missing attribute is
+// treated as an empty list.
+func (u *Update) AppendToList(path string, values ...interface{}) *Update {
+	return u.addAction(updateClauseSet, path, func(ea *expressionAttributes) (string, error) {
+		empty, err := ea.value([]interface{}{})
+		if err != nil {
+			return "", err
+		}
+		v, err := ea.value(values)
+		if err != nil {
+			return "", err
+		}
+		p := ea.path(path)
+		return fmt.Sprintf("%s = list_append(if_not_exists(%s, %s), %s)", p, p, empty, v), nil
+	})
+}
+
+// Remove removes the attribute at given path.
+func (u *Update) Remove(path string) *Update {
+	return u.addAction(updateClauseRemove, path, func(ea *expressionAttributes) (string, error) {
+		return ea.path(path), nil
+	})
+}
+
+// Add adds the value to a top-level number or set attribute.
+func (u *Update) Add(name string, value interface{}) *Update {
+	return u.addAction(updateClauseAdd, name, func(ea *expressionAttributes) (string, error) {
+		v, err := ea.value(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s", ea.name(name), v), nil
+	})
+}
+
+// ExpectVersion conditions the update of a Versioned item on the given version.
+func (u *Update) ExpectVersion(version int64) *Update {
+	u.expectedVersion = &version
+	return u
+}
+
+func (u *Update) copy() *Update {
+	return &Update{
+		actions:         append([]updateAction{}, u.actions...),
+		expectedVersion: u.expectedVersion,
+	}
+}
+
+// attributes returns the names of the top-level attributes which are updated.
+func (u *Update) attributes() []string {
+	var names []string
+	for _, action := range u.actions {
+		name := action.path
+		if i := strings.IndexAny(name, ".["); i >= 0 {
+			name = name[:i]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Build builds the update expression.
+func (u *Update) Build() (UpdateExpression, error) {
+	if u == nil || len(u.actions) == 0 {
+		return UpdateExpression{}, errors.New("empty update")
+	}
+	ea := newExpressionAttributes("u")
+	clauses := make(map[string][]string)
+	for _, action := range u.actions {
+		expr, err := action.build(ea)
+		if err != nil {
+			return UpdateExpression{}, fmt.Errorf("failed to build update: %v", err)
+		}
+		clauses[action.clause] = append(clauses[action.clause], expr)
+	}
+	var parts []string
+	for _, clause := range []string{updateClauseSet, updateClauseRemove, updateClauseAdd} {
+		if exprs, ok := clauses[clause]; ok {
+			parts = append(parts, clause+" "+strings.Join(exprs, ", "))
+		}
+	}
+	return UpdateExpression{
+		Expression:                strings.Join(parts, " "),
+		ExpressionAttributeNames:  ea.namesOrNil(),
+		ExpressionAttributeValues: ea.valuesOrNil(),
+	}, nil
+}
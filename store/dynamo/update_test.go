@@ -0,0 +1,91 @@
+package dynamo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+	"github.com/forta-network/core-go/store/dynamo/cache"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type failingValue struct{}
+
+func (failingValue) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return nil, errors.New("failed")
+}
+
+func TestUpdateBuild(t *testing.T) {
+	r := require.New(t)
+
+	expr, err := dynamo.NewUpdate().
+		Set("status", "active").
+		Remove("deleted").
+		Add("tags", []string{"x"}).
+		Increment("stats.count", 1).
+		SetIfNotExists("createdAt", 10).
+		AppendToList("history[0].events", "updated").
+		Build()
+	r.NoError(err)
+
+	r.Equal(
+		"SET #u0 = :u0, #u3.#u4 = if_not_exists(#u3.#u4, :u2) + :u3, #u5 = if_not_exists(#u5, :u4), "+
+			"#u6[0].#u7 = list_append(if_not_exists(#u6[0].#u7, :u5), :u6) REMOVE #u1 ADD #u2 :u1",
+		expr.Expression,
+	)
+	r.Equal(map[string]string{
+		"#u0": "status", "#u1": "deleted", "#u2": "tags", "#u3": "stats",
+		"#u4": "count", "#u5": "createdAt", "#u6": "history", "#u7": "events",
+	}, expr.ExpressionAttributeNames)
+	r.Len(expr.ExpressionAttributeValues, 7)
+	r.Equal(&types.AttributeValueMemberS{Value: "active"}, expr.ExpressionAttributeValues[":u0"])
+
+	_, err = dynamo.NewUpdate().Build()
+	r.Error(err)
+
+	_, err = dynamo.NewUpdate().Set("invalid", failingValue{}).Build()
+	r.Error(err)
+}
+
+func TestUpdate(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	testCache := cache.NewTemp[testItem]()
+	store := dynamo.NewStore[testItem](client, testTableName).WithCache(testCache)
+
+	testCache.Put(context.Background(), &testItem{}, testPartitionKeyVal, testSortKeyVal)
+
+	cond := dynamo.ConditionExpression{
+		Expression: "attribute_exists(pkey) AND skey <> :skey",
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":skey": &types.AttributeValueMemberS{Value: "other"},
+		},
+	}
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			r.Equal(testBothKeys, input.Key)
+			r.Equal("SET #u0 = :u0", *input.UpdateExpression)
+			r.Equal(cond.Expression, *input.ConditionExpression)
+			r.Len(input.ExpressionAttributeValues, 2)
+			r.Equal(types.ReturnValueAllNew, input.ReturnValues)
+			return &dynamodb.UpdateItemOutput{Attributes: testFoundItem}, nil
+		})
+
+	item, err := store.Update(
+		context.Background(), dynamo.NewKey(testPartitionKeyVal, testSortKeyVal),
+		dynamo.NewUpdate().Set("foo", "bar"), cond,
+	)
+	r.NoError(err)
+	r.Equal(testPartitionKeyVal, item.Pkey)
+
+	// the cached item is invalidated
+	_, ok := testCache.Get(context.Background(), testPartitionKeyVal, testSortKeyVal)
+	r.False(ok)
+}
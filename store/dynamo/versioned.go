@@ -0,0 +1,133 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Condition errors
+var (
+	ErrConditionFailed = errors.New("condition failed")
+	ErrVersionConflict = fmt.Errorf("%w: version conflict", ErrConditionFailed)
+)
+
+// Versioned is implemented by the items which use optimistic locking. The store
+// increments the version on every write and conditions the write on the previous
+// version. It is checked on the item pointer so that the version can be set.
+type Versioned interface {
+	GetVersionAttributeName() string
+	GetVersion() int64
+	SetVersion(version int64)
+}
+
+// versionCheck is the optimistic locking condition of a write.
+type versionCheck struct {
+	attributeName string
+	// prevVersion is zero for new items.
+	prevVersion int64
+}
+
+func (vc *versionCheck) condition(ea *expressionAttributes) (string, error) {
+	name := ea.name(vc.attributeName)
+	if vc.prevVersion == 0 {
+		return fmt.Sprintf("attribute_not_exists(%s)", name), nil
+	}
+	v, err := ea.value(vc.prevVersion)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s = %s", name, v), nil
+}
+
+// isConflict tells if the old item from a failed condition check has a different version.
+func (vc *versionCheck) isConflict(oldItem map[string]types.AttributeValue) bool {
+	if vc.prevVersion == 0 {
+		return len(oldItem) > 0
+	}
+	version, ok := oldItem[vc.attributeName].(*types.AttributeValueMemberN)
+	if !ok {
+		return true
+	}
+	return version.Value != strconv.FormatInt(vc.prevVersion, 10)
+}
+
+// writeCondition combines the user provided condition with the version check.
+type writeCondition struct {
+	expression *string
+	names      map[string]string
+	values     map[string]types.AttributeValue
+}
+
+func makeWriteCondition(vc *versionCheck, conditionExpression ...ConditionExpression) (*writeCondition, error) {
+	wc := &writeCondition{}
+	if conditionExpression != nil {
+		wc.expression = &conditionExpression[0].Expression
+		wc.values = conditionExpression[0].ExpressionAttributeValues
+	}
+	if vc == nil {
+		return wc, nil
+	}
+	ea := newExpressionAttributes("v")
+	versionCond, err := vc.condition(ea)
+	if err != nil {
+		return nil, err
+	}
+	if wc.expression != nil {
+		versionCond = fmt.Sprintf("(%s) AND (%s)", *wc.expression, versionCond)
+	}
+	wc.expression = &versionCond
+	wc.names = ea.namesOrNil()
+	wc.values = mergeExpressionValues(wc.values, ea.values)
+	return wc, nil
+}
+
+// prepareVersionedPut increments the version of the item if it is versioned. The returned
+// function restores the previous version.
+func prepareVersionedPut[I Item](item *I) (*versionCheck, func()) {
+	versioned, ok := any(item).(Versioned)
+	if !ok {
+		return nil, func() {}
+	}
+	vc := &versionCheck{
+		attributeName: versioned.GetVersionAttributeName(),
+		prevVersion:   versioned.GetVersion(),
+	}
+	versioned.SetVersion(vc.prevVersion + 1)
+	return vc, func() {
+		versioned.SetVersion(vc.prevVersion)
+	}
+}
+
+// prepareVersionedUpdate adds the version increment to the update if the item type is versioned.
+// The update is conditioned on the previous version only if it is expected with ExpectVersion().
+func prepareVersionedUpdate[I Item](update *Update) (*Update, *versionCheck) {
+	var item I
+	versioned, ok := any(&item).(Versioned)
+	if !ok || update == nil {
+		return update, nil
+	}
+	attributeName := versioned.GetVersionAttributeName()
+	update = update.copy().Increment(attributeName, 1)
+	if update.expectedVersion == nil {
+		return update, nil
+	}
+	return update, &versionCheck{
+		attributeName: attributeName,
+		prevVersion:   *update.expectedVersion,
+	}
+}
+
+// toConditionError converts the failed condition checks to the condition errors.
+func toConditionError(err error, vc *versionCheck) error {
+	var conditionErr *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionErr) {
+		return err
+	}
+	if vc != nil && vc.isConflict(conditionErr.Item) {
+		return fmt.Errorf("%w: %w", ErrVersionConflict, err)
+	}
+	return fmt.Errorf("%w: %w", ErrConditionFailed, err)
+}
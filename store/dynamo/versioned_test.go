@@ -0,0 +1,143 @@
+package dynamo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/dynamo"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testVersionedItem struct {
+	Pkey    string `dynamodbav:"pkey"`
+	Version int64  `dynamodbav:"version"`
+}
+
+func (item testVersionedItem) GetPartitionKeyName() string {
+	return "pkey"
+}
+
+func (item testVersionedItem) GetSortKeyName() string {
+	return ""
+}
+
+func (item testVersionedItem) GetVersionAttributeName() string {
+	return "version"
+}
+
+func (item testVersionedItem) GetVersion() int64 {
+	return item.Version
+}
+
+func (item *testVersionedItem) SetVersion(version int64) {
+	item.Version = version
+}
+
+func TestPut_Versioned(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testVersionedItem](client, testTableName)
+
+	// new item
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			r.Equal("attribute_not_exists(#v0)", *input.ConditionExpression)
+			r.Equal(map[string]string{"#v0": "version"}, input.ExpressionAttributeNames)
+			r.Equal(&types.AttributeValueMemberN{Value: "1"}, input.Item["version"])
+			return &dynamodb.PutItemOutput{}, nil
+		})
+	item := &testVersionedItem{Pkey: testPartitionKeyVal}
+	r.NoError(store.Put(context.Background(), item))
+	r.Equal(int64(1), item.Version)
+
+	// existing item with a user condition
+	client.EXPECT().PutItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			r.Equal("(attribute_exists(pkey)) AND (#v0 = :v0)", *input.ConditionExpression)
+			r.Equal(&types.AttributeValueMemberN{Value: "1"}, input.ExpressionAttributeValues[":v0"])
+			return &dynamodb.PutItemOutput{}, nil
+		})
+	r.NoError(store.Put(context.Background(), item, dynamo.ConditionExpression{Expression: "attribute_exists(pkey)"}))
+	r.Equal(int64(2), item.Version)
+}
+
+func TestPut_VersionConflict(t *testing.T) {
+	testCases := []struct {
+		name       string
+		oldItem    map[string]types.AttributeValue
+		isConflict bool
+	}{
+		{
+			name: "newer version",
+			oldItem: map[string]types.AttributeValue{
+				"pkey":    &types.AttributeValueMemberS{Value: testPartitionKeyVal},
+				"version": &types.AttributeValueMemberN{Value: "3"},
+			},
+			isConflict: true,
+		},
+		{
+			name: "same version",
+			oldItem: map[string]types.AttributeValue{
+				"pkey":    &types.AttributeValueMemberS{Value: testPartitionKeyVal},
+				"version": &types.AttributeValueMemberN{Value: "2"},
+			},
+		},
+		{
+			name:       "deleted",
+			isConflict: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := require.New(t)
+
+			client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+			store := dynamo.NewStore[testVersionedItem](client, testTableName)
+
+			client.EXPECT().PutItem(gomock.Any(), gomock.Any()).
+				Return(nil, &types.ConditionalCheckFailedException{Item: testCase.oldItem})
+
+			item := &testVersionedItem{Pkey: testPartitionKeyVal, Version: 2}
+			err := store.Put(context.Background(), item, dynamo.ConditionExpression{Expression: "attribute_exists(foo)"})
+			r.ErrorIs(err, dynamo.ErrConditionFailed)
+			r.Equal(testCase.isConflict, errors.Is(err, dynamo.ErrVersionConflict))
+			// the version is not changed
+			r.Equal(int64(2), item.Version)
+		})
+	}
+}
+
+func TestUpdate_Versioned(t *testing.T) {
+	r := require.New(t)
+
+	client := mock_aws.NewMockDynamoDBClient(gomock.NewController(t))
+	store := dynamo.NewStore[testVersionedItem](client, testTableName)
+
+	client.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+			r.Equal("SET #u0 = :u0, #u1 = if_not_exists(#u1, :u1) + :u2", *input.UpdateExpression)
+			r.Equal("#v0 = :v0", *input.ConditionExpression)
+			r.Equal(map[string]string{"#u0": "foo", "#u1": "version", "#v0": "version"}, input.ExpressionAttributeNames)
+			r.Len(input.ExpressionAttributeValues, 4)
+			return nil, &types.ConditionalCheckFailedException{Item: map[string]types.AttributeValue{
+				"version": &types.AttributeValueMemberN{Value: "6"},
+			}}
+		})
+
+	update := dynamo.NewUpdate().Set("foo", "bar").ExpectVersion(5)
+	_, err := store.Update(context.Background(), dynamo.NewKey(testPartitionKeyVal), update)
+	r.ErrorIs(err, dynamo.ErrVersionConflict)
+
+	// the update is reusable
+	expr, err := update.Build()
+	r.NoError(err)
+	r.Equal("SET #u0 = :u0", expr.Expression)
+}
@@ -0,0 +1,342 @@
+package s3store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/forta-network/core-go/aws"
+	"github.com/forta-network/core-go/utils"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Object metadata
+const (
+	ContentTypeJSON     = "application/json"
+	ContentEncodingGzip = "gzip"
+)
+
+// Errors
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrNotModified = errors.New("not modified")
+)
+
+// Config configures an object store.
+type Config struct {
+	Bucket string
+	// KeyPrefix is prepended to the object keys.
+	KeyPrefix string
+	// Gzip compresses the written objects. The compressed objects are read regardless of it.
+	Gzip bool
+}
+
+// ObjectInfo is the metadata of an object. The keys do not include the key prefix.
+type ObjectInfo struct {
+	Key          string
+	ETag         string
+	Size         int64
+	LastModified time.Time
+}
+
+// Object is an object with its value.
+type Object[T any] struct {
+	ObjectInfo
+	Value *T
+}
+
+// ObjectStore stores the values of a type as JSON objects in a bucket.
+type ObjectStore[T any] struct {
+	client   aws.S3
+	uploader aws.S3Uploader
+	config   Config
+}
+
+// NewObjectStore creates a new object store. The uploader is used only for streaming the objects.
+func NewObjectStore[T any](client aws.S3, uploader aws.S3Uploader, config Config) *ObjectStore[T] {
+	return &ObjectStore[T]{
+		client:   client,
+		uploader: uploader,
+		config:   config,
+	}
+}
+
+func (s *ObjectStore[T]) objectKey(key string) string {
+	return s.config.KeyPrefix + key
+}
+
+// Put writes the value and returns the ETag of the object.
+func (s *ObjectStore[T]) Put(ctx context.Context, key string, value *T) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+	input := &s3.PutObjectInput{
+		Bucket:      &s.config.Bucket,
+		Key:         strPtr(s.objectKey(key)),
+		ContentType: strPtr(ContentTypeJSON),
+	}
+	if s.config.Gzip {
+		if data, err = utils.GzipEncode(data); err != nil {
+			return "", err
+		}
+		input.ContentEncoding = strPtr(ContentEncodingGzip)
+	}
+	input.Body = bytes.NewReader(data)
+	res, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	return strValue(res.ETag), nil
+}
+
+// Get reads the value. It returns ErrNotFound if the object does not exist.
+func (s *ObjectStore[T]) Get(ctx context.Context, key string) (*Object[T], error) {
+	return s.get(ctx, key, nil)
+}
+
+// GetIfChanged reads the value if the ETag of the object is not the given one. It returns
+// ErrNotModified if the object did not change.
+func (s *ObjectStore[T]) GetIfChanged(ctx context.Context, key, etag string) (*Object[T], error) {
+	return s.get(ctx, key, &etag)
+}
+
+func (s *ObjectStore[T]) get(ctx context.Context, key string, ifNoneMatch *string) (*Object[T], error) {
+	res, err := s.getObject(ctx, key, ifNoneMatch)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if strValue(res.ContentEncoding) == ContentEncodingGzip {
+		if data, err = utils.GzipDecode(data); err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+		}
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return &Object[T]{
+		ObjectInfo: ObjectInfo{
+			Key:          key,
+			ETag:         strValue(res.ETag),
+			Size:         int64Value(res.ContentLength),
+			LastModified: timeValue(res.LastModified),
+		},
+		Value: &value,
+	}, nil
+}
+
+func (s *ObjectStore[T]) getObject(ctx context.Context, key string, ifNoneMatch *string) (*s3.GetObjectOutput, error) {
+	res, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:      &s.config.Bucket,
+		Key:         strPtr(s.objectKey(key)),
+		IfNoneMatch: ifNoneMatch,
+	})
+	var (
+		noSuchKey *types.NoSuchKey
+		httpErr   interface{ HTTPStatusCode() int }
+	)
+	switch {
+	case errors.As(err, &noSuchKey):
+		return nil, ErrNotFound
+	case errors.As(err, &httpErr) && httpErr.HTTPStatusCode() == http.StatusNotModified:
+		return nil, ErrNotModified
+	case err != nil:
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return res, nil
+}
+
+// Head returns the metadata of the object. It returns ErrNotFound if the object does not exist.
+func (s *ObjectStore[T]) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	res, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &s.config.Bucket,
+		Key:    strPtr(s.objectKey(key)),
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to head %s: %w", key, err)
+	}
+	return &ObjectInfo{
+		Key:          key,
+		ETag:         strValue(res.ETag),
+		Size:         int64Value(res.ContentLength),
+		LastModified: timeValue(res.LastModified),
+	}, nil
+}
+
+// Delete deletes the object. It does not fail if the object does not exist.
+func (s *ObjectStore[T]) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.config.Bucket,
+		Key:    strPtr(s.objectKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns the metadata of all objects whose keys start with the prefix.
+func (s *ObjectStore[T]) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	var (
+		objects           []*ObjectInfo
+		continuationToken *string
+	)
+	for {
+		res, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            &s.config.Bucket,
+			Prefix:            strPtr(s.objectKey(prefix)),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		for _, object := range res.Contents {
+			objects = append(objects, &ObjectInfo{
+				Key:          strings.TrimPrefix(strValue(object.Key), s.config.KeyPrefix),
+				ETag:         strValue(object.ETag),
+				Size:         int64Value(object.Size),
+				LastModified: timeValue(object.LastModified),
+			})
+		}
+		if res.IsTruncated == nil || !*res.IsTruncated || res.NextContinuationToken == nil {
+			return objects, nil
+		}
+		continuationToken = res.NextContinuationToken
+	}
+}
+
+// PutStream writes the value which is encoded by the function without buffering the whole
+// object. It is for the large objects which are uploaded in parts.
+func (s *ObjectStore[T]) PutStream(ctx context.Context, key string, encode func(w io.Writer) error) error {
+	pr, pw := io.Pipe()
+	go func() {
+		var (
+			w   io.Writer = pw
+			gzw *gzip.Writer
+		)
+		if s.config.Gzip {
+			gzw = gzip.NewWriter(pw)
+			w = gzw
+		}
+		err := encode(w)
+		if gzw != nil {
+			if closeErr := gzw.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	input := &s3.PutObjectInput{
+		Bucket:      &s.config.Bucket,
+		Key:         strPtr(s.objectKey(key)),
+		ContentType: strPtr(ContentTypeJSON),
+		Body:        pr,
+	}
+	if s.config.Gzip {
+		input.ContentEncoding = strPtr(ContentEncodingGzip)
+	}
+	_, err := s.uploader.Upload(ctx, input)
+	// unblock the encoder if the upload failed
+	pr.CloseWithError(err)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// PutJSONStream streams the JSON encoding of the value.
+func (s *ObjectStore[T]) PutJSONStream(ctx context.Context, key string, value *T) error {
+	return s.PutStream(ctx, key, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(value)
+	})
+}
+
+// GetStream returns the decompressed content of the object. The caller should close it.
+// It returns ErrNotFound if the object does not exist.
+func (s *ObjectStore[T]) GetStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s.getObject(ctx, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strValue(res.ContentEncoding) != ContentEncodingGzip {
+		return res.Body, nil
+	}
+	gzr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		res.Body.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+	return &gzipReadCloser{Reader: gzr, body: res.Body}, nil
+}
+
+// GetJSONStream decodes the value from the object stream without reading the whole object first.
+func (s *ObjectStore[T]) GetJSONStream(ctx context.Context, key string) (*T, error) {
+	r, err := s.GetStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var value T
+	if err := json.NewDecoder(r).Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return &value, nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	err := r.Reader.Close()
+	if bodyErr := r.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func strValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int64Value(n *int64) int64 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,353 @@
+package s3store_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mock_aws "github.com/forta-network/core-go/aws/mocks"
+	"github.com/forta-network/core-go/store/s3store"
+	"github.com/forta-network/core-go/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBucket    = "test-bucket"
+	testKeyPrefix = "docs/"
+	testPageSize  = 2
+)
+
+type testDoc struct {
+	Name  string   `json:"name"`
+	Items []string `json:"items"`
+}
+
+type testObject struct {
+	data            []byte
+	contentType     string
+	contentEncoding string
+	etag            string
+}
+
+// testHTTPError is an error with an HTTP status code like the SDK response errors.
+type testHTTPError struct {
+	statusCode int
+}
+
+func (e *testHTTPError) Error() string {
+	return fmt.Sprintf("status code %d", e.statusCode)
+}
+
+func (e *testHTTPError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+// testBucketObjects fakes a bucket with the S3 mocks.
+type testBucketObjects struct {
+	mu      sync.Mutex
+	objects map[string]*testObject
+}
+
+func (b *testBucketObjects) put(key string, body io.Reader, contentType, contentEncoding *string) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(data)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = &testObject{
+		data:            data,
+		contentType:     aws.ToString(contentType),
+		contentEncoding: aws.ToString(contentEncoding),
+		etag:            etag,
+	}
+	return etag, nil
+}
+
+func (b *testBucketObjects) get(key string) *testObject {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.objects[key]
+}
+
+func newTestStore(t *testing.T, config s3store.Config) (*s3store.ObjectStore[testDoc], *testBucketObjects) {
+	ctrl := gomock.NewController(t)
+	bucket := &testBucketObjects{objects: make(map[string]*testObject)}
+	client := mock_aws.NewMockS3(ctrl)
+	uploader := mock_aws.NewMockS3Uploader(ctrl)
+	lastModified := time.UnixMilli(1700000000000).UTC()
+
+	uploader.EXPECT().Upload(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.PutObjectInput, _ ...func(*manager.Uploader)) (*manager.UploadOutput, error) {
+			require.Equal(t, testBucket, *input.Bucket)
+			etag, err := bucket.put(*input.Key, input.Body, input.ContentType, input.ContentEncoding)
+			if err != nil {
+				return nil, err
+			}
+			return &manager.UploadOutput{Key: input.Key, ETag: &etag}, nil
+		}).AnyTimes()
+	client.EXPECT().PutObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+			require.Equal(t, testBucket, *input.Bucket)
+			etag, err := bucket.put(*input.Key, input.Body, input.ContentType, input.ContentEncoding)
+			if err != nil {
+				return nil, err
+			}
+			return &s3.PutObjectOutput{ETag: &etag}, nil
+		}).AnyTimes()
+	client.EXPECT().GetObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+			object := bucket.get(*input.Key)
+			if object == nil {
+				return nil, &types.NoSuchKey{}
+			}
+			if input.IfNoneMatch != nil && *input.IfNoneMatch == object.etag {
+				return nil, &testHTTPError{statusCode: http.StatusNotModified}
+			}
+			res := &s3.GetObjectOutput{
+				Body:          io.NopCloser(bytes.NewReader(object.data)),
+				ETag:          aws.String(object.etag),
+				ContentLength: aws.Int64(int64(len(object.data))),
+				LastModified:  aws.Time(lastModified),
+			}
+			if len(object.contentEncoding) > 0 {
+				res.ContentEncoding = aws.String(object.contentEncoding)
+			}
+			return res, nil
+		}).AnyTimes()
+	client.EXPECT().HeadObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+			object := bucket.get(*input.Key)
+			if object == nil {
+				return nil, &types.NotFound{}
+			}
+			return &s3.HeadObjectOutput{
+				ETag:          aws.String(object.etag),
+				ContentLength: aws.Int64(int64(len(object.data))),
+				LastModified:  aws.Time(lastModified),
+			}, nil
+		}).AnyTimes()
+	client.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			delete(bucket.objects, *input.Key)
+			return &s3.DeleteObjectOutput{}, nil
+		}).AnyTimes()
+	client.EXPECT().ListObjectsV2(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, input *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			var keys []string
+			for key := range bucket.objects {
+				if strings.HasPrefix(key, *input.Prefix) && key > aws.ToString(input.ContinuationToken) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			// paginated
+			res := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+			if len(keys) > testPageSize {
+				keys = keys[:testPageSize]
+				res.IsTruncated = aws.Bool(true)
+				res.NextContinuationToken = aws.String(keys[testPageSize-1])
+			}
+			for _, key := range keys {
+				res.Contents = append(res.Contents, types.Object{
+					Key:          aws.String(key),
+					ETag:         aws.String(bucket.objects[key].etag),
+					Size:         aws.Int64(int64(len(bucket.objects[key].data))),
+					LastModified: aws.Time(lastModified),
+				})
+			}
+			return res, nil
+		}).AnyTimes()
+
+	config.Bucket = testBucket
+	config.KeyPrefix = testKeyPrefix
+	return s3store.NewObjectStore[testDoc](client, uploader, config), bucket
+}
+
+func TestObjectStore(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	store, bucket := newTestStore(t, s3store.Config{})
+
+	doc := &testDoc{Name: "a", Items: []string{"1", "2"}}
+	etag, err := store.Put(ctx, "a.json", doc)
+	r.NoError(err)
+	object := bucket.get(testKeyPrefix + "a.json")
+	r.Equal(`{"name":"a","items":["1","2"]}`, string(object.data))
+	r.Equal(s3store.ContentTypeJSON, object.contentType)
+	r.Empty(object.contentEncoding)
+
+	got, err := store.Get(ctx, "a.json")
+	r.NoError(err)
+	r.Equal(doc, got.Value)
+	r.Equal("a.json", got.Key)
+	r.Equal(etag, got.ETag)
+	r.Equal(int64(len(object.data)), got.Size)
+	r.Equal(int64(1700000000000), got.LastModified.UnixMilli())
+
+	info, err := store.Head(ctx, "a.json")
+	r.NoError(err)
+	r.Equal(got.ObjectInfo, *info)
+
+	r.NoError(store.Delete(ctx, "a.json"))
+	_, err = store.Get(ctx, "a.json")
+	r.ErrorIs(err, s3store.ErrNotFound)
+	_, err = store.Head(ctx, "a.json")
+	r.ErrorIs(err, s3store.ErrNotFound)
+	// deleting a missing object does not fail
+	r.NoError(store.Delete(ctx, "a.json"))
+}
+
+func TestObjectStore_Gzip(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	store, bucket := newTestStore(t, s3store.Config{Gzip: true})
+
+	doc := &testDoc{Name: "a", Items: []string{strings.Repeat("x", 1000)}}
+	_, err := store.Put(ctx, "a.json", doc)
+	r.NoError(err)
+	object := bucket.get(testKeyPrefix + "a.json")
+	r.Equal(s3store.ContentEncodingGzip, object.contentEncoding)
+	decoded, err := utils.GzipDecode(object.data)
+	r.NoError(err)
+	r.Contains(string(decoded), `"name":"a"`)
+
+	got, err := store.Get(ctx, "a.json")
+	r.NoError(err)
+	r.Equal(doc, got.Value)
+
+	// the compressed objects are read regardless of the config
+	plainStore, plainBucket := newTestStore(t, s3store.Config{})
+	plainBucket.objects = bucket.objects
+	got, err = plainStore.Get(ctx, "a.json")
+	r.NoError(err)
+	r.Equal(doc, got.Value)
+}
+
+func TestObjectStore_GetIfChanged(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	store, _ := newTestStore(t, s3store.Config{})
+
+	etag, err := store.Put(ctx, "a.json", &testDoc{Name: "v1"})
+	r.NoError(err)
+	_, err = store.GetIfChanged(ctx, "a.json", etag)
+	r.ErrorIs(err, s3store.ErrNotModified)
+
+	_, err = store.Put(ctx, "a.json", &testDoc{Name: "v2"})
+	r.NoError(err)
+	got, err := store.GetIfChanged(ctx, "a.json", etag)
+	r.NoError(err)
+	r.Equal("v2", got.Value.Name)
+	r.NotEqual(etag, got.ETag)
+
+	_, err = store.GetIfChanged(ctx, "b.json", etag)
+	r.ErrorIs(err, s3store.ErrNotFound)
+}
+
+func TestObjectStore_List(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	store, bucket := newTestStore(t, s3store.Config{})
+
+	for _, key := range []string{"x/1", "x/2", "x/3", "x/4", "x/5", "y/1"} {
+		_, err := store.Put(ctx, key, &testDoc{Name: key})
+		r.NoError(err)
+	}
+	// an object which is not in the store
+	_, err := bucket.put("other/x/1", strings.NewReader("{}"), nil, nil)
+	r.NoError(err)
+
+	objects, err := store.List(ctx, "x/")
+	r.NoError(err)
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+		r.NotEmpty(object.ETag)
+		r.NotZero(object.Size)
+	}
+	r.Equal([]string{"x/1", "x/2", "x/3", "x/4", "x/5"}, keys)
+
+	objects, err = store.List(ctx, "")
+	r.NoError(err)
+	r.Len(objects, 6)
+
+	objects, err = store.List(ctx, "z/")
+	r.NoError(err)
+	r.Empty(objects)
+}
+
+func TestObjectStore_Stream(t *testing.T) {
+	for _, gzip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%v", gzip), func(t *testing.T) {
+			r := require.New(t)
+			ctx := context.Background()
+			store, bucket := newTestStore(t, s3store.Config{Gzip: gzip})
+
+			doc := &testDoc{Name: "large"}
+			for i := 0; i < 10000; i++ {
+				doc.Items = append(doc.Items, fmt.Sprint(i))
+			}
+			r.NoError(store.PutJSONStream(ctx, "large.json", doc))
+			if gzip {
+				r.Equal(s3store.ContentEncodingGzip, bucket.get(testKeyPrefix+"large.json").contentEncoding)
+			}
+
+			got, err := store.GetJSONStream(ctx, "large.json")
+			r.NoError(err)
+			r.Equal(doc, got)
+			// the streamed objects can be read at once
+			object, err := store.Get(ctx, "large.json")
+			r.NoError(err)
+			r.Equal(doc, object.Value)
+
+			stream, err := store.GetStream(ctx, "large.json")
+			r.NoError(err)
+			data, err := io.ReadAll(stream)
+			r.NoError(err)
+			r.NoError(stream.Close())
+			r.True(strings.HasPrefix(string(data), `{"name":"large"`))
+
+			_, err = store.GetStream(ctx, "missing.json")
+			r.ErrorIs(err, s3store.ErrNotFound)
+		})
+	}
+}
+
+func TestObjectStore_StreamErrors(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	store, bucket := newTestStore(t, s3store.Config{})
+
+	encodeErr := errors.New("encode failed")
+	err := store.PutStream(ctx, "a.json", func(w io.Writer) error {
+		if _, err := w.Write([]byte(`{"name":`)); err != nil {
+			return err
+		}
+		return encodeErr
+	})
+	r.ErrorIs(err, encodeErr)
+	r.Nil(bucket.get(testKeyPrefix + "a.json"))
+}
@@ -0,0 +1,176 @@
+package traceutils
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/core-go/etherclient"
+)
+
+// Call types as reported by the call tracer
+const (
+	CallTypeCall         = "CALL"
+	CallTypeCallCode     = "CALLCODE"
+	CallTypeDelegateCall = "DELEGATECALL"
+	CallTypeStaticCall   = "STATICCALL"
+	CallTypeCreate       = "CREATE"
+	CallTypeCreate2      = "CREATE2"
+	CallTypeSelfDestruct = "SELFDESTRUCT"
+)
+
+// TransferEventTopic is the topic of the ERC-20 Transfer(address,address,uint256) event.
+const TransferEventTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// Transfer is a native or ERC-20 value transfer found in a call tree.
+type Transfer struct {
+	TxHash string
+	Path   CallPath
+	// Token is the zero address for native transfers.
+	Token common.Address
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+// IsNative tells if this is a native currency transfer.
+func (t *Transfer) IsNative() bool {
+	return t.Token == (common.Address{})
+}
+
+func isCallType(call *etherclient.TracedCall, callTypes ...string) bool {
+	for _, callType := range callTypes {
+		if strings.EqualFold(call.CallType, callType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Creates returns the contract creation frames.
+func Creates(calls []*FlatCall) []*FlatCall {
+	return Filter(calls, func(call *FlatCall) bool {
+		return isCallType(call.Call, CallTypeCreate, CallTypeCreate2)
+	})
+}
+
+// SelfDestructs returns the self-destruct frames.
+func SelfDestructs(calls []*FlatCall) []*FlatCall {
+	return Filter(calls, func(call *FlatCall) bool {
+		return isCallType(call.Call, CallTypeSelfDestruct)
+	})
+}
+
+// DelegateCalls returns the delegate call frames.
+func DelegateCalls(calls []*FlatCall) []*FlatCall {
+	return Filter(calls, func(call *FlatCall) bool {
+		return isCallType(call.Call, CallTypeDelegateCall)
+	})
+}
+
+// Failed returns the frames which failed themselves.
+func Failed(calls []*FlatCall) []*FlatCall {
+	return Filter(calls, func(call *FlatCall) bool {
+		return len(call.Call.Error) > 0
+	})
+}
+
+// NativeTransfers returns the native currency transfers from the frames which were not reverted.
+func NativeTransfers(calls []*FlatCall) []*Transfer {
+	var transfers []*Transfer
+	for _, call := range calls {
+		if call.Reverted || call.Call.Value == nil || call.Call.Value.ToInt().Sign() <= 0 {
+			continue
+		}
+		// value is not transferred to another account in these
+		if isCallType(call.Call, CallTypeDelegateCall, CallTypeStaticCall, CallTypeCallCode) {
+			continue
+		}
+		transfers = append(transfers, &Transfer{
+			TxHash: call.TxHash,
+			Path:   call.Path,
+			From:   call.Call.From,
+			To:     call.Call.To,
+			Value:  new(big.Int).Set(call.Call.Value.ToInt()),
+		})
+	}
+	return transfers
+}
+
+// TokenTransfers returns the ERC-20 transfers from the logs of the frames which were not reverted.
+// The logs are only available if the trace was collected with the "withLog" option.
+func TokenTransfers(calls []*FlatCall) []*Transfer {
+	var transfers []*Transfer
+	for _, call := range calls {
+		if call.Reverted {
+			continue
+		}
+		for _, log := range call.Call.Logs {
+			// ERC-721 transfers have the same signature but the token ID is indexed
+			if len(log.Topics) != 3 || !strings.EqualFold(log.Topics[0], TransferEventTopic) || len(log.Data) != 32 {
+				continue
+			}
+			transfers = append(transfers, &Transfer{
+				TxHash: call.TxHash,
+				Path:   call.Path,
+				Token:  log.Address,
+				From:   common.HexToAddress(log.Topics[1]),
+				To:     common.HexToAddress(log.Topics[2]),
+				Value:  new(big.Int).SetBytes(log.Data),
+			})
+		}
+	}
+	return transfers
+}
+
+// BalanceDeltas computes the net balance changes per token and address from the given transfers.
+// Native balances are keyed by the zero address. Gas fees are not included.
+func BalanceDeltas(transfers []*Transfer) map[common.Address]map[common.Address]*big.Int {
+	deltas := make(map[common.Address]map[common.Address]*big.Int)
+	addDelta := func(token, addr common.Address, value *big.Int) {
+		tokenDeltas, ok := deltas[token]
+		if !ok {
+			tokenDeltas = make(map[common.Address]*big.Int)
+			deltas[token] = tokenDeltas
+		}
+		delta, ok := tokenDeltas[addr]
+		if !ok {
+			delta = new(big.Int)
+			tokenDeltas[addr] = delta
+		}
+		delta.Add(delta, value)
+	}
+	for _, transfer := range transfers {
+		addDelta(transfer.Token, transfer.From, new(big.Int).Neg(transfer.Value))
+		addDelta(transfer.Token, transfer.To, transfer.Value)
+	}
+	return deltas
+}
+
+// DeepestRevert follows the revert data bubbling down the call tree and returns the deepest
+// frame which caused the top call to fail. It returns nil if the top call did not fail.
+func DeepestRevert(call *etherclient.TracedCall) *FlatCall {
+	if call == nil || len(call.Error) == 0 {
+		return nil
+	}
+	var (
+		parent *etherclient.TracedCall
+		path   = CallPath{}
+	)
+	for {
+		next := -1
+		for i := len(call.Calls) - 1; i >= 0; i-- {
+			subcall := call.Calls[i]
+			if len(subcall.Error) > 0 && subcall.Output == call.Output {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return &FlatCall{Path: path, Call: call, Parent: parent, Reverted: true}
+		}
+		parent = call
+		call = call.Calls[next]
+		path = append(path[:len(path):len(path)], next)
+	}
+}
@@ -0,0 +1,158 @@
+package traceutils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/core-go/etherclient"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAddr1 = common.HexToAddress("0x1")
+	testAddr2 = common.HexToAddress("0x2")
+	testAddr3 = common.HexToAddress("0x3")
+	testToken = common.HexToAddress("0x4")
+)
+
+func testValue(v int64) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(v))
+}
+
+func testTransferLog(from, to common.Address, value int64) *etherclient.TracedLog {
+	return &etherclient.TracedLog{
+		Address: testToken,
+		Topics: []string{
+			TransferEventTopic,
+			common.BytesToHash(from.Bytes()).Hex(),
+			common.BytesToHash(to.Bytes()).Hex(),
+		},
+		Data: common.BigToHash(big.NewInt(value)).Bytes(),
+	}
+}
+
+// testTrace:
+//
+//	CALL 1->2 (value 10, transfer log 2->1)
+//	├── DELEGATECALL 2->3 (value 10)
+//	│   └── CALL 2->3 (value 3)
+//	├── CALL 2->3 (reverted, value 5, transfer log)
+//	│   └── CREATE 3->4 (value 1)
+//	└── SELFDESTRUCT 2->1 (value 2)
+func testTrace() *etherclient.TracedCall {
+	return &etherclient.TracedCall{
+		CallType: "CALL", From: testAddr1, To: testAddr2, Value: testValue(10),
+		Logs: []*etherclient.TracedLog{testTransferLog(testAddr2, testAddr1, 100)},
+		Calls: []*etherclient.TracedCall{
+			{
+				CallType: "DELEGATECALL", From: testAddr2, To: testAddr3, Value: testValue(10),
+				Calls: []*etherclient.TracedCall{
+					{CallType: "CALL", From: testAddr2, To: testAddr3, Value: testValue(3)},
+				},
+			},
+			{
+				CallType: "CALL", From: testAddr2, To: testAddr3, Value: testValue(5), Error: "execution reverted",
+				Logs: []*etherclient.TracedLog{testTransferLog(testAddr3, testAddr1, 50)},
+				Calls: []*etherclient.TracedCall{
+					{CallType: "CREATE", From: testAddr3, To: testToken, Value: testValue(1)},
+				},
+			},
+			{CallType: "SELFDESTRUCT", From: testAddr2, To: testAddr1, Value: testValue(2)},
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	r := require.New(t)
+
+	var paths []string
+	Walk(testTrace(), func(call *etherclient.TracedCall, path CallPath) bool {
+		paths = append(paths, path.String())
+		// skip the subcalls of the reverted call
+		return len(call.Error) == 0
+	})
+	r.Equal([]string{"", "0", "0.0", "1", "2"}, paths)
+
+	var txHashes []string
+	WalkBlock(etherclient.TracedBlock{
+		{TxHash: "0x1", Result: testTrace()},
+		{TxHash: "0x2", Result: &etherclient.TracedCall{}},
+	}, func(txHash string, call *etherclient.TracedCall, path CallPath) bool {
+		if len(path) == 0 {
+			txHashes = append(txHashes, txHash)
+		}
+		return true
+	})
+	r.Equal([]string{"0x1", "0x2"}, txHashes)
+}
+
+func TestFlatten(t *testing.T) {
+	r := require.New(t)
+
+	calls := FlattenBlock(etherclient.TracedBlock{{TxHash: "0x1", Result: testTrace()}})
+	r.Len(calls, 6)
+	r.Equal("1.0", calls[4].Path.String())
+	r.Equal("0x1", calls[4].TxHash)
+	r.True(calls[4].Reverted)
+	r.Equal("CALL", calls[4].Parent.CallType)
+	r.False(calls[5].Reverted)
+
+	r.Len(Creates(calls), 1)
+	r.Len(SelfDestructs(calls), 1)
+	r.Len(DelegateCalls(calls), 1)
+	r.Len(Failed(calls), 1)
+}
+
+func TestTransfers(t *testing.T) {
+	r := require.New(t)
+
+	calls := Flatten(testTrace())
+
+	nativeTransfers := NativeTransfers(calls)
+	r.Len(nativeTransfers, 3)
+	r.Equal("0.0", nativeTransfers[1].Path.String())
+	r.True(nativeTransfers[0].IsNative())
+
+	tokenTransfers := TokenTransfers(calls)
+	r.Len(tokenTransfers, 1)
+	r.Equal(testToken, tokenTransfers[0].Token)
+	r.Equal(testAddr2, tokenTransfers[0].From)
+	r.Equal(testAddr1, tokenTransfers[0].To)
+	r.Equal(int64(100), tokenTransfers[0].Value.Int64())
+
+	deltas := BalanceDeltas(append(nativeTransfers, tokenTransfers...))
+	native := deltas[common.Address{}]
+	r.Equal(int64(-8), native[testAddr1].Int64())
+	r.Equal(int64(5), native[testAddr2].Int64())
+	r.Equal(int64(3), native[testAddr3].Int64())
+	r.Equal(int64(100), deltas[testToken][testAddr1].Int64())
+	r.Equal(int64(-100), deltas[testToken][testAddr2].Int64())
+}
+
+func TestDeepestRevert(t *testing.T) {
+	r := require.New(t)
+
+	r.Nil(DeepestRevert(testTrace()))
+
+	trace := &etherclient.TracedCall{
+		CallType: "CALL", Error: "execution reverted", Output: "0x01",
+		Calls: []*etherclient.TracedCall{
+			{CallType: "CALL"},
+			{
+				CallType: "CALL", Error: "execution reverted", Output: "0x01",
+				Calls: []*etherclient.TracedCall{
+					// caught revert with different output
+					{CallType: "STATICCALL", Error: "execution reverted", Output: "0x02"},
+					{CallType: "DELEGATECALL", Error: "execution reverted", Output: "0x01"},
+				},
+			},
+		},
+	}
+	revert := DeepestRevert(trace)
+	r.NotNil(revert)
+	r.Equal("1.1", revert.Path.String())
+	r.Equal("DELEGATECALL", revert.Call.CallType)
+	r.Equal(trace.Calls[1], revert.Parent)
+}
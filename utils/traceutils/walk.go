@@ -0,0 +1,125 @@
+package traceutils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/forta-network/core-go/etherclient"
+)
+
+// CallPath is the position of a call frame in the call tree. It is empty for the top call
+// and is in the same format as the trace address of Parity traces.
+type CallPath []int
+
+// String returns the path in "0.1.2" format.
+func (p CallPath) String() string {
+	parts := make([]string, len(p))
+	for i, index := range p {
+		parts[i] = strconv.Itoa(index)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Visitor is called for every call frame while walking a call tree. Returning false
+// skips the subcalls of the visited frame.
+type Visitor func(call *etherclient.TracedCall, path CallPath) bool
+
+// Walk visits the call tree in depth-first order.
+func Walk(call *etherclient.TracedCall, visitor Visitor) {
+	if call == nil {
+		return
+	}
+	walk(call, CallPath{}, visitor)
+}
+
+func walk(call *etherclient.TracedCall, path CallPath, visitor Visitor) {
+	if !visitor(call, path) {
+		return
+	}
+	for i, subcall := range call.Calls {
+		subpath := make(CallPath, len(path)+1)
+		copy(subpath, path)
+		subpath[len(path)] = i
+		walk(subcall, subpath, visitor)
+	}
+}
+
+// BlockVisitor is the same as Visitor but also receives the transaction hash.
+type BlockVisitor func(txHash string, call *etherclient.TracedCall, path CallPath) bool
+
+// WalkBlock walks the call trees of all transactions in a traced block.
+func WalkBlock(block etherclient.TracedBlock, visitor BlockVisitor) {
+	for _, tx := range block {
+		if tx == nil {
+			continue
+		}
+		Walk(tx.Result, func(call *etherclient.TracedCall, path CallPath) bool {
+			return visitor(tx.TxHash, call, path)
+		})
+	}
+}
+
+// FlatCall is a call frame from a flattened call tree.
+type FlatCall struct {
+	TxHash string
+	Path   CallPath
+	Call   *etherclient.TracedCall
+	Parent *etherclient.TracedCall
+	// Reverted tells if this frame or any of its parents failed, i.e. if the
+	// effects of this frame were discarded.
+	Reverted bool
+}
+
+// Flatten flattens the call tree of a transaction in depth-first order.
+func Flatten(call *etherclient.TracedCall) []*FlatCall {
+	return flatten("", call)
+}
+
+// FlattenBlock flattens the call trees of all transactions in a block.
+func FlattenBlock(block etherclient.TracedBlock) []*FlatCall {
+	var calls []*FlatCall
+	for _, tx := range block {
+		if tx == nil {
+			continue
+		}
+		calls = append(calls, flatten(tx.TxHash, tx.Result)...)
+	}
+	return calls
+}
+
+func flatten(txHash string, call *etherclient.TracedCall) []*FlatCall {
+	if call == nil {
+		return nil
+	}
+	var calls []*FlatCall
+	var flattenFrame func(call, parent *etherclient.TracedCall, path CallPath, reverted bool)
+	flattenFrame = func(call, parent *etherclient.TracedCall, path CallPath, reverted bool) {
+		reverted = reverted || len(call.Error) > 0
+		calls = append(calls, &FlatCall{
+			TxHash:   txHash,
+			Path:     path,
+			Call:     call,
+			Parent:   parent,
+			Reverted: reverted,
+		})
+		for i, subcall := range call.Calls {
+			subpath := make(CallPath, len(path)+1)
+			copy(subpath, path)
+			subpath[len(path)] = i
+			flattenFrame(subcall, call, subpath, reverted)
+		}
+	}
+	flattenFrame(call, nil, CallPath{}, false)
+	return calls
+}
+
+// Filter returns the calls which match the given condition.
+func Filter(calls []*FlatCall, match func(call *FlatCall) bool) []*FlatCall {
+	var filtered []*FlatCall
+	for _, call := range calls {
+		if match(call) {
+			filtered = append(filtered, call)
+		}
+	}
+	return filtered
+}